@@ -12,7 +12,6 @@ import (
 	"github.com/alexispurslane/org-lsp/lspstream"
 	"github.com/alexispurslane/org-lsp/server"
 	"go.lsp.dev/jsonrpc2"
-	"go.lsp.dev/protocol"
 	"go.uber.org/zap"
 )
 
@@ -33,7 +32,7 @@ func main() {
 	flag.Parse()
 
 	// Create server implementation
-	impl := &server.ServerImpl{}
+	impl := server.New()
 
 	if tcp != "" {
 		slog.Info("org-lsp server starting", "mode", "tcp", "address", tcp)
@@ -48,6 +47,9 @@ func main() {
 			os.Exit(1)
 		}
 	}
+
+	slog.Info("org-lsp server exiting", "code", impl.ExitCode())
+	os.Exit(impl.ExitCode())
 }
 
 func runStdio(impl *server.ServerImpl) error {
@@ -65,10 +67,7 @@ func runStdio(impl *server.ServerImpl) error {
 	} else {
 		stream = lspstream.NewLargeBufferStream(rwc)
 	}
-	ctx, conn, client := protocol.NewServer(ctx, impl, stream, logger)
-	impl.SetClient(client)
-	<-conn.Done()
-	return conn.Err()
+	return impl.Serve(ctx, stream, logger)
 }
 
 func runTCP(impl *server.ServerImpl, addr string) error {
@@ -78,21 +77,31 @@ func runTCP(impl *server.ServerImpl, addr string) error {
 	}
 	defer listener.Close()
 
+	// Stop accepting connections once a client sends exit
+	go func() {
+		<-impl.Exited()
+		listener.Close()
+	}()
+
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
+			select {
+			case <-impl.Exited():
+				return nil
+			default:
+			}
 			slog.Error("Failed to accept connection", "error", err)
 			continue
 		}
 
 		go func() {
 			defer conn.Close()
-			ctx := context.Background()
 			logger, _ := zap.NewProduction()
 			stream := lspstream.NewLargeBufferStream(conn)
-			ctx, srvConn, client := protocol.NewServer(ctx, impl, stream, logger)
-			impl.SetClient(client)
-			<-srvConn.Done()
+			if err := impl.Serve(context.Background(), stream, logger); err != nil {
+				slog.Debug("Connection closed", "error", err)
+			}
 		}()
 	}
 }
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestCallHierarchyIDLinks(t *testing.T) {
+	Given("a target heading linked from two files", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:
+This is the target.`).
+				GivenFile("source1.org", `* Source One
+See [[id:{{.targetID}}][the target]].
+
+** Nested
+Again [[id:{{.targetID}}]].`).
+				GivenFile("source2.org", `* Source Two
+Also [[id:{{.targetID}}]].`).
+				GivenSaveFile("target.org").
+				GivenSaveFile("source1.org").
+				GivenSaveFile("source2.org").
+				GivenOpenFile("target.org").
+				GivenOpenFile("source2.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			prepareAt := func(path string) protocol.CallHierarchyPrepareParams {
+				return protocol.CallHierarchyPrepareParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI(path)},
+						Position:     protocol.Position{Line: 0, Character: 3},
+					},
+				}
+			}
+
+			var target protocol.CallHierarchyItem
+			When(t, tc, "preparing the call hierarchy on the target heading", "textDocument/prepareCallHierarchy", prepareAt("target.org"), func(t *testing.T, items []protocol.CallHierarchyItem) {
+				Then("returns the target heading item", t, func(t *testing.T) {
+					testza.AssertLen(t, items, 1)
+					target = items[0]
+					testza.AssertEqual(t, "Target Heading", target.Name)
+				})
+			})
+
+			incoming := protocol.CallHierarchyIncomingCallsParams{Item: target}
+			When(t, tc, "requesting incoming calls", "callHierarchy/incomingCalls", incoming, func(t *testing.T, calls []protocol.CallHierarchyIncomingCall) {
+				Then("lists the linking headings in both referencing files", t, func(t *testing.T) {
+					testza.AssertLen(t, calls, 3, "Expected Source One, Nested and Source Two")
+
+					callers := make(map[string]protocol.DocumentURI)
+					for _, call := range calls {
+						callers[call.From.Name] = call.From.URI
+						testza.AssertLen(t, call.FromRanges, 1)
+					}
+					testza.AssertEqual(t, tc.DocURI("source1.org"), callers["Source One"])
+					testza.AssertEqual(t, tc.DocURI("source1.org"), callers["Nested"])
+					testza.AssertEqual(t, tc.DocURI("source2.org"), callers["Source Two"])
+				})
+			})
+
+			var source protocol.CallHierarchyItem
+			When(t, tc, "preparing the call hierarchy on a linking heading", "textDocument/prepareCallHierarchy", prepareAt("source2.org"), func(t *testing.T, items []protocol.CallHierarchyItem) {
+				Then("returns the linking heading item", t, func(t *testing.T) {
+					testza.AssertLen(t, items, 1)
+					source = items[0]
+				})
+			})
+
+			outgoing := protocol.CallHierarchyOutgoingCallsParams{Item: source}
+			When(t, tc, "requesting outgoing calls", "callHierarchy/outgoingCalls", outgoing, func(t *testing.T, calls []protocol.CallHierarchyOutgoingCall) {
+				Then("lists the linked target heading", t, func(t *testing.T) {
+					testza.AssertLen(t, calls, 1)
+					testza.AssertEqual(t, "Target Heading", calls[0].To.Name)
+					testza.AssertEqual(t, tc.DocURI("target.org"), calls[0].To.URI)
+				})
+			})
+		},
+	)
+}
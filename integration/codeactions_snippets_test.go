@@ -127,14 +127,8 @@ func applyEdit(content string, r protocol.Range, newText string) string {
 		if endChar > len(lines[endLine]) {
 			endChar = len(lines[endLine])
 		}
-		lines[startLine] = lines[startLine][:startChar] + newText
-		for i := startLine + 1; i <= endLine; i++ {
-			if i < endLine {
-				lines[i] = ""
-			} else {
-				lines[i] = lines[i][endChar:]
-			}
-		}
+		lines[startLine] = lines[startLine][:startChar] + newText + lines[endLine][endChar:]
+		lines = append(lines[:startLine+1], lines[endLine+1:]...)
 	}
 
 	return strings.Join(lines, "\n")
@@ -548,10 +542,83 @@ func TestAddTagsToExistingEndToEnd(t *testing.T) {
 
 // TestSetCustomIDEndToEnd tests setting a custom ID property
 func TestSetCustomIDEndToEnd(t *testing.T) {
-	Given("a heading without custom ID", t,
+	cases := []struct {
+		given    string
+		content  string
+		line     uint32
+		then     string
+		expected string
+	}{
+		{
+			given:    "a heading without custom ID",
+			content:  "* My Great Heading!\nSome content",
+			then:     "properties drawer is created with the slugified title",
+			expected: "* My Great Heading!\n:PROPERTIES:\n:CUSTOM_ID: my-great-heading\n:END:\nSome content",
+		},
+		{
+			given:    "a tagged heading without custom ID",
+			content:  "* My Great Heading :work:\nSome content",
+			then:     "the drawer goes below the headline and the tags are untouched",
+			expected: "* My Great Heading :work:\n:PROPERTIES:\n:CUSTOM_ID: my-great-heading\n:END:\nSome content",
+		},
+		{
+			given:    "a scheduled heading without custom ID",
+			content:  "* Task\nSCHEDULED: <2024-01-01 Mon>\nSome content",
+			then:     "the drawer goes below the planning line",
+			expected: "* Task\nSCHEDULED: <2024-01-01 Mon>\n:PROPERTIES:\n:CUSTOM_ID: task\n:END:\nSome content",
+		},
+		{
+			given:    "a heading whose slug is already used in the file",
+			content:  "* Notes\n:PROPERTIES:\n:CUSTOM_ID: notes\n:END:\n* Notes\n:PROPERTIES:\n:ID: abc\n:END:\n",
+			line:     4,
+			then:     "a counter is appended and the existing drawer is kept",
+			expected: "* Notes\n:PROPERTIES:\n:CUSTOM_ID: notes\n:END:\n* Notes\n:PROPERTIES:\n:ID: abc\n:CUSTOM_ID: notes-2\n:END:\n",
+		},
+	}
+
+	for _, c := range cases {
+		Given(c.given, t,
+			func(t *testing.T) *LSPTestContext {
+				tc := NewTestContext(t)
+				tc.GivenFile("test.org", c.content).
+					GivenOpenFile("test.org")
+				return tc
+			},
+			func(t *testing.T, tc *LSPTestContext) {
+				params := protocol.CodeActionParams{
+					TextDocument: protocol.TextDocumentIdentifier{
+						URI: tc.DocURI("test.org"),
+					},
+					Range: protocol.Range{
+						Start: protocol.Position{Line: c.line, Character: 0},
+						End:   protocol.Position{Line: c.line, Character: 0},
+					},
+				}
+
+				When(t, tc, "applying Set CUSTOM_ID action", "textDocument/codeAction", params,
+					func(t *testing.T, actions []protocol.CodeAction) {
+						action := findAction(actions, "Org: Set CUSTOM_ID")
+						testza.AssertNotNil(t, action, "Expected Set CUSTOM_ID action")
+
+						edit := action.Edit.Changes[tc.DocURI("test.org")][0]
+						result := applyEdit(c.content, edit.Range, edit.NewText)
+
+						Then(c.then, t, func(t *testing.T) {
+							testza.AssertEqual(t, c.expected, result)
+						})
+					})
+			})
+	}
+}
+
+// TestAddIDEndToEnd tests giving a single heading an :ID: property
+func TestAddIDEndToEnd(t *testing.T) {
+	content := "* First\nSome content\n* Second\n:PROPERTIES:\n:EFFORT: 1:00\n:END:\n* Third\n"
+
+	Given("several headings without IDs", t,
 		func(t *testing.T) *LSPTestContext {
 			tc := NewTestContext(t)
-			tc.GivenFile("test.org", "* My Heading Title\nSome content").
+			tc.GivenFile("test.org", content).
 				GivenOpenFile("test.org")
 			return tc
 		},
@@ -562,21 +629,108 @@ func TestSetCustomIDEndToEnd(t *testing.T) {
 				},
 				Range: protocol.Range{
 					Start: protocol.Position{Line: 0, Character: 0},
-					End:   protocol.Position{Line: 0, Character: 10},
+					End:   protocol.Position{Line: 0, Character: 0},
 				},
 			}
 
-			When(t, tc, "applying Set CUSTOM_ID action", "textDocument/codeAction", params,
+			When(t, tc, "applying Add ID to the first heading", "textDocument/codeAction", params,
 				func(t *testing.T, actions []protocol.CodeAction) {
-					action := findAction(actions, "Org: Set CUSTOM_ID")
-					testza.AssertNotNil(t, action, "Expected Set CUSTOM_ID action")
+					action := findAction(actions, "Org: Add ID to this heading")
+					testza.AssertNotNil(t, action, "Expected Add ID action")
+
+					edits := action.Edit.Changes[tc.DocURI("test.org")]
+					testza.AssertLen(t, edits, 1, "Expected a single edit")
+					result := applyEdit(content, edits[0].Range, edits[0].NewText)
+
+					Then("only the first heading gets a drawer with a UUID", t, func(t *testing.T) {
+						lines := strings.Split(result, "\n")
+						testza.AssertEqual(t, ":PROPERTIES:", lines[1])
+						testza.AssertRegexp(t, `^:ID: [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, lines[2])
+						testza.AssertEqual(t, ":END:", lines[3])
+						testza.AssertEqual(t, "Some content\n* Second\n:PROPERTIES:\n:EFFORT: 1:00\n:END:\n* Third\n", strings.Join(lines[4:], "\n"))
+					})
+				})
 
-					originalContent := "* My Heading Title\nSome content"
-					result := applySnippetEdit(originalContent, action)
+			params.Range = protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 0},
+				End:   protocol.Position{Line: 2, Character: 0},
+			}
+			When(t, tc, "applying Add ID to a heading with a drawer", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					action := findAction(actions, "Org: Add ID to this heading")
+					testza.AssertNotNil(t, action, "Expected Add ID action")
 
-					Then("properties drawer is created with custom ID", t, func(t *testing.T) {
-						expected := "* My Heading Title\n:PROPERTIES:\n:CUSTOM_ID: ${1:my-heading-title}\n:END:\nSome content"
-						testza.AssertEqual(t, expected, result)
+					edit := action.Edit.Changes[tc.DocURI("test.org")][0]
+					result := applyEdit(content, edit.Range, edit.NewText)
+
+					Then("the ID is added to the existing drawer", t, func(t *testing.T) {
+						lines := strings.Split(result, "\n")
+						testza.AssertEqual(t, []string{":PROPERTIES:", ":EFFORT: 1:00"}, lines[3:5])
+						testza.AssertRegexp(t, `^:ID: [0-9a-f-]{36}$`, lines[5])
+						testza.AssertEqual(t, ":END:\n* Third\n", strings.Join(lines[6:], "\n"))
+					})
+				})
+		})
+
+	scheduled := "* Task\nSCHEDULED: <2026-10-20 Tue>\nSome content\n"
+
+	Given("a scheduled heading without a drawer", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", scheduled).
+				GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("test.org"),
+				},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 0},
+					End:   protocol.Position{Line: 0, Character: 0},
+				},
+			}
+
+			When(t, tc, "applying Add ID", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					action := findAction(actions, "Org: Add ID to this heading")
+					testza.AssertNotNil(t, action, "Expected Add ID action")
+
+					edit := action.Edit.Changes[tc.DocURI("test.org")][0]
+					result := applyEdit(scheduled, edit.Range, edit.NewText)
+
+					Then("the drawer goes below the planning line", t, func(t *testing.T) {
+						lines := strings.Split(result, "\n")
+						testza.AssertEqual(t, []string{"* Task", "SCHEDULED: <2026-10-20 Tue>", ":PROPERTIES:"}, lines[:3])
+						testza.AssertRegexp(t, `^:ID: [0-9a-f-]{36}$`, lines[3])
+						testza.AssertEqual(t, ":END:\nSome content\n", strings.Join(lines[4:], "\n"))
+					})
+				})
+		})
+
+	Given("a heading that already has an ID", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", "* Task\n:PROPERTIES:\n:ID: abc\n:END:\n").
+				GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("test.org"),
+				},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 0},
+					End:   protocol.Position{Line: 0, Character: 0},
+				},
+			}
+
+			When(t, tc, "requesting code actions", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("Add ID action is not offered", t, func(t *testing.T) {
+						testza.AssertNil(t, findAction(actions, "Org: Add ID to this heading"))
 					})
 				})
 		})
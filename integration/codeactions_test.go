@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/MarvinJWendt/testza"
@@ -485,3 +486,767 @@ There's a problem with just using immigration as a panacea for this, though: it'
 		},
 	)
 }
+
+func TestCodeBlockEvaluateAction(t *testing.T) {
+	source := `* Script
+#+begin_src python
+print("hello")
+#+end_src
+`
+	params := func(tc *LSPTestContext) protocol.CodeActionParams {
+		return protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 0},
+				End:   protocol.Position{Line: 2, Character: 0},
+			},
+		}
+	}
+
+	Given("a python src block and no allowed languages", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", source).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "requesting code actions inside the block", "textDocument/codeAction", params(tc),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("does not offer to evaluate the block", t, func(t *testing.T) {
+						for _, action := range actions {
+							testza.AssertFalse(t, strings.HasPrefix(action.Title, "Evaluate"), "Unexpected action %q", action.Title)
+						}
+					})
+				})
+		},
+	)
+
+	Given("a python src block with python allowed", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages": []string{"python"},
+			})
+			tc.GivenFile("test.org", source).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "requesting code actions inside the block", "textDocument/codeAction", params(tc),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("offers to evaluate the block", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Evaluate python code block" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Should offer the evaluate action")
+						testza.AssertEqual(t, "org.executeCodeBlock", found.Command.Command)
+					})
+				})
+		},
+	)
+}
+
+func TestWrapBareURLAction(t *testing.T) {
+	Given("a paragraph containing a bare URL", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Links
+See https://example.com/docs for details.
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			findWrap := func(actions []protocol.CodeAction) *protocol.CodeAction {
+				for i, action := range actions {
+					if action.Title == "Org: Wrap in org link" {
+						return &actions[i]
+					}
+				}
+				return nil
+			}
+
+			cursor := tc.PosAfter("test.org", "https://exa")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the URL", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("wraps the URL in a bracket link", t, func(t *testing.T) {
+						action := findWrap(actions)
+						testza.AssertNotNil(t, action, "Expected a wrap in org link action")
+
+						edits := action.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "[[https://example.com/docs]]", edits[0].NewText)
+						testza.AssertEqual(t, uint32(4), edits[0].Range.Start.Character)
+						testza.AssertEqual(t, uint32(28), edits[0].Range.End.Character)
+					})
+				})
+
+			selection := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range: protocol.Range{
+					Start: tc.PosBefore("test.org", "https://"),
+					End:   tc.PosAfter("test.org", "for details"),
+				},
+			}
+
+			When(t, tc, "selecting the URL and following text", "textDocument/codeAction", selection,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("uses the following text as the description", t, func(t *testing.T) {
+						action := findWrap(actions)
+						testza.AssertNotNil(t, action, "Expected a wrap in org link action")
+						testza.AssertEqual(t, "[[https://example.com/docs][for details]]", action.Edit.Changes[tc.DocURI("test.org")][0].NewText)
+					})
+				})
+		},
+	)
+}
+
+func TestCheckboxToggleAction(t *testing.T) {
+	Given("a heading with a statistics cookie and a checkbox list", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Groceries [1/3]
+- [X] milk
+- [ ] eggs
+- [ ] bread
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "egg")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on an unchecked item", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					var found *protocol.CodeAction
+					for i, action := range actions {
+						if action.Title == "Org: Check item" {
+							found = &actions[i]
+						}
+					}
+
+					Then("toggles the checkbox to [X]", t, func(t *testing.T) {
+						testza.AssertNotNil(t, found, "Expected a check item action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 2, "Expected checkbox and cookie edits")
+						testza.AssertEqual(t, "X", edits[0].NewText)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(3), edits[0].Range.Start.Character)
+					})
+
+					Then("updates the statistics cookie", t, func(t *testing.T) {
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertEqual(t, "[2/3]", edits[1].NewText)
+						testza.AssertEqual(t, uint32(0), edits[1].Range.Start.Line)
+						testza.AssertEqual(t, uint32(12), edits[1].Range.Start.Character)
+					})
+				})
+		},
+	)
+
+	Given("a checked item under a percentage cookie", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Chores
+- Weekend [100%]
+  - [X] laundry
+  - [X] dishes
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "laun")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on a checked item", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("unchecks the item and recomputes the percentage", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Uncheck item" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected an uncheck item action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 2)
+						testza.AssertEqual(t, " ", edits[0].NewText)
+						testza.AssertEqual(t, "[50%]", edits[1].NewText)
+						testza.AssertEqual(t, uint32(1), edits[1].Range.Start.Line)
+					})
+				})
+		},
+	)
+}
+
+func TestTimestampToggleAction(t *testing.T) {
+	Given("a heading with an active and an inactive timestamp", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Meeting
+SCHEDULED: <2024-01-01 Mon>
+Created [2024-01-01 Mon]
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			actionAt := func(marker string) protocol.CodeActionParams {
+				cursor := tc.PosAfter("test.org", marker)
+				return protocol.CodeActionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+					Range:        protocol.Range{Start: cursor, End: cursor},
+				}
+			}
+			findAction := func(actions []protocol.CodeAction, title string) *protocol.CodeAction {
+				for i, action := range actions {
+					if action.Title == title {
+						return &actions[i]
+					}
+				}
+				return nil
+			}
+
+			When(t, tc, "requesting code actions on the active timestamp", "textDocument/codeAction", actionAt("<2024-01"),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("makes it inactive", t, func(t *testing.T) {
+						found := findAction(actions, "Org: Make timestamp inactive")
+						testza.AssertNotNil(t, found, "Expected a make inactive action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "[2024-01-01 Mon]", edits[0].NewText)
+						testza.AssertEqual(t, uint32(1), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(11), edits[0].Range.Start.Character)
+						testza.AssertEqual(t, uint32(27), edits[0].Range.End.Character)
+					})
+				})
+
+			When(t, tc, "requesting code actions on the inactive timestamp", "textDocument/codeAction", actionAt("[2024-01"),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("makes it active", t, func(t *testing.T) {
+						found := findAction(actions, "Org: Make timestamp active")
+						testza.AssertNotNil(t, found, "Expected a make active action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "<2024-01-01 Mon>", edits[0].NewText)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(8), edits[0].Range.Start.Character)
+					})
+				})
+		},
+	)
+}
+
+func TestCodeBlockLanguageWithSwitches(t *testing.T) {
+	Given("a python src block with extra header arguments", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages": []string{"python"},
+			})
+			tc.GivenFile("test.org", `* Script
+#+begin_src python -n :results output
+print("hello")
+#+end_src
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 2, Character: 0},
+					End:   protocol.Position{Line: 2, Character: 0},
+				},
+			}
+
+			When(t, tc, "requesting code actions inside the block", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("still detects the language as python", t, func(t *testing.T) {
+						titles := make([]string, 0, len(actions))
+						for _, action := range actions {
+							titles = append(titles, action.Title)
+						}
+						testza.AssertContains(t, strings.Join(titles, "\n"), "Evaluate python code block")
+					})
+				})
+		},
+	)
+}
+
+func TestWrapSelectionInBlockAction(t *testing.T) {
+	Given("a paragraph of pasted text", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Quotes
+The first line of the quote.
+The second line of the quote.
+After the quote.
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 0},
+					End:   protocol.Position{Line: 3, Character: 0},
+				},
+			}
+
+			When(t, tc, "requesting code actions for two selected lines", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("wraps the lines in a quote block", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Wrap selection in #+begin_quote" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected a wrap in quote block action")
+
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "#+begin_quote\nThe first line of the quote.\nThe second line of the quote.\n#+end_quote", edits[0].NewText)
+						testza.AssertEqual(t, uint32(1), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.End.Line, "Should not swallow the line after the selection")
+					})
+				})
+		},
+	)
+}
+
+func TestSortListItemsAction(t *testing.T) {
+	Given("an out-of-order bullet list with a nested sublist", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Shopping
+- pears
+- apples
+  - green
+  - red
+- [X] bananas
+
+After the list.
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "- pea")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the list", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					findTitle := func(title string) *protocol.CodeAction {
+						for i, action := range actions {
+							if action.Title == title {
+								return &actions[i]
+							}
+						}
+						return nil
+					}
+
+					Then("sorts the items alphabetically, keeping sublists with their parent", t, func(t *testing.T) {
+						action := findTitle("Org: Sort list items alphabetically")
+						testza.AssertNotNil(t, action, "Expected a sort list action")
+
+						edits := action.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "- apples\n  - green\n  - red\n- [X] bananas\n- pears", edits[0].NewText)
+						testza.AssertEqual(t, uint32(1), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(5), edits[0].Range.End.Line, "Should not replace the blank line after the list")
+					})
+
+					Then("offers to sort checked items last", t, func(t *testing.T) {
+						action := findTitle("Org: Sort list items with checked last")
+						testza.AssertNotNil(t, action, "Expected a checked-last sort action")
+						testza.AssertEqual(t, "- pears\n- apples\n  - green\n  - red\n- [X] bananas", action.Edit.Changes[tc.DocURI("test.org")][0].NewText)
+					})
+				})
+		},
+	)
+}
+
+func TestTableOfContentsAction(t *testing.T) {
+	Given("a document with a #+TOC marker and nested headings", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `#+TITLE: Guide
+#+TOC: headlines
+* Introduction
+** Background
+* TODO Usage :docs:
+Text.
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "#+TOC")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the marker", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("inserts every heading as a link after the marker", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Insert table of contents" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected an insert table of contents action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "\n- [[*Introduction][Introduction]]\n  - [[*Background][Background]]\n- [[*Usage][Usage]]", edits[0].NewText)
+						testza.AssertEqual(t, uint32(1), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(1), edits[0].Range.End.Line)
+					})
+				})
+		},
+	)
+
+	Given("a <<toc>> target followed by a stale table of contents", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `<<toc>>
+- [[*Old][Old]]
+- [[*Gone][Gone]]
+
+* New
+* Other
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "[[*Go")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions inside the old list", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("replaces the old list with the current headings", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Update table of contents" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected an update table of contents action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "\n- [[*New][New]]\n- [[*Other][Other]]", edits[0].NewText)
+						testza.AssertEqual(t, uint32(0), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.End.Line)
+					})
+				})
+		},
+	)
+
+	bracketed := `#+TOC: headlines
+* Notes [draft]
+* Setup [v2]
+:PROPERTIES:
+:CUSTOM_ID: setup
+:END:
+* Plain
+`
+
+	Given("headings with brackets in their titles", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", bracketed).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "#+TOC")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the marker", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("links them by CUSTOM_ID, adding one where missing", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Insert table of contents" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected an insert table of contents action")
+						expected := `#+TOC: headlines
+- [[#notes-draft][Notes {draft}]]
+- [[#setup][Setup {v2}]]
+- [[*Plain][Plain]]
+* Notes [draft]
+:PROPERTIES:
+:CUSTOM_ID: notes-draft
+:END:
+* Setup [v2]
+:PROPERTIES:
+:CUSTOM_ID: setup
+:END:
+* Plain
+`
+						testza.AssertEqual(t, expected, applyEdits(t, tc, "test.org", found.Edit.Changes[tc.DocURI("test.org")]))
+					})
+				})
+		},
+	)
+
+	Given("a stale table of contents with CUSTOM_ID entries", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `#+TOC: headlines
+- [[#notes-draft][Notes {draft}]]
+- [[*Gone][Gone]]
+* Notes [draft]
+:PROPERTIES:
+:CUSTOM_ID: notes-draft
+:END:
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "#+TOC")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the marker", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("replaces every old entry without duplicating them", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Update table of contents" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected an update table of contents action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "\n- [[#notes-draft][Notes {draft}]]", edits[0].NewText)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.End.Line)
+					})
+				})
+		},
+	)
+}
+
+func TestSplitHeadingAction(t *testing.T) {
+	content := "* Notes\n** Project\nFirst body line.\n  Second body line.\n"
+
+	Given("a level 2 heading with two body lines", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", content).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := tc.PosAfter("test.org", "Second")
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "requesting code actions on the second body line", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("splits it into a sibling heading at the same level", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Split heading here" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected a split heading action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "* Notes\n** Project\nFirst body line.\n** Second body line.\n",
+							applyEdit(content, edits[0].Range, edits[0].NewText))
+					})
+				})
+
+			headingParams := params
+			headingParams.Range.Start = protocol.Position{Line: 1, Character: 4}
+			headingParams.Range.End = headingParams.Range.Start
+			When(t, tc, "requesting code actions on the heading line", "textDocument/codeAction", headingParams,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("offers no split", t, func(t *testing.T) {
+						for _, action := range actions {
+							testza.AssertNotEqual(t, "Org: Split heading here", action.Title)
+						}
+					})
+				})
+		},
+	)
+
+	structured := `* Project
+SCHEDULED: <2026-10-20 Tue>
+#+CAPTION: Results
+| a | b |
+#+BEGIN_SRC python
+print("hi")
+#+END_SRC
+#+BEGIN_EXAMPLE
+sample output
+#+END_EXAMPLE
+`
+
+	Given("a heading whose body has planning, keyword, table, and block lines", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", structured).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			for _, c := range []struct {
+				name   string
+				marker string
+			}{
+				{"the planning line", "SCHEDULED"},
+				{"a keyword line", "#+CAPTION"},
+				{"a table row", "| a"},
+				{"a block delimiter", "#+BEGIN_SRC"},
+				{"a line inside a src block", "print"},
+				{"a line inside an example block", "sample"},
+			} {
+				cursor := tc.PosAfter("test.org", c.marker)
+				params := protocol.CodeActionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+					Range:        protocol.Range{Start: cursor, End: cursor},
+				}
+				When(t, tc, "requesting code actions on "+c.name, "textDocument/codeAction", params,
+					func(t *testing.T, actions []protocol.CodeAction) {
+						Then("offers no split", t, func(t *testing.T) {
+							for _, action := range actions {
+								testza.AssertNotEqual(t, "Org: Split heading here", action.Title)
+							}
+						})
+					})
+			}
+		},
+	)
+}
+
+func TestMergeHeadingAction(t *testing.T) {
+	content := "* Inbox\n** First\nFirst body.\n*** First child\n** Second\nSecond body.\n*** Second child\n* Archive\n"
+
+	Given("two adjacent sibling headings with bodies and subheadings", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", content).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			actionAt := func(line uint32) protocol.CodeActionParams {
+				cursor := protocol.Position{Line: line, Character: 4}
+				return protocol.CodeActionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+					Range:        protocol.Range{Start: cursor, End: cursor},
+				}
+			}
+			findMerge := func(actions []protocol.CodeAction) *protocol.CodeAction {
+				for i, action := range actions {
+					if action.Title == "Org: Merge with previous heading" {
+						return &actions[i]
+					}
+				}
+				return nil
+			}
+
+			When(t, tc, "requesting code actions on the second heading", "textDocument/codeAction", actionAt(4),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("demotes it into the first heading's body, ahead of the subheadings", t, func(t *testing.T) {
+						found := findMerge(actions)
+						testza.AssertNotNil(t, found, "Expected a merge action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t,
+							"* Inbox\n** First\nFirst body.\nSecond\nSecond body.\n*** First child\n*** Second child\n* Archive\n",
+							applyEdit(content, edits[0].Range, edits[0].NewText))
+					})
+				})
+
+			When(t, tc, "requesting code actions on a first sibling", "textDocument/codeAction", actionAt(1),
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("offers no merge", t, func(t *testing.T) {
+						testza.AssertNil(t, findMerge(actions))
+					})
+				})
+		},
+	)
+
+	scheduled := `* Inbox
+** First
+:PROPERTIES:
+:ID: first-id
+:END:
+First body.
+** Second
+SCHEDULED: <2026-10-20 Tue>
+:PROPERTIES:
+:ID: second-id
+:EFFORT: 1:00
+:END:
+Second body.
+`
+
+	Given("a scheduled sibling with its own property drawer", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", scheduled).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			cursor := protocol.Position{Line: 6, Character: 4}
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range:        protocol.Range{Start: cursor, End: cursor},
+			}
+
+			When(t, tc, "merging it into the previous heading", "textDocument/codeAction", params,
+				func(t *testing.T, actions []protocol.CodeAction) {
+					Then("moves its planning line and new properties up instead of into the body", t, func(t *testing.T) {
+						var found *protocol.CodeAction
+						for i, action := range actions {
+							if action.Title == "Org: Merge with previous heading" {
+								found = &actions[i]
+							}
+						}
+						testza.AssertNotNil(t, found, "Expected a merge action")
+						edits := found.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, edits, 1)
+						expected := `* Inbox
+** First
+SCHEDULED: <2026-10-20 Tue>
+:PROPERTIES:
+:ID: first-id
+:EFFORT: 1:00
+:END:
+First body.
+Second
+Second body.
+`
+						testza.AssertEqual(t, expected, applyEdit(scheduled, edits[0].Range, edits[0].NewText))
+					})
+				})
+		},
+	)
+}
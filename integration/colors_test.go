@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestDocumentColor(t *testing.T) {
+	Given("a document with a hex color code and a hashtag", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("palette.org", "* Palette\nBrand red is #ff0000, see #add.").GivenOpenFile("palette.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentColorParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("palette.org")},
+			}
+
+			When(t, tc, "requesting document colors", "textDocument/documentColor", params, func(t *testing.T, colors []protocol.ColorInformation) {
+				Then("returns the red color at its range", t, func(t *testing.T) {
+					testza.AssertLen(t, colors, 1)
+					testza.AssertEqual(t, protocol.Color{Red: 1, Green: 0, Blue: 0, Alpha: 1}, colors[0].Color)
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 1, Character: 13},
+						End:   protocol.Position{Line: 1, Character: 20},
+					}, colors[0].Range)
+				})
+			})
+
+			presentationParams := protocol.ColorPresentationParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("palette.org")},
+				Color:        protocol.Color{Red: 0, Green: 0.5, Blue: 1, Alpha: 1},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 13},
+					End:   protocol.Position{Line: 1, Character: 20},
+				},
+			}
+
+			When(t, tc, "requesting a presentation for a picked color", "textDocument/colorPresentation", presentationParams, func(t *testing.T, presentations []protocol.ColorPresentation) {
+				Then("offers the hex string", t, func(t *testing.T) {
+					testza.AssertLen(t, presentations, 1)
+					testza.AssertEqual(t, "#0080ff", presentations[0].Label)
+					testza.AssertEqual(t, "#0080ff", presentations[0].TextEdit.NewText)
+				})
+			})
+		},
+	)
+}
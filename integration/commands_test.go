@@ -0,0 +1,759 @@
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/MarvinJWendt/testza"
+	ourserver "github.com/alexispurslane/org-lsp/server"
+	"go.lsp.dev/protocol"
+)
+
+func TestExecuteCodeBlockCommand(t *testing.T) {
+	Given("a file with a bash src block", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages": []string{"bash"},
+			})
+			tc.GivenFile("test.org", `* Script
+#+begin_src bash
+echo hello from bash
+#+end_src
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.executeCodeBlock",
+				Arguments: []any{string(tc.DocURI("test.org")), 1, 0},
+			}
+
+			When(t, tc, "executing the code block command", "workspace/executeCommand", params,
+				func(t *testing.T, output string) {
+					Then("returns the command output", t, func(t *testing.T) {
+						testza.AssertContains(t, output, "hello from bash", "Output should contain echoed text")
+					})
+
+					Then("asks the client to insert a results section", t, func(t *testing.T) {
+						edits := tc.PollNotification("workspace/applyEdit", 2*time.Second)
+						testza.AssertLen(t, edits, 1, "Should send one applyEdit request")
+
+						var applyParams protocol.ApplyWorkspaceEditParams
+						testza.AssertNoError(t, json.Unmarshal(edits[0], &applyParams))
+
+						textEdits := applyParams.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, textEdits, 1, "Should have one text edit")
+						testza.AssertEqual(t, uint32(4), textEdits[0].Range.Start.Line, "Results should go below #+end_src")
+						testza.AssertContains(t, textEdits[0].NewText, "#+RESULTS:\n: hello from bash", "Should insert fixed-width results")
+					})
+				})
+		},
+	)
+
+	Given("a file with a src block that already has results", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages": []string{"bash"},
+			})
+			tc.GivenFile("test.org", `* Script
+#+begin_src bash
+echo fresh
+#+end_src
+
+#+RESULTS:
+: stale
+
+After results.
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.executeCodeBlock",
+				Arguments: []any{string(tc.DocURI("test.org")), 1, 0},
+			}
+
+			When(t, tc, "executing the code block command", "workspace/executeCommand", params,
+				func(t *testing.T, output string) {
+					Then("replaces the existing results section", t, func(t *testing.T) {
+						edits := tc.PollNotification("workspace/applyEdit", 2*time.Second)
+						testza.AssertLen(t, edits, 1, "Should send one applyEdit request")
+
+						var applyParams protocol.ApplyWorkspaceEditParams
+						testza.AssertNoError(t, json.Unmarshal(edits[0], &applyParams))
+
+						textEdits := applyParams.Edit.Changes[tc.DocURI("test.org")]
+						testza.AssertLen(t, textEdits, 1, "Should have one text edit")
+						testza.AssertEqual(t, uint32(5), textEdits[0].Range.Start.Line, "Should start at #+RESULTS:")
+						testza.AssertEqual(t, uint32(6), textEdits[0].Range.End.Line, "Should end at last result line")
+						testza.AssertEqual(t, "#+RESULTS:\n: fresh", textEdits[0].NewText)
+					})
+				})
+		},
+	)
+}
+
+func TestExecuteCodeBlockLimits(t *testing.T) {
+	Given("a bash src block that never finishes and a short timeout", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages":        []string{"bash"},
+				"codeExecutionTimeoutSeconds": 0.5,
+			})
+			tc.GivenFile("test.org", `* Script
+#+begin_src bash
+sleep 30
+#+end_src
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.executeCodeBlock",
+				Arguments: []any{string(tc.DocURI("test.org")), 1, 0},
+			}
+
+			t.Run("when executing the code block command", func(t *testing.T) {
+				start := time.Now()
+				var output string
+				_, err := tc.conn.Call(tc.ctx, "workspace/executeCommand", params, &output)
+				elapsed := time.Since(start)
+
+				Then("fails with a timeout error", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertContains(t, err.Error(), "timed out", "Error should mention the timeout")
+				})
+
+				Then("returns well before the block would finish", t, func(t *testing.T) {
+					testza.AssertLess(t, elapsed.Seconds(), 5.0, "Should be killed at the timeout")
+				})
+			})
+		},
+	)
+
+	Given("a bash src block with a lot of output and a small output limit", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"allowedCodeLanguages": []string{"bash"},
+				"maxCodeOutputBytes":   1024,
+			})
+			tc.GivenFile("test.org", `* Script
+#+begin_src bash
+yes org-lsp | head -n 10000
+#+end_src
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.executeCodeBlock",
+				Arguments: []any{string(tc.DocURI("test.org")), 1, 0},
+			}
+
+			When(t, tc, "executing the code block command", "workspace/executeCommand", params,
+				func(t *testing.T, output string) {
+					Then("truncates the output with a notice", t, func(t *testing.T) {
+						testza.AssertContains(t, output, "org-lsp\norg-lsp", "Should keep the start of the output")
+						testza.AssertContains(t, output, "[output truncated at 1024 bytes]", "Should note the truncation")
+						testza.AssertLess(t, len(output), 1100, "Should cap the captured output")
+					})
+				})
+		},
+	)
+}
+
+func TestExecuteCodeBlockRequiresAllowedLanguage(t *testing.T) {
+	Given("a bash src block and no allowed languages", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", `* Script
+#+begin_src bash
+echo should not run
+#+end_src
+`).GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.executeCodeBlock",
+				Arguments: []any{string(tc.DocURI("test.org")), 1, 0},
+			}
+
+			t.Run("when executing the code block command", func(t *testing.T) {
+				var output string
+				_, err := tc.conn.Call(tc.ctx, "workspace/executeCommand", params, &output)
+
+				Then("refuses to run the block", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertContains(t, err.Error(), "not allowed", "Error should explain the refusal")
+					testza.AssertEqual(t, 0, tc.NotificationCount("workspace/applyEdit"), "Should not insert results")
+				})
+			})
+		},
+	)
+}
+
+func TestRefileCommand(t *testing.T) {
+	source := `* Inbox
+** Call the plumber
+Before Friday.
+*** Find their number
+* Someday
+`
+	target := `* Projects
+** House
+Renovation notes.
+* Archive
+`
+
+	Given("a subtree in a.org and a target heading in b.org", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("a.org", source).
+				GivenFile("b.org", target).
+				GivenOpenFile("a.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.refile",
+				Arguments: []any{string(tc.DocURI("a.org")), 1, 0, string(tc.DocURI("b.org")), "House"},
+			}
+
+			When(t, tc, "refiling the subtree under House", "workspace/executeCommand", params,
+				func(t *testing.T, result protocol.WorkspaceEdit) {
+					Then("removes the subtree from the source", t, func(t *testing.T) {
+						edits := result.Changes[tc.DocURI("a.org")]
+						testza.AssertLen(t, edits, 1)
+						testza.AssertEqual(t, "* Inbox\n* Someday\n", applyEdit(source, edits[0].Range, edits[0].NewText))
+					})
+
+					Then("inserts it at the end of the parent with adjusted levels", t, func(t *testing.T) {
+						edits := result.Changes[tc.DocURI("b.org")]
+						testza.AssertLen(t, edits, 1)
+						expected := `* Projects
+** House
+Renovation notes.
+*** Call the plumber
+Before Friday.
+**** Find their number
+* Archive
+`
+						testza.AssertEqual(t, expected, applyEdit(target, edits[0].Range, edits[0].NewText))
+					})
+
+					Then("asks the client to apply the edit", t, func(t *testing.T) {
+						testza.AssertLen(t, tc.PollNotification("workspace/applyEdit", 2*time.Second), 1)
+					})
+				})
+		},
+	)
+}
+
+func TestArchiveCommand(t *testing.T) {
+	source := `#+CATEGORY: chores
+#+ARCHIVE: done.org::* Finished
+* DONE Call the plumber
+Fixed the sink.
+* TODO Paint the fence
+`
+
+	Given("a file whose #+ARCHIVE: points at a file that doesn't exist yet", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", source).GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.archiveSubtree",
+				Arguments: []any{string(tc.DocURI("tasks.org")), 2, 0},
+			}
+
+			When(t, tc, "archiving the finished heading", "workspace/executeCommand", params,
+				func(t *testing.T, result protocol.WorkspaceEdit) {
+					Then("removes the subtree from the source", t, func(t *testing.T) {
+						edits := result.Changes[tc.DocURI("tasks.org")]
+						testza.AssertLen(t, edits, 1)
+						expected := "#+CATEGORY: chores\n#+ARCHIVE: done.org::* Finished\n* TODO Paint the fence\n"
+						testza.AssertEqual(t, expected, applyEdit(source, edits[0].Range, edits[0].NewText))
+					})
+
+					Then("creates the archive file with its heading", t, func(t *testing.T) {
+						data, err := os.ReadFile(filepath.Join(tc.tempDir, "done.org"))
+						testza.AssertNoError(t, err)
+						testza.AssertEqual(t, "* Finished\n", string(data))
+					})
+
+					Then("files the subtree under the archive heading", t, func(t *testing.T) {
+						edits := result.Changes[tc.DocURI("done.org")]
+						testza.AssertLen(t, edits, 1)
+						expected := "* Finished\n** DONE Call the plumber\nFixed the sink.\n"
+						testza.AssertEqual(t, expected, applyEdit("* Finished\n", edits[0].Range, edits[0].NewText))
+					})
+				})
+		},
+	)
+}
+
+func TestArchiveCommandRefusals(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "evil", "x.org")
+
+	archive := func(tc *LSPTestContext, line int) error {
+		params := protocol.ExecuteCommandParams{
+			Command:   "org.archiveSubtree",
+			Arguments: []any{string(tc.DocURI("tasks.org")), line, 0},
+		}
+		var edit protocol.WorkspaceEdit
+		_, err := tc.conn.Call(tc.ctx, "workspace/executeCommand", params, &edit)
+		return err
+	}
+
+	Given("a file whose #+ARCHIVE: points outside the workspace", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", "#+ARCHIVE: "+outside+"::\n* DONE Call the plumber\n").GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			t.Run("when archiving the heading", func(t *testing.T) {
+				err := archive(tc, 1)
+
+				Then("refuses and creates nothing", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertContains(t, err.Error(), "outside the workspace")
+					testza.AssertNoFileExists(t, outside)
+				})
+			})
+		},
+	)
+
+	Given("a file whose archive file doesn't exist yet and a line without a heading", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", "#+ARCHIVE: done.org::* Finished\nJust a note.\n").GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			t.Run("when archiving that line", func(t *testing.T) {
+				err := archive(tc, 1)
+
+				Then("fails without leaving an archive file behind", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertNoFileExists(t, filepath.Join(tc.tempDir, "done.org"))
+				})
+			})
+		},
+	)
+}
+
+func TestAgendaCommand(t *testing.T) {
+	Given("headings scheduled today and next month", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.TestData["today"] = time.Now().Format("2006-01-02")
+			tc.TestData["later"] = time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+			tc.GivenFile("tasks.org", `* TODO Water the plants
+SCHEDULED: <{{.today}}>
+* Plain heading
+* DONE Renew passport
+DEADLINE: <{{.later}}>
+`).GivenSaveFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.agenda",
+				Arguments: []any{tc.TestData["today"], tc.TestData["today"]},
+			}
+
+			When(t, tc, "requesting the agenda for today", "workspace/executeCommand", params,
+				func(t *testing.T, items []ourserver.AgendaItem) {
+					Then("returns only the heading scheduled today", t, func(t *testing.T) {
+						testza.AssertLen(t, items, 1, "Expected exactly one agenda item")
+						testza.AssertEqual(t, ourserver.AgendaItem{
+							Title:    "Water the plants",
+							File:     string(tc.DocURI("tasks.org")),
+							Line:     0,
+							Date:     tc.TestData["today"],
+							Kind:     "SCHEDULED",
+							State:    "TODO",
+							Category: "tasks",
+						}, items[0])
+					})
+				})
+		},
+	)
+}
+
+func TestTodoTreeCommand(t *testing.T) {
+	Given("a file mixing TODO, DONE, and plain headings", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", `* Home
+** TODO Fix the sink
+** Notes on plumbing
+* Work
+** DONE Send report
+** Meetings
+*** TODO Book room
+* Reading list
+`).GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.todoTree",
+				Arguments: []any{string(tc.DocURI("tasks.org"))},
+			}
+
+			When(t, tc, "requesting the TODO tree", "workspace/executeCommand", params,
+				func(t *testing.T, symbols []protocol.DocumentSymbol) {
+					Then("returns only open TODO branches and their ancestors", t, func(t *testing.T) {
+						testza.AssertLen(t, symbols, 2, "Expected Home and Work")
+
+						testza.AssertEqual(t, "Home", symbols[0].Name)
+						testza.AssertLen(t, symbols[0].Children, 1)
+						testza.AssertEqual(t, "Fix the sink", symbols[0].Children[0].Name)
+
+						testza.AssertEqual(t, "Work", symbols[1].Name)
+						testza.AssertLen(t, symbols[1].Children, 1)
+						testza.AssertEqual(t, "Meetings", symbols[1].Children[0].Name)
+						testza.AssertLen(t, symbols[1].Children[0].Children, 1)
+						testza.AssertEqual(t, "Book room", symbols[1].Children[0].Children[0].Name)
+					})
+				})
+		},
+	)
+}
+
+func TestCheckLinksCommand(t *testing.T) {
+	Given("files with a valid link, a broken id link and a broken file link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+			tc.GivenFile("target.org", `* Target
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("notes.org", `* Notes
+Good [[id:{{.targetID}}][target]].
+Bad [[id:00000000-0000-0000-0000-000000000000][gone]].`).
+				GivenFile("more.org", `* More
+See [[file:missing.org][missing]].`).
+				GivenSaveFile("target.org").
+				GivenSaveFile("notes.org").
+				GivenSaveFile("more.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{Command: "org.checkLinks"}
+
+			When(t, tc, "checking links", "workspace/executeCommand", params,
+				func(t *testing.T, links []ourserver.DanglingLink) {
+					Then("reports both broken links with reasons", t, func(t *testing.T) {
+						testza.AssertLen(t, links, 2)
+
+						testza.AssertEqual(t, string(tc.DocURI("more.org")), links[0].SourceFile)
+						testza.AssertEqual(t, 1, links[0].Line)
+						testza.AssertContains(t, links[0].LinkText, "file:missing.org")
+						testza.AssertContains(t, links[0].Reason, "File not found")
+
+						testza.AssertEqual(t, string(tc.DocURI("notes.org")), links[1].SourceFile)
+						testza.AssertEqual(t, 2, links[1].Line)
+						testza.AssertContains(t, links[1].LinkText, "id:00000000-0000-0000-0000-000000000000")
+						testza.AssertContains(t, links[1].Reason, "ID not found")
+					})
+				})
+		},
+	)
+}
+
+func TestExportMarkdownCommand(t *testing.T) {
+	Given("a document with headings, markup, a src block, and links", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+			tc.GivenFile("notes/target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("doc.org", `#+TITLE: Doc
+* Heading
+Some *bold* and /italic/ text with ~code~.
+
+- first
+- [X] done
+
+#+begin_src go
+fmt.Println("hi")
+#+end_src
+** Links
+See [[id:{{.targetID}}][the target]] and [[file:notes/target.org][the file]].`).
+				GivenSaveFile("notes/target.org").
+				GivenOpenFile("doc.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.exportMarkdown",
+				Arguments: []interface{}{string(tc.DocURI("doc.org"))},
+			}
+
+			When(t, tc, "exporting the document", "workspace/executeCommand", params,
+				func(t *testing.T, markdown string) {
+					Then("renders headings and emphasis", t, func(t *testing.T) {
+						testza.AssertContains(t, markdown, "# Heading\n")
+						testza.AssertContains(t, markdown, "## Links")
+						testza.AssertContains(t, markdown, "Some **bold** and *italic* text with `code`.")
+						testza.AssertNotContains(t, markdown, "#+TITLE")
+					})
+
+					Then("renders lists and fenced code", t, func(t *testing.T) {
+						testza.AssertContains(t, markdown, "- first\n- [x] done")
+						testza.AssertContains(t, markdown, "```go\nfmt.Println(\"hi\")\n```")
+					})
+
+					Then("converts id and file links to relative Markdown paths", t, func(t *testing.T) {
+						testza.AssertContains(t, markdown, "[the target](notes/target.md#target-heading)")
+						testza.AssertContains(t, markdown, "[the file](notes/target.md)")
+					})
+				})
+		},
+	)
+}
+
+func TestTangleCommand(t *testing.T) {
+	Given("two src blocks tangling to the same file and one that doesn't tangle", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("build.org", `* Setup
+#+begin_src bash :tangle out/build.sh
+echo first
+#+end_src
+* Skipped
+#+begin_src bash
+echo never
+#+end_src
+* Finish
+#+begin_src bash :tangle out/build.sh
+echo second
+#+end_src`).GivenOpenFile("build.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.tangle",
+				Arguments: []interface{}{string(tc.DocURI("build.org"))},
+			}
+
+			When(t, tc, "tangling the document", "workspace/executeCommand", params,
+				func(t *testing.T, files []ourserver.TangledFile) {
+					target := filepath.Join(tc.tempDir, "out", "build.sh")
+
+					Then("reports the written file", t, func(t *testing.T) {
+						testza.AssertLen(t, files, 1)
+						testza.AssertEqual(t, target, files[0].Path)
+						testza.AssertEqual(t, 2, files[0].Blocks)
+					})
+
+					Then("writes the blocks in document order, creating the directory", t, func(t *testing.T) {
+						data, err := os.ReadFile(target)
+						testza.AssertNoError(t, err)
+						testza.AssertEqual(t, "echo first\n\necho second\n", string(data))
+					})
+				})
+		},
+	)
+}
+
+func TestTangleOutsideWorkspace(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "rc.sh")
+	content := "* Dotfiles\n#+begin_src bash :tangle " + outside + "\necho hi\n#+end_src\n"
+
+	tangle := func(tc *LSPTestContext) ([]ourserver.TangledFile, error) {
+		params := protocol.ExecuteCommandParams{
+			Command:   "org.tangle",
+			Arguments: []any{string(tc.DocURI("dotfiles.org"))},
+		}
+		var files []ourserver.TangledFile
+		_, err := tc.conn.Call(tc.ctx, "workspace/executeCommand", params, &files)
+		return files, err
+	}
+
+	Given("a src block tangling to a file outside the workspace", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("dotfiles.org", content).GivenOpenFile("dotfiles.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			t.Run("when tangling the document", func(t *testing.T) {
+				_, err := tangle(tc)
+
+				Then("refuses and writes nothing", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertContains(t, err.Error(), "outside the workspace")
+					testza.AssertNoFileExists(t, outside)
+				})
+			})
+		},
+	)
+
+	Given("a src block tangling through a workspace symlink that points outside", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("dotfiles.org", "* Dotfiles\n#+begin_src bash :tangle link/pwned.sh\necho hi\n#+end_src\n").
+				GivenOpenFile("dotfiles.org")
+			if err := os.Symlink(filepath.Dir(outside), filepath.Join(tc.tempDir, "link")); err != nil {
+				t.Fatalf("Failed to create symlink: %v", err)
+			}
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			t.Run("when tangling the document", func(t *testing.T) {
+				_, err := tangle(tc)
+
+				Then("refuses and writes nothing behind the link", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected the command to fail")
+					testza.AssertContains(t, err.Error(), "outside the workspace")
+					testza.AssertNoFileExists(t, filepath.Join(filepath.Dir(outside), "pwned.sh"))
+				})
+			})
+		},
+	)
+
+	Given("the same block with tangleOutsideWorkspace enabled", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"tangleOutsideWorkspace": true})
+			tc.GivenFile("dotfiles.org", content).GivenOpenFile("dotfiles.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			t.Run("when tangling the document", func(t *testing.T) {
+				files, err := tangle(tc)
+
+				Then("writes the file", t, func(t *testing.T) {
+					testza.AssertNoError(t, err)
+					testza.AssertLen(t, files, 1)
+					data, err := os.ReadFile(outside)
+					testza.AssertNoError(t, err)
+					testza.AssertEqual(t, "echo hi\n", string(data))
+				})
+			})
+		},
+	)
+}
+
+func TestCopyIDLinkCommand(t *testing.T) {
+	content := "* Reading list\nBooks to get to.\n"
+
+	Given("a heading without an ID", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", content).GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.copyIdLink",
+				Arguments: []any{string(tc.DocURI("notes.org")), 0, 0},
+			}
+
+			When(t, tc, "copying an id link to the heading", "workspace/executeCommand", params,
+				func(t *testing.T, result ourserver.IDLink) {
+					var id string
+
+					Then("returns an edit adding an ID to the heading", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result.Edit)
+						edits := result.Edit.Changes[tc.DocURI("notes.org")]
+						testza.AssertLen(t, edits, 1)
+						updated := applyEdit(content, edits[0].Range, edits[0].NewText)
+						match := regexp.MustCompile(`(?m)^\* Reading list\n:PROPERTIES:\n:ID: +(\S+)\n:END:\nBooks to get to\.\n$`).FindStringSubmatch(updated)
+						testza.AssertNotNil(t, match, updated)
+						id = match[1]
+					})
+
+					Then("returns a link using the new ID and the heading title", t, func(t *testing.T) {
+						testza.AssertEqual(t, "[[id:"+id+"][Reading list]]", result.Link)
+					})
+
+					Then("asks the client to apply the edit", t, func(t *testing.T) {
+						testza.AssertLen(t, tc.PollNotification("workspace/applyEdit", 2*time.Second), 1)
+					})
+				})
+		},
+	)
+}
+
+func TestInsertJournalLinkCommand(t *testing.T) {
+	content := "* Today\nSee \n"
+
+	Given("a document and a journal date format", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"journalDateFormat": "20060102"})
+			tc.GivenFile("notes.org", content).GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.insertJournalLink",
+				Arguments: []any{string(tc.DocURI("notes.org")), 1, 4},
+			}
+
+			When(t, tc, "inserting a journal link", "workspace/executeCommand", params,
+				func(t *testing.T, result protocol.WorkspaceEdit) {
+					today := time.Now().Format("20060102")
+
+					Then("inserts a link to today's journal file", t, func(t *testing.T) {
+						edits := result.Changes[tc.DocURI("notes.org")]
+						testza.AssertLen(t, edits, 1)
+						expected := "* Today\nSee [[file:" + today + ".org][" + today + "]]\n"
+						testza.AssertEqual(t, expected, applyEdit(content, edits[0].Range, edits[0].NewText))
+					})
+
+					Then("creates the journal file next to the document", t, func(t *testing.T) {
+						data, err := os.ReadFile(filepath.Join(tc.tempDir, today+".org"))
+						testza.AssertNoError(t, err)
+						testza.AssertEqual(t, "#+TITLE: "+today+"\n", string(data))
+					})
+				})
+		},
+	)
+}
+
+func TestOpenAttachmentDirCommand(t *testing.T) {
+	Given("a heading with an ID and no attachment directory yet", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "* Papers\n:PROPERTIES:\n:ID: abcdef12-3456\n:END:\n").GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ExecuteCommandParams{
+				Command:   "org.openAttachmentDir",
+				Arguments: []any{string(tc.DocURI("notes.org")), 0, 0, true},
+			}
+
+			When(t, tc, "opening the heading's attachment directory", "workspace/executeCommand", params,
+				func(t *testing.T, result string) {
+					expected := filepath.Join(tc.tempDir, "data", "ab", "cdef12-3456")
+
+					Then("returns the path in org-attach's data/xx/rest layout", t, func(t *testing.T) {
+						testza.AssertEqual(t, expected, result)
+					})
+
+					Then("creates the directory", t, func(t *testing.T) {
+						info, err := os.Stat(expected)
+						testza.AssertNoError(t, err)
+						testza.AssertTrue(t, info.IsDir())
+					})
+				})
+		},
+	)
+}
@@ -3,8 +3,10 @@ package integration
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/org-lsp/server"
 	"go.lsp.dev/protocol"
 )
 
@@ -70,6 +72,64 @@ Content here.`
 	)
 }
 
+func TestIDCompletionOrderAndFilterText(t *testing.T) {
+	Given("several headings with IDs indexed out of title order", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("zebraID").WithUUID("appleID").WithUUID("mangoID")
+
+			tc.GivenFile("target.org", `* Zebra
+:PROPERTIES:
+:ID: {{.zebraID}}
+:END:
+* apple
+:PROPERTIES:
+:ID: {{.appleID}}
+:END:
+* Mango
+:PROPERTIES:
+:ID: {{.mangoID}}
+:END:
+`).
+				GivenFile("source.org", "* Source\nSome text with [[id:").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			When(t, tc, "requesting completion after [[id:", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("items are sorted by title regardless of case", t, func(t *testing.T) {
+					var labels []string
+					for _, item := range result.Items {
+						labels = append(labels, item.Label)
+					}
+					testza.AssertEqual(t, []string{"apple", "Mango", "Zebra"}, labels)
+					for i := 1; i < len(result.Items); i++ {
+						testza.AssertTrue(t, result.Items[i-1].SortText < result.Items[i].SortText, "SortText should follow title order")
+					}
+				})
+
+				Then("each item's FilterText includes both its title and UUID", t, func(t *testing.T) {
+					for _, item := range result.Items {
+						if item.Label == "Mango" {
+							testza.AssertContains(t, item.FilterText, "Mango")
+							testza.AssertContains(t, item.FilterText, tc.TestData["mangoID"])
+						}
+					}
+				})
+			})
+		},
+	)
+}
+
 func TestTagCompletion(t *testing.T) {
 	Given("a file with tags and source file with : prefix in headline", t,
 		func(t *testing.T) *LSPTestContext {
@@ -132,6 +192,84 @@ Content here.`
 	)
 }
 
+func TestTagCompletionSkipsExistingTags(t *testing.T) {
+	Given("a headline already tagged :work: and an index with other tags", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+
+			tc.GivenFile("target.org", "* Target Heading :work:home:\nContent here.").
+				GivenFile("source.org", "* Foo :work:").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     protocol.Position{Line: 0, Character: 12},
+				},
+			}
+
+			When(t, tc, "completing a second tag", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the other tags but not work again", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+
+					var labels []string
+					for _, item := range result.Items {
+						if item.Kind == protocol.CompletionItemKindProperty {
+							labels = append(labels, item.Label)
+						}
+					}
+					testza.AssertContains(t, labels, "home")
+					testza.AssertNotContains(t, labels, "work", "Expected work not to be offered twice")
+				})
+			})
+		},
+	)
+}
+
+func TestFileTagsCompletion(t *testing.T) {
+	Given("a file with #+FILETAGS and source file with : prefix in headline", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+
+			tc.GivenFile("target.org", "#+TITLE: Tagged File\n#+FILETAGS: :project:archive:\n* Untagged Heading\nContent here.").
+				GivenFile("source.org", "* Source Heading :").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     protocol.Position{Line: 0, Character: 18},
+				},
+			}
+
+			When(t, tc, "requesting completion after : in headline", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the file-level tags", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					labels := make(map[string]bool)
+					for _, item := range result.Items {
+						labels[item.Label] = true
+					}
+					testza.AssertTrue(t, labels["project"], "Expected 'project' from #+FILETAGS")
+					testza.AssertTrue(t, labels["archive"], "Expected 'archive' from #+FILETAGS")
+				})
+			})
+
+			When(t, tc, "requesting org/status", server.MethodStatus, struct{}{}, func(t *testing.T, result server.ScanStatus) {
+				Then("indexes the file-level tags", t, func(t *testing.T) {
+					testza.AssertEqual(t, 2, result.TagCount)
+				})
+			})
+		},
+	)
+}
 func TestFileLinkCompletion(t *testing.T) {
 	Given("multiple org files and source file with [[file: prefix", t,
 		func(t *testing.T) *LSPTestContext {
@@ -241,6 +379,99 @@ func TestBlockTypeCompletion(t *testing.T) {
 	)
 }
 
+func TestSrcBlockScaffoldCompletion(t *testing.T) {
+	srcItem := func(result *protocol.CompletionList) *protocol.CompletionItem {
+		for i, item := range result.Items {
+			if item.Label == "#+begin_src" {
+				return &result.Items[i]
+			}
+		}
+		return nil
+	}
+	params := func(tc *LSPTestContext) protocol.CompletionParams {
+		return protocol.CompletionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("blocks.org")},
+				Position:     protocol.Position{Line: 0, Character: 9},
+			},
+		}
+	}
+
+	Given("a client with snippet support typing #+begin_s", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("blocks.org", "#+begin_s").GivenOpenFile("blocks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "completing the src block", "textDocument/completion", params(tc), func(t *testing.T, result *protocol.CompletionList) {
+				Then("inserts a scaffold with tab stops for the language and body", t, func(t *testing.T) {
+					src := srcItem(result)
+					testza.AssertNotNil(t, src, "Expected '#+begin_src' block type")
+					testza.AssertEqual(t, protocol.InsertTextFormatSnippet, src.InsertTextFormat)
+					testza.AssertEqual(t, "#+begin_src ${1:language} :results output\n$0\n#+end_src", src.TextEdit.NewText)
+				})
+			})
+		},
+	)
+
+	Given("a client without snippet support typing #+begin_s", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithCapabilities(t, func(capabilities *protocol.ClientCapabilities) {
+				capabilities.TextDocument.Completion.CompletionItem.SnippetSupport = false
+			})
+			tc.GivenFile("blocks.org", "#+begin_s").GivenOpenFile("blocks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "completing the src block", "textDocument/completion", params(tc), func(t *testing.T, result *protocol.CompletionList) {
+				Then("inserts plain begin and end lines", t, func(t *testing.T) {
+					src := srcItem(result)
+					testza.AssertNotNil(t, src, "Expected '#+begin_src' block type")
+					testza.AssertNotEqual(t, protocol.InsertTextFormatSnippet, src.InsertTextFormat)
+					testza.AssertEqual(t, "#+begin_src\n\n#+end_src", src.TextEdit.NewText)
+				})
+			})
+		},
+	)
+}
+
+func TestUppercaseBlockTypeCompletion(t *testing.T) {
+	Given("a file with an uppercase #+BEGIN_ prefix", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("blocks.org", "#+BEGIN_S").
+				GivenOpenFile("blocks.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("blocks.org")},
+					Position:     protocol.Position{Line: 0, Character: 9},
+				},
+			}
+
+			When(t, tc, "requesting completion after #+BEGIN_S", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("returns block types in the case the keyword was typed", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+
+					var src *protocol.CompletionItem
+					for i, item := range result.Items {
+						if item.Label == "#+BEGIN_SRC" {
+							src = &result.Items[i]
+						}
+					}
+					testza.AssertNotNil(t, src, "Expected '#+BEGIN_SRC' block type")
+					testza.AssertEqual(t, "#+BEGIN_SRC ${1:language} :results output\n$0\n#+END_SRC", src.TextEdit.NewText)
+					testza.AssertEqual(t, uint32(0), src.TextEdit.Range.Start.Character)
+				})
+			})
+		},
+	)
+}
+
 func TestExportBlockCompletion(t *testing.T) {
 	Given("a file with #+begin_export_ prefix", t,
 		func(t *testing.T) *LSPTestContext {
@@ -335,3 +566,706 @@ Content here.`
 		},
 	)
 }
+
+func TestCitationCompletion(t *testing.T) {
+	Given("a bibliography file and a source with an open citation", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("refs.bib", `@book{knuth1984,
+  author = {Donald E. Knuth},
+  title  = {The {TeX}book},
+  year   = 1984,
+}
+
+@article{dijkstra1968,
+  author = "Edsger W. Dijkstra",
+  title = "Go To Statement Considered Harmful",
+  year = {1968}
+}
+`).GivenFile("paper.org", `#+BIBLIOGRAPHY: refs.bib
+* Notes
+As shown in [cite:@knu`).GivenOpenFile("paper.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("paper.org")},
+					Position:     tc.PosAfter("paper.org", "[cite:@knu"),
+				},
+			}
+
+			When(t, tc, "requesting completion after [cite:@", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the matching bibliography key with author and year", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					testza.AssertLen(t, result.Items, 1, "Expected only the key matching the typed prefix")
+					testza.AssertEqual(t, "knuth1984", result.Items[0].Label)
+					testza.AssertEqual(t, "Donald E. Knuth (1984)", result.Items[0].Detail)
+				})
+			})
+		},
+	)
+}
+
+func TestEntityCompletion(t *testing.T) {
+	Given("a paragraph with a partial entity and a src block with a backslash", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("math.org", `* Math
+The angle \al
+#+begin_src python
+print("\al
+#+end_src
+`).GivenOpenFile("math.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("math.org")},
+					Position:     tc.PosAfter("math.org", "The angle \\al"),
+				},
+			}
+
+			When(t, tc, "requesting completion after \\al", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers \\alpha with its glyph", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					var alpha *protocol.CompletionItem
+					for i, item := range result.Items {
+						if item.Label == "\\alpha" {
+							alpha = &result.Items[i]
+						}
+					}
+					testza.AssertNotNil(t, alpha, "Expected \\alpha to be offered")
+					testza.AssertEqual(t, "α", alpha.Detail)
+					testza.AssertEqual(t, "alpha", alpha.InsertText)
+				})
+			})
+
+			srcParams := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("math.org")},
+					Position:     tc.PosAfter("math.org", "print(\"\\al"),
+				},
+			}
+
+			When(t, tc, "requesting completion after a backslash in a src block", "textDocument/completion", srcParams, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers no entities", t, func(t *testing.T) {
+					for _, item := range result.Items {
+						testza.AssertFalse(t, strings.HasPrefix(item.Label, "\\"), "Unexpected entity %q in src block", item.Label)
+					}
+				})
+			})
+		},
+	)
+}
+
+func TestIDCompletionResolve(t *testing.T) {
+	Given("a target heading linked from two files and a source with [[id: prefix", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("notes/target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:
+Content here.`).
+				GivenFile("linker1.org", "* One\nSee [[id:{{.targetID}}]].").
+				GivenFile("linker2.org", "* Two\nSee [[id:{{.targetID}}]].").
+				GivenFile("source.org", "* Source\nSome text with [[id:").
+				GivenSaveFile("notes/target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			var target protocol.CompletionItem
+			When(t, tc, "requesting completion after [[id:", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("the target item carries its UUID as data", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					for _, item := range result.Items {
+						if item.Label == "Target Heading" {
+							target = item
+						}
+					}
+					testza.AssertEqual(t, tc.TestData["targetID"], target.Data)
+				})
+			})
+
+			When(t, tc, "resolving the target item", "completionItem/resolve", target, func(t *testing.T, resolved protocol.CompletionItem) {
+				Then("documentation includes the file path and backlink count", t, func(t *testing.T) {
+					doc, _ := resolved.Documentation.(map[string]any)
+					value, _ := doc["value"].(string)
+					testza.AssertContains(t, value, "notes/target.org")
+					testza.AssertContains(t, value, "2 backlinks")
+				})
+			})
+		},
+	)
+}
+
+func TestIDCompletionLazyPreview(t *testing.T) {
+	Given("a target heading with body text and a source with [[id: prefix", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:
+Preview body text.`).
+				GivenFile("source.org", "* Source\nSome text with [[id:").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			var target protocol.CompletionItem
+			When(t, tc, "requesting completion after [[id:", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("items have no documentation yet", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					for _, item := range result.Items {
+						if item.Label == "Target Heading" {
+							target = item
+						}
+					}
+					testza.AssertEqual(t, tc.TestData["targetID"], target.Data)
+					testza.AssertNil(t, target.Documentation, "Expected no eager documentation")
+				})
+			})
+
+			When(t, tc, "resolving the target item", "completionItem/resolve", target, func(t *testing.T, resolved protocol.CompletionItem) {
+				Then("documentation contains the heading preview", t, func(t *testing.T) {
+					doc, _ := resolved.Documentation.(map[string]any)
+					value, _ := doc["value"].(string)
+					testza.AssertContains(t, value, "**Target Heading**")
+					testza.AssertContains(t, value, "Preview body text.")
+				})
+			})
+		},
+	)
+}
+
+func TestPropertyValueCompletion(t *testing.T) {
+	Given("a heading with an ORDERED property awaiting a value", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", `* Project
+:PROPERTIES:
+:ORDERED: 
+:END:
+`).GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tasks.org")},
+					Position:     tc.PosAfter("tasks.org", ":ORDERED: "),
+				},
+			}
+
+			When(t, tc, "requesting completion after :ORDERED: ", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers t and nil", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					labels := make([]string, len(result.Items))
+					for i, item := range result.Items {
+						labels[i] = item.Label
+					}
+					testza.AssertEqual(t, []string{"t", "nil"}, labels)
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspacePropertyValueCompletion(t *testing.T) {
+	Given("two files using :CATEGORY: values and a third awaiting one", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("one.org", "* Task\n:PROPERTIES:\n:CATEGORY: work\n:END:\n").
+				GivenFile("two.org", "* Errand\n:PROPERTIES:\n:category: home\n:END:\n* Meeting\n:PROPERTIES:\n:CATEGORY: work\n:END:\n").
+				GivenFile("new.org", "* New\n:PROPERTIES:\n:CATEGORY: \n:END:\n").
+				GivenSaveFile("one.org").
+				GivenOpenFile("new.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("new.org")},
+					Position:     tc.PosAfter("new.org", ":CATEGORY: "),
+				},
+			}
+
+			When(t, tc, "requesting completion after :CATEGORY: ", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers each value used in the workspace once", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					labels := make([]string, len(result.Items))
+					for i, item := range result.Items {
+						labels[i] = item.Label
+					}
+					testza.AssertEqual(t, []string{"home", "work"}, labels)
+				})
+			})
+		},
+	)
+}
+
+func TestPropertyDrawerCompletion(t *testing.T) {
+	Given("a partial :PROPERTIES line below a headline", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "* Task\n:PROPERTIES\nBody.\n").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", ":PROPERTIES"),
+				},
+			}
+
+			When(t, tc, "requesting completion after :PROPERTIES", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers a drawer snippet closed by :END:", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					testza.AssertLen(t, result.Items, 1)
+					item := result.Items[0]
+					testza.AssertEqual(t, protocol.InsertTextFormatSnippet, item.InsertTextFormat)
+					testza.AssertNotNil(t, item.TextEdit)
+					testza.AssertEqual(t, uint32(0), item.TextEdit.Range.Start.Character)
+					testza.AssertTrue(t, strings.HasPrefix(item.TextEdit.NewText, ":PROPERTIES:\n:ID: ${1:"))
+					testza.AssertContains(t, item.TextEdit.NewText, "}\n:END:\n")
+				})
+			})
+		},
+	)
+}
+
+func TestDenoteCompletion(t *testing.T) {
+	Given("Denote-named notes and a source with [[denote: prefix", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("20240115T103000--meeting-notes__work.org", "#+title: Meeting Notes\n* Agenda").
+				GivenFile("20231201T080000--reading-list.org", "#+title: Reading List\n* Books").
+				GivenFile("plain.org", "* Not a Denote note").
+				GivenFile("source.org", "* Source\nSee [[denote:").
+				GivenSaveFile("20240115T103000--meeting-notes__work.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[denote:"),
+				},
+			}
+
+			When(t, tc, "requesting completion after [[denote:", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers Denote identifiers with titles as detail, newest first", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					testza.AssertLen(t, result.Items, 2, "Only Denote-named notes should be offered")
+					testza.AssertEqual(t, "20240115T103000", result.Items[0].Label)
+					testza.AssertEqual(t, "Meeting Notes", result.Items[0].Detail)
+					testza.AssertEqual(t, "20240115T103000]]", result.Items[0].InsertText)
+					testza.AssertEqual(t, "20231201T080000", result.Items[1].Label)
+					testza.AssertEqual(t, "Reading List", result.Items[1].Detail)
+				})
+			})
+		},
+	)
+}
+
+func TestInternalLinkCompletion(t *testing.T) {
+	Given("a buffer with two headings and a target, typing a bare [[", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "* First Heading\nA <<named spot>> here.\n* Second Heading\nSee [[").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "See [["),
+				},
+			}
+
+			When(t, tc, "requesting completion after [[", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers both headings as *Heading links and the target", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					newTexts := make(map[string]string)
+					for _, item := range result.Items {
+						if item.TextEdit != nil {
+							newTexts[item.Label] = item.TextEdit.NewText
+						}
+					}
+					testza.AssertEqual(t, "*First Heading]]", newTexts["*First Heading"])
+					testza.AssertEqual(t, "*Second Heading]]", newTexts["*Second Heading"])
+					testza.AssertEqual(t, "named spot]]", newTexts["named spot"])
+				})
+			})
+		},
+	)
+}
+
+func TestRoamRefCompletion(t *testing.T) {
+	Given("a heading carrying :ROAM_REFS: and a source file typing [[roam:", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("paper.org", `* Attention Is All You Need
+:PROPERTIES:
+:ROAM_REFS: https://arxiv.org/abs/1706.03762 @vaswani2017
+:END:`).
+				GivenFile("source.org", "* Source\nSee [[roam:").
+				GivenSaveFile("paper.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[roam:"),
+				},
+			}
+
+			When(t, tc, "requesting completion after [[roam:", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers every ref of the heading", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertLen(t, result.Items, 2)
+					testza.AssertEqual(t, "@vaswani2017", result.Items[0].Label)
+					testza.AssertEqual(t, "@vaswani2017]]", result.Items[0].InsertText)
+					testza.AssertEqual(t, "Attention Is All You Need", result.Items[0].Detail)
+					testza.AssertEqual(t, "https://arxiv.org/abs/1706.03762", result.Items[1].Label)
+				})
+			})
+		},
+	)
+}
+
+func TestCompletionTriggeredByBracket(t *testing.T) {
+	Given("a target heading and a source with an open and a closed id link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("source.org", "* Source\nSee [[id:\nDone [[id:{{.targetID}}][target]] then [").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			triggeredAt := func(marker string) protocol.CompletionParams {
+				return protocol.CompletionParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+						Position:     tc.PosAfter("source.org", marker),
+					},
+					Context: &protocol.CompletionContext{
+						TriggerKind:      protocol.CompletionTriggerKindTriggerCharacter,
+						TriggerCharacter: "[",
+					},
+				}
+			}
+
+			When(t, tc, "completion is triggered by [ inside [[id:", "textDocument/completion", triggeredAt("See [[id:"), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the target heading", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					labels := make([]string, len(result.Items))
+					for i, item := range result.Items {
+						labels[i] = item.Label
+					}
+					testza.AssertContains(t, labels, "Target Heading")
+				})
+			})
+
+			When(t, tc, "completion is triggered by [ after a closed link", "textDocument/completion", triggeredAt("] then ["), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers nothing from the closed link", t, func(t *testing.T) {
+					testza.AssertLen(t, result.Items, 0)
+				})
+			})
+		},
+	)
+}
+
+func TestIncludePathCompletion(t *testing.T) {
+	Given("a workspace with org and non-org files and an #+INCLUDE: being typed", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("chapters/one.org", "* One").
+				GivenFile("snippets/example.py", "print('hi')").
+				GivenFile("book.org", "#+TITLE: Book\n#+INCLUDE: \"").
+				GivenOpenFile("book.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("book.org")},
+					Position:     tc.PosAfter("book.org", "#+INCLUDE: \""),
+				},
+			}
+
+			When(t, tc, "requesting completion after #+INCLUDE: \"", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers org and non-org files with a closing quote", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					inserts := make(map[string]string)
+					for _, item := range result.Items {
+						inserts[item.Label] = item.InsertText
+					}
+					testza.AssertEqual(t, "chapters/one.org\"", inserts["chapters/one.org"])
+					testza.AssertEqual(t, "snippets/example.py\"", inserts["snippets/example.py"])
+					_, offersSelf := inserts["book.org"]
+					testza.AssertFalse(t, offersSelf, "The including file should not be offered")
+				})
+			})
+		},
+	)
+
+	Given("an #+INCLUDE: completed once before a new file appears", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("chapters/one.org", "* One").
+				GivenFile("book.org", "#+TITLE: Book\n#+INCLUDE: \"").
+				GivenOpenFile("book.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("book.org")},
+					Position:     tc.PosAfter("book.org", "#+INCLUDE: \""),
+				},
+			}
+			labels := func(result *protocol.CompletionList) []string {
+				var labels []string
+				for _, item := range result.Items {
+					labels = append(labels, item.Label)
+				}
+				return labels
+			}
+
+			When(t, tc, "requesting completion before the file exists", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("only the existing file is offered", t, func(t *testing.T) {
+					testza.AssertEqual(t, []string{"chapters/one.org"}, labels(result))
+				})
+			})
+
+			tc.GivenFile("chapters/two.txt", "two")
+			tc.GivenWatchedFileChange("chapters/two.txt", protocol.FileChangeTypeCreated)
+
+			When(t, tc, "requesting completion after a watched create event", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("the new file is offered too", t, func(t *testing.T) {
+					testza.AssertContains(t, labels(result), "chapters/two.txt")
+				})
+			})
+		},
+	)
+}
+
+func TestPriorityCompletion(t *testing.T) {
+	Given("a TODO headline with a partial priority cookie", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", "* TODO [#\n* Other").GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tasks.org")},
+					Position:     tc.PosAfter("tasks.org", "* TODO [#"),
+				},
+			}
+
+			When(t, tc, "requesting completion after [#", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers A, B and C replacing the partial cookie", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					testza.AssertLen(t, result.Items, 3)
+					for i, want := range []string{"[#A]", "[#B]", "[#C]"} {
+						item := result.Items[i]
+						testza.AssertEqual(t, want, item.Label)
+						testza.AssertNotNil(t, item.TextEdit)
+						testza.AssertEqual(t, want+" ", item.TextEdit.NewText)
+						testza.AssertEqual(t, uint32(7), item.TextEdit.Range.Start.Character)
+					}
+				})
+			})
+		},
+	)
+}
+
+func TestTodoKeywordCompletion(t *testing.T) {
+	Given("a document with a custom #+TODO sequence and a partial keyword", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", "#+TODO: TODO NEXT | DONE CANCELLED\n* N\n* NEXT Call back").GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tasks.org")},
+					Position:     protocol.Position{Line: 1, Character: 3},
+				},
+			}
+
+			When(t, tc, "requesting completion after the stars", "textDocument/completion", params, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the document's NEXT keyword replacing the partial word", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					testza.AssertLen(t, result.Items, 1)
+					testza.AssertEqual(t, "NEXT", result.Items[0].Label)
+					testza.AssertNotNil(t, result.Items[0].TextEdit)
+					testza.AssertEqual(t, "NEXT ", result.Items[0].TextEdit.NewText)
+					testza.AssertEqual(t, uint32(2), result.Items[0].TextEdit.Range.Start.Character)
+				})
+			})
+		},
+	)
+
+	Given("a workspace TODO sequence and a document with #+SEQ_TODO", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"todoKeywords": "TODO WAITING | DONE",
+			})
+			tc.GivenFile("plain.org", "* \n").
+				GivenFile("seq.org", "#+SEQ_TODO: DRAFT REVIEW | PUBLISHED\n* \n").
+				GivenOpenFile("plain.org").
+				GivenOpenFile("seq.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			completionAt := func(file string, line uint32) protocol.CompletionParams {
+				return protocol.CompletionParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI(file)},
+						Position:     protocol.Position{Line: line, Character: 2},
+					},
+				}
+			}
+			labels := func(result *protocol.CompletionList) []string {
+				var labels []string
+				for _, item := range result.Items {
+					labels = append(labels, item.Label)
+				}
+				return labels
+			}
+
+			When(t, tc, "completing in a document without its own keywords", "textDocument/completion", completionAt("plain.org", 0), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the workspace keywords", t, func(t *testing.T) {
+					testza.AssertEqual(t, []string{"TODO", "WAITING", "DONE"}, labels(result))
+				})
+			})
+
+			When(t, tc, "completing in a document with #+SEQ_TODO", "textDocument/completion", completionAt("seq.org", 1), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the document's keywords instead", t, func(t *testing.T) {
+					testza.AssertEqual(t, []string{"DRAFT", "REVIEW", "PUBLISHED"}, labels(result))
+				})
+			})
+		},
+	)
+}
+
+func TestTimestampCompletion(t *testing.T) {
+	content := `* Plans
+Call the bank on <to
+Pay rent by [+
+Visit them <+3d
+- [
+If a < b
+`
+
+	Given("a paragraph with timestamp openers, a checkbox, and a comparison", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", content).GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			completionAt := func(marker string) protocol.CompletionParams {
+				return protocol.CompletionParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+						Position:     tc.PosAfter("notes.org", marker),
+					},
+				}
+			}
+			labelsOf := func(result *protocol.CompletionList) []string {
+				labels := make([]string, len(result.Items))
+				for i, item := range result.Items {
+					labels[i] = item.Label
+				}
+				return labels
+			}
+
+			When(t, tc, "requesting completion after <to", "textDocument/completion", completionAt("bank on <to"), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers today", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertEqual(t, []string{"<today>"}, labelsOf(result))
+				})
+
+				Then("<today> expands to today's timestamp, replacing what was typed", t, func(t *testing.T) {
+					edit := result.Items[0].TextEdit
+					testza.AssertNotNil(t, edit)
+					testza.AssertEqual(t, "<"+time.Now().Format("2006-01-02 Mon")+">", edit.NewText)
+					testza.AssertEqual(t, protocol.Position{Line: 1, Character: 17}, edit.Range.Start)
+					testza.AssertEqual(t, protocol.Position{Line: 1, Character: 20}, edit.Range.End)
+				})
+			})
+
+			When(t, tc, "requesting completion after [+", "textDocument/completion", completionAt("rent by [+"), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the inactive relative offsets", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertEqual(t, []string{"[+1d]", "[+1w]"}, labelsOf(result))
+				})
+			})
+
+			When(t, tc, "requesting completion after a typed offset", "textDocument/completion", completionAt("them <+3d"), func(t *testing.T, result *protocol.CompletionList) {
+				Then("expands the offset as typed", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertLen(t, result.Items, 1)
+					expected := "<" + time.Now().AddDate(0, 0, 3).Format("2006-01-02 Mon") + ">"
+					testza.AssertEqual(t, expected, result.Items[0].TextEdit.NewText)
+				})
+			})
+
+			When(t, tc, "requesting completion after a checkbox opener", "textDocument/completion", completionAt("- ["), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers no timestamps", t, func(t *testing.T) {
+					testza.AssertLen(t, result.Items, 0)
+				})
+			})
+
+			When(t, tc, "requesting completion after a comparison", "textDocument/completion", completionAt("If a <"), func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers no timestamps", t, func(t *testing.T) {
+					testza.AssertLen(t, result.Items, 0)
+				})
+			})
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package integration
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/MarvinJWendt/testza"
@@ -38,6 +40,52 @@ func TestFileLinkDefinition(t *testing.T) {
 	)
 }
 
+func TestFileLinkSearchDefinition(t *testing.T) {
+	Given("a source file linking to a line number, a heading, and past the end of a target file", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("target.org", "* First\nline two\nline three\n* Second\nlast line").
+				GivenFile("source.org", `* Source
+Line [[file:target.org::3][three]].
+Heading [[file:target.org::*Second][second]].
+Past [[file:target.org::99][the end]].`).
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			definitionAt := func(marker string) protocol.DefinitionParams {
+				return protocol.DefinitionParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+						Position:     tc.PosAfter("source.org", marker),
+					},
+				}
+			}
+
+			When(t, tc, "requesting definition of a ::3 link", "textDocument/definition", definitionAt("Line [[file:"), func(t *testing.T, locs []protocol.Location) {
+				Then("points at the 0-based line 2", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertContains(t, string(locs[0].URI), "target.org")
+					testza.AssertEqual(t, uint32(2), locs[0].Range.Start.Line)
+				})
+			})
+
+			When(t, tc, "requesting definition of a ::*heading link", "textDocument/definition", definitionAt("Heading [[file:"), func(t *testing.T, locs []protocol.Location) {
+				Then("points at the heading", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertEqual(t, uint32(3), locs[0].Range.Start.Line)
+				})
+			})
+
+			When(t, tc, "requesting definition of a line past the end", "textDocument/definition", definitionAt("Past [[file:"), func(t *testing.T, locs []protocol.Location) {
+				Then("clamps to the last line", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertEqual(t, uint32(4), locs[0].Range.Start.Line)
+				})
+			})
+		},
+	)
+}
 func TestUUIDLinkDefinition(t *testing.T) {
 	Given("a target file with UUID property and source file with id link", t,
 		func(t *testing.T) *LSPTestContext {
@@ -81,3 +129,345 @@ This is a target file with UUID.`
 		},
 	)
 }
+
+func TestUUIDLinkDefinitionTargetsTitle(t *testing.T) {
+	Given("a target heading with a TODO keyword, priority, and tags", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `** TODO [#A] Target Heading :work:
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("source.org", "* Source\nSee [[id:{{.targetID}}][the target]].").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			When(t, tc, "requesting definition at the id link", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("the location covers the heading title, not the stars", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					start := uint32(len("** TODO [#A] "))
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 0, Character: start},
+						End:   protocol.Position{Line: 0, Character: start + uint32(len("Target Heading"))},
+					}, locs[0].Range)
+				})
+			})
+		},
+	)
+}
+
+func TestBareUUIDDefinition(t *testing.T) {
+	Given("a target file with UUID property and source file mentioning the UUID as plain text", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			targetContent := `Foo, bar, baz
+
+* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`
+
+			tc.GivenFile("target.org", targetContent).
+				GivenFile("source.org", "* Source File\nThe id {{.targetID}} was pasted without a link.").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "The id "),
+				},
+			}
+			params.Position.Character += 4
+
+			When(t, tc, "requesting definition on the bare UUID", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns the heading location with matching UUID", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+					testza.AssertContains(t, string(locs[0].URI), "target.org")
+					testza.AssertEqual(t, uint32(2), locs[0].Range.Start.Line)
+				})
+			})
+
+			params.Position = protocol.Position{Line: 1, Character: 2}
+			When(t, tc, "requesting definition away from the UUID", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns nothing", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 0)
+				})
+			})
+		},
+	)
+}
+
+func TestDenoteLinkDefinition(t *testing.T) {
+	Given("a Denote-named note and a source file linking to its identifier", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes/20240115T103000--meeting-notes__work.org", "#+title: Meeting Notes\n* Agenda").
+				GivenFile("source.org", "* Source\nSee [[denote:20240115T103000][the meeting]]").
+				GivenSaveFile("notes/20240115T103000--meeting-notes__work.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{
+						URI: tc.DocURI("source.org"),
+					},
+					Position: tc.PosAfter("source.org", "[[denote:"),
+				},
+			}
+
+			When(t, tc, "requesting definition at denote link position", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns the note file whose name carries the identifier", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+					testza.AssertEqual(t, tc.DocURI("notes/20240115T103000--meeting-notes__work.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(0), locs[0].Range.Start.Line, "Should point to line 0")
+				})
+			})
+		},
+	)
+}
+
+func TestRoamRefDefinition(t *testing.T) {
+	Given("headings and a file node carrying :ROAM_REFS: and a source file linking to them", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("paperID")
+
+			tc.GivenFile("paper.org", `#+TITLE: Reading notes
+
+* Attention Is All You Need
+:PROPERTIES:
+:ID:       {{.paperID}}
+:ROAM_REFS: https://arxiv.org/abs/1706.03762 @vaswani2017
+:END:
+Notes on the paper.`).
+				GivenFile("site.org", `:PROPERTIES:
+:ROAM_REFS: "https://example.com/a page"
+:END:
+#+TITLE: Example Site`).
+				GivenFile("source.org", `* Source
+See [[roam:@vaswani2017]] and [[roam:https://example.com/a page]].`).
+				GivenSaveFile("paper.org").
+				GivenSaveFile("site.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[roam:@vas"),
+				},
+			}
+
+			When(t, tc, "requesting definition on a roam ref link", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns the heading carrying the ref", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertEqual(t, tc.DocURI("paper.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(2), locs[0].Range.Start.Line)
+				})
+			})
+
+			params.Position = tc.PosAfter("source.org", "[[roam:https://exa")
+			When(t, tc, "requesting definition on a quoted file-level ref", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns the top of the file node", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertEqual(t, tc.DocURI("site.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(0), locs[0].Range.Start.Line)
+				})
+			})
+		},
+	)
+}
+
+func TestIncludeDefinition(t *testing.T) {
+	Given("a document including another file whole and by heading", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("chapters/one.org", "* First\nIntro\n* Second\nMore").
+				GivenFile("book.org", `#+TITLE: Book
+#+INCLUDE: "chapters/one.org"
+#+INCLUDE: "chapters/one.org::*Second" :only-contents t`).
+				GivenOpenFile("book.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			definitionAt := func(marker string) protocol.DefinitionParams {
+				return protocol.DefinitionParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("book.org")},
+						Position:     tc.PosAfter("book.org", marker),
+					},
+				}
+			}
+
+			When(t, tc, "requesting definition on a plain include", "textDocument/definition", definitionAt("#+INCL"), func(t *testing.T, locs []protocol.Location) {
+				Then("returns the included file at its first line", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+					testza.AssertEqual(t, tc.DocURI("chapters/one.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(0), locs[0].Range.Start.Line)
+				})
+			})
+
+			When(t, tc, "requesting definition on an include with a heading search", "textDocument/definition", definitionAt("::*Sec"), func(t *testing.T, locs []protocol.Location) {
+				Then("returns the named heading", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+					testza.AssertEqual(t, tc.DocURI("chapters/one.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(2), locs[0].Range.Start.Line)
+				})
+			})
+		},
+	)
+}
+
+func TestCodeRefDefinition(t *testing.T) {
+	Given("a src block with a labeled line and a coderef link to it", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("code.org", `* Example
+#+begin_src python
+total = 0
+for x in items:  (ref:loop)
+    total += x
+#+end_src
+The [[(loop)]] adds everything up.`).GivenOpenFile("code.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("code.org")},
+					Position:     tc.PosAfter("code.org", "[[(lo"),
+				},
+			}
+
+			When(t, tc, "requesting definition of the coderef link", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("points at the labeled line in the same file", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1)
+					testza.AssertEqual(t, tc.DocURI("code.org"), locs[0].URI)
+					testza.AssertEqual(t, uint32(3), locs[0].Range.Start.Line)
+					testza.AssertEqual(t, uint32(17), locs[0].Range.Start.Character)
+				})
+			})
+		},
+	)
+}
+
+func TestAttachmentLinkDefinition(t *testing.T) {
+	Given("a heading with a :DIR: property and an attachment link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("assets/diagram.txt", "diagram").
+				GivenFile("notes.org", `* Design
+:PROPERTIES:
+:DIR: assets
+:END:
+See [[attachment:diagram.txt]] for the overview.`).
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "[[attach"),
+				},
+			}
+
+			When(t, tc, "requesting definition at the attachment link", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("resolves into the attachment directory", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+					testza.AssertEqual(t, tc.DocURI("assets/diagram.txt"), locs[0].URI)
+				})
+			})
+		},
+	)
+
+	Given("a heading with an :ID: and no attachment directory on disk", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", `* Design
+:PROPERTIES:
+:ID: 5f3a9c1e-0000-4000-8000-000000000000
+:END:
+See [[attachment:diagram.png]] for the overview.`).
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "[[attach"),
+				},
+			}
+
+			When(t, tc, "requesting definition at the attachment link", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("returns no location", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 0, "Expected no definition without an attachment directory")
+				})
+			})
+		},
+	)
+}
+
+func TestWatchedFileDeletePrunesUUID(t *testing.T) {
+	Given("an indexed target file with a UUID heading and a source linking to it", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", "* Target Heading\n:PROPERTIES:\n:ID:       {{.targetID}}\n:END:\n").
+				GivenFile("source.org", "* Source\nSee [[id:{{.targetID}}][the target]].").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{
+						URI: tc.DocURI("source.org"),
+					},
+					Position: tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			When(t, tc, "requesting definition before the delete", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("resolves the UUID to target.org", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 1, "Expected exactly one definition location")
+				})
+			})
+
+			os.Remove(filepath.Join(tc.tempDir, "target.org"))
+			tc.GivenWatchedFileChange("target.org", protocol.FileChangeTypeDeleted)
+
+			When(t, tc, "requesting definition after a watched delete event", "textDocument/definition", params, func(t *testing.T, locs []protocol.Location) {
+				Then("the UUID no longer resolves", t, func(t *testing.T) {
+					testza.AssertLen(t, locs, 0, "Expected no definition after delete")
+				})
+			})
+		},
+	)
+}
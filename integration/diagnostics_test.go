@@ -258,3 +258,143 @@ See [[file:fixed.org][fixed link]]`)
 		},
 	)
 }
+
+func TestDiagnosticsMalformedTimestamp(t *testing.T) {
+	Given("a document with a malformed and a valid timestamp", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("source.org", `* Meeting
+SCHEDULED: <2024-13-40>
+* Review
+SCHEDULED: <2024-01-15 Mon 10:00-11:30 +1w>`).
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			Then("warning diagnostic for the malformed timestamp only", t, func(t *testing.T) {
+				diags := tc.GetDiagnostics("source.org")
+				testza.AssertEqual(t, 1, len(diags), "Expected one diagnostic for the malformed timestamp")
+				testza.AssertEqual(t, protocol.DiagnosticSeverityWarning, diags[0].Severity)
+				testza.AssertContains(t, diags[0].Message, "2024-13-40")
+				testza.AssertEqual(t, uint32(1), diags[0].Range.Start.Line)
+			})
+		},
+	)
+}
+
+func TestDiagnosticsValidateWorkspaceOnInitialize(t *testing.T) {
+	Given("a workspace file with a dangling id link, validateWorkspace enabled", t,
+		func(t *testing.T) *LSPTestContext {
+			return NewTestContextWithFiles(t, map[string]any{"validateWorkspace": true}, map[string]string{
+				"broken.org": "* Notes\nSee [[id:00000000-0000-0000-0000-000000000000][missing]].",
+				"fine.org":   "* Fine\nNo links here.",
+			})
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			Then("diagnostics are published for the unopened file", t, func(t *testing.T) {
+				diags := tc.GetDiagnostics("broken.org")
+				testza.AssertLen(t, diags, 1)
+				testza.AssertEqual(t, uint32(1), diags[0].Range.Start.Line)
+			})
+
+			Then("files without problems are not published", t, func(t *testing.T) {
+				for _, raw := range tc.GetNotifications("textDocument/publishDiagnostics") {
+					var params protocol.PublishDiagnosticsParams
+					testza.AssertNoError(t, json.Unmarshal(raw, &params))
+					testza.AssertNotEqual(t, tc.DocURI("fine.org"), params.URI)
+				}
+			})
+		},
+	)
+}
+
+func TestDiagnosticsValidateWorkspaceOnIndexRefresh(t *testing.T) {
+	Given("an unopened file with a dangling id link, validateWorkspace enabled", t,
+		func(t *testing.T) *LSPTestContext {
+			return NewTestContextWithFiles(t, map[string]any{"validateWorkspace": true}, map[string]string{
+				"broken.org": "* Notes\nSee [[id:00000000-0000-0000-0000-000000000000][missing]].",
+			})
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			testza.AssertLen(t, tc.GetDiagnostics("broken.org"), 1)
+
+			tc.ClearNotifications("textDocument/publishDiagnostics")
+			tc.GivenFile("broken.org", "* Notes\nNo links any more.")
+			tc.GivenWatchedFileChange("broken.org", protocol.FileChangeTypeChanged)
+
+			Then("the fixed file gets its diagnostics cleared", t, func(t *testing.T) {
+				notifications := tc.PollNotification("textDocument/publishDiagnostics", 500*time.Millisecond)
+				testza.AssertLen(t, notifications, 1)
+				var params protocol.PublishDiagnosticsParams
+				testza.AssertNoError(t, json.Unmarshal(notifications[0], &params))
+				testza.AssertEqual(t, tc.DocURI("broken.org"), params.URI)
+				testza.AssertLen(t, params.Diagnostics, 0)
+			})
+		},
+	)
+}
+
+func TestDiagnosticsValidateWorkspaceDisabledByDefault(t *testing.T) {
+	Given("a workspace file with a dangling id link and default config", t,
+		func(t *testing.T) *LSPTestContext {
+			return NewTestContextWithFiles(t, nil, map[string]string{
+				"broken.org": "* Notes\nSee [[id:00000000-0000-0000-0000-000000000000][missing]].",
+			})
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			Then("nothing is published until the file is opened", t, func(t *testing.T) {
+				testza.AssertNil(t, tc.PollNotification("textDocument/publishDiagnostics", 100*time.Millisecond))
+			})
+		},
+	)
+}
+
+func TestDiagnosticsRequireHeadingIDs(t *testing.T) {
+	Given("a heading without an ID, requireHeadingIDs enabled", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"requireHeadingIDs": true})
+			tc.GivenFile("notes.org", "* Has ID\n:PROPERTIES:\n:ID: abc\n:END:\n* Missing ID\nBody.\n").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			diags := tc.GetDiagnostics("notes.org")
+
+			Then("a hint is published on the heading lacking an ID only", t, func(t *testing.T) {
+				testza.AssertLen(t, diags, 1)
+				testza.AssertEqual(t, protocol.DiagnosticSeverityHint, diags[0].Severity)
+				testza.AssertEqual(t, uint32(4), diags[0].Range.Start.Line)
+				testza.AssertEqual(t, uint32(len("* Missing ID")), diags[0].Range.End.Character)
+			})
+
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+				Range:        diags[0].Range,
+				Context:      protocol.CodeActionContext{Diagnostics: diags},
+			}
+			When(t, tc, "requesting code actions for the hint", "textDocument/codeAction", params, func(t *testing.T, actions []protocol.CodeAction) {
+				Then("the Add ID quick fix is offered for that diagnostic", t, func(t *testing.T) {
+					action := findAction(actions, "Org: Add ID to this heading")
+					testza.AssertNotNil(t, action, "Expected Add ID action")
+					testza.AssertLen(t, action.Diagnostics, 1)
+					testza.AssertEqual(t, "missing-id", action.Diagnostics[0].Code)
+					testza.AssertTrue(t, action.IsPreferred)
+				})
+			})
+		},
+	)
+
+	Given("a heading without an ID and default config", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "* Missing ID\nBody.\n").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			Then("no hint is published", t, func(t *testing.T) {
+				testza.AssertLen(t, tc.GetDiagnostics("notes.org"), 0)
+			})
+		},
+	)
+}
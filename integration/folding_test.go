@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/org-lsp/server"
 	"go.lsp.dev/protocol"
 )
 
@@ -55,6 +56,45 @@ Content under heading 2`
 	)
 }
 
+func TestLastHeadingFoldsToEndOfFile(t *testing.T) {
+	Given("an org file whose final heading has several body lines and trailing blanks", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			content := `* Heading 1
+Content under heading 1
+
+* Heading 2
+First body line
+
+- a list item
+- another item
+Last body line
+
+
+`
+
+			tc.GivenFile("folding.org", content).
+				GivenOpenFile("folding.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.FoldingRangeParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("folding.org")},
+				},
+			}
+
+			When(t, tc, "requesting folding ranges", "textDocument/foldingRange", params, func(t *testing.T, ranges []protocol.FoldingRange) {
+				Then("each heading folds to its last body line", t, func(t *testing.T) {
+					testza.AssertLen(t, ranges, 2)
+					testza.AssertEqual(t, protocol.FoldingRange{StartLine: 0, EndLine: 1, Kind: protocol.RegionFoldingRange}, ranges[0])
+					testza.AssertEqual(t, protocol.FoldingRange{StartLine: 3, EndLine: 8, Kind: protocol.RegionFoldingRange}, ranges[1])
+				})
+			})
+		},
+	)
+}
+
 func TestBlockFolding(t *testing.T) {
 	Given("an org file with source blocks", t,
 		func(t *testing.T) *LSPTestContext {
@@ -181,3 +221,120 @@ func TestEmptyFileFolding(t *testing.T) {
 		},
 	)
 }
+
+func TestLogbookDrawerFolding(t *testing.T) {
+	Given("a heading with a properties drawer and a logbook drawer", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("taskID")
+
+			content := `* Task
+:PROPERTIES:
+:ID:       {{.taskID}}
+:END:
+:LOGBOOK:
+CLOCK: [2024-01-01 Mon 10:00]--[2024-01-01 Mon 11:00] =>  1:00
+:END:
+Visible body text`
+
+			tc.GivenFile("logbook.org", content).
+				GivenFile("source.org", "* Source\nSee [[id:").
+				GivenSaveFile("logbook.org").
+				GivenOpenFile("logbook.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.FoldingRangeParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{
+						URI: tc.DocURI("logbook.org"),
+					},
+				},
+			}
+
+			When(t, tc, "requesting folding ranges", "textDocument/foldingRange", params, func(t *testing.T, ranges []protocol.FoldingRange) {
+				Then("returns a region fold for the logbook drawer", t, func(t *testing.T) {
+					var logbookRange *protocol.FoldingRange
+					for i := range ranges {
+						if ranges[i].Kind == protocol.RegionFoldingRange && ranges[i].StartLine == 4 {
+							logbookRange = &ranges[i]
+							break
+						}
+					}
+
+					testza.AssertNotNil(t, logbookRange, "Should have a logbook folding range")
+					testza.AssertEqual(t, uint32(6), logbookRange.EndLine, "Logbook should end at line 6")
+				})
+			})
+
+			completionParams := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     tc.PosAfter("source.org", "[[id:"),
+				},
+			}
+
+			var task protocol.CompletionItem
+			When(t, tc, "requesting ID completion", "textDocument/completion", completionParams, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the task heading", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected completion result")
+					for _, item := range result.Items {
+						if item.Label == "Task" {
+							task = item
+						}
+					}
+					testza.AssertNotNil(t, task.Data, "Expected the Task heading to be offered")
+				})
+			})
+
+			When(t, tc, "resolving the task completion", "completionItem/resolve", task, func(t *testing.T, resolved protocol.CompletionItem) {
+				Then("the preview skips the logbook contents", t, func(t *testing.T) {
+					doc, _ := resolved.Documentation.(map[string]any)
+					preview, _ := doc["value"].(string)
+
+					testza.AssertContains(t, preview, "Visible body text")
+					testza.AssertNotContains(t, preview, "CLOCK:")
+					testza.AssertNotContains(t, preview, ":LOGBOOK:")
+				})
+			})
+		},
+	)
+}
+
+func TestStartupVisibilityRequest(t *testing.T) {
+	Given("an indexed file starting in overview and an open one showing contents", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("overview.org", "#+STARTUP: overview indent\n* Heading\nBody.\n").
+				GivenFile("content.org", "#+startup: content\n* Heading\n").
+				GivenFile("plain.org", "* Heading\n").
+				GivenSaveFile("overview.org").
+				GivenOpenFile("content.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			paramsFor := func(file string) map[string]any {
+				return map[string]any{"textDocument": map[string]any{"uri": string(tc.DocURI(file))}}
+			}
+
+			When(t, tc, "requesting the indexed file's startup visibility", server.MethodStartupVisibility, paramsFor("overview.org"), func(t *testing.T, result server.StartupVisibility) {
+				Then("reports overview", t, func(t *testing.T) {
+					testza.AssertEqual(t, "overview", result.Visibility)
+				})
+			})
+
+			When(t, tc, "requesting the open file's startup visibility", server.MethodStartupVisibility, paramsFor("content.org"), func(t *testing.T, result server.StartupVisibility) {
+				Then("reports content", t, func(t *testing.T) {
+					testza.AssertEqual(t, "content", result.Visibility)
+				})
+			})
+
+			When(t, tc, "requesting it for a file without #+STARTUP", server.MethodStartupVisibility, paramsFor("plain.org"), func(t *testing.T, result server.StartupVisibility) {
+				Then("reports none, leaving it to the client", t, func(t *testing.T) {
+					testza.AssertEqual(t, "", result.Visibility)
+				})
+			})
+		},
+	)
+}
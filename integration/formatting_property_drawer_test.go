@@ -135,3 +135,45 @@ Has blank line here`
 		},
 	)
 }
+
+func TestFormatRemovesEmptyPropertyDrawer(t *testing.T) {
+	Given("headings with empty property drawers", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			content := `* Scheduled Task
+SCHEDULED: <2024-01-01 Mon>
+:PROPERTIES:
+:END:
+Task body
+
+* Plain Heading
+:PROPERTIES:
+:END:
+Plain body`
+			tc.GivenFile("test.org", content).
+				GivenOpenFile("test.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("test.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("no empty drawer is left and each heading keeps one drawer with its ID", t, func(t *testing.T) {
+					testza.AssertNotNil(t, edits, "Expected non-nil edits")
+					formatted := applyEdits(t, tc, "test.org", edits)
+
+					testza.AssertFalse(t, strings.Contains(formatted, ":PROPERTIES:\n:END:"),
+						"Should not contain an empty property drawer")
+					testza.AssertEqual(t, 2, strings.Count(formatted, ":PROPERTIES:"),
+						"Should have exactly one drawer per heading")
+					testza.AssertEqual(t, 2, strings.Count(formatted, ":ID:"),
+						"Each heading should still get an ID")
+				})
+			})
+		},
+	)
+}
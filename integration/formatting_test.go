@@ -1,7 +1,7 @@
 package integration
 
 import (
-	"os"
+	"sort"
 	"strings"
 	"testing"
 
@@ -374,6 +374,54 @@ func TestFormatEmphasisSpacing(t *testing.T) {
 			})
 		},
 	)
+	Given("emphasis with stray runs of spaces inside its markers", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			content := "Some /italic    words/, *bold\t text* with _under  /nested   italic/_ and ~code    span~."
+			tc.GivenFile("emphasis.org", content).
+				GivenOpenFile("emphasis.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("emphasis.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("the spaces inside emphasis are collapsed but code is left alone", t, func(t *testing.T) {
+					testza.AssertNotNil(t, edits, "Expected non-nil edits")
+
+					formatted := applyEdits(t, tc, "emphasis.org", edits)
+					testza.AssertEqual(t, "Some /italic words/, *bold text* with _under /nested italic/_ and ~code    span~.\n", formatted)
+				})
+			})
+		},
+	)
+	Given("arithmetic and slash-separated prose that looks like spaced emphasis", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			content := `1 + 2 + 3 = 6, 3 * 4 * 5 and a / b / c stay as written.`
+			tc.GivenFile("emphasis.org", content).
+				GivenOpenFile("emphasis.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("emphasis.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("no emphasis markup is introduced", t, func(t *testing.T) {
+					formatted := applyEdits(t, tc, "emphasis.org", edits)
+					testza.AssertEqual(t, "1 + 2 + 3 = 6, 3 * 4 * 5 and a / b / c stay as written.\n", formatted)
+				})
+			})
+		},
+	)
 }
 
 func TestFormatLinkSpacing(t *testing.T) {
@@ -863,25 +911,70 @@ Content under heading 2`
 	)
 }
 
-// applyEdits applies text edits to a file and returns the resulting content
+func TestFormatRangeSendsOnlyChangedLines(t *testing.T) {
+	content := "* One   \n:PROPERTIES:\n:ID: one\n:END:\n\nBody one.\n\n* Two   \n:PROPERTIES:\n:ID: two\n:END:\n\nBody two.\n"
+
+	Given("a document with trailing whitespace on two separate headlines", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("range.org", content).
+				GivenOpenFile("range.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentRangeFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("range.org")},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 7, Character: 0},
+					End:   protocol.Position{Line: 7, Character: 8},
+				},
+			}
+
+			When(t, tc, "formatting the range covering the second headline", "textDocument/rangeFormatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("returns a single edit replacing just that line", t, func(t *testing.T) {
+					testza.AssertLen(t, edits, 1)
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 7, Character: 0},
+						End:   protocol.Position{Line: 8, Character: 0},
+					}, edits[0].Range)
+					testza.AssertEqual(t, "* Two\n", edits[0].NewText)
+				})
+			})
+
+			params.Range = protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 0},
+				End:   protocol.Position{Line: 2, Character: 8},
+			}
+			When(t, tc, "formatting a range with nothing to change", "textDocument/rangeFormatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("returns no edits", t, func(t *testing.T) {
+					testza.AssertLen(t, edits, 0)
+				})
+			})
+		},
+	)
+}
+
+// applyEdits applies text edits to an open document's buffer, the text the
+// server computed them against, and returns the resulting content
 func applyEdits(t *testing.T, tc *LSPTestContext, filename string, edits []protocol.TextEdit) string {
 	t.Helper()
 
-	// Read original content
-	original, err := os.ReadFile(tc.tempDir + "/" + filename)
-	if err != nil {
-		t.Fatalf("Failed to read file: %v", err)
-	}
+	// LSP ranges all refer to the original text, so apply the edits from the
+	// end of the document backwards to keep earlier ranges valid
+	sorted := append([]protocol.TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Range.Start, sorted[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line > b.Line
+		}
+		return a.Character > b.Character
+	})
 
-	// Apply edits (simplified - just use the first edit's new text for now)
-	// In a full implementation, we'd properly merge multiple edits
-	if len(edits) == 0 {
-		return string(original)
+	content := tc.DocumentContent(filename)
+	for _, edit := range sorted {
+		content = applyEdit(content, edit.Range, edit.NewText)
 	}
-
-	// For simplicity, return the new text from the first full-document edit
-	// A real implementation would need to handle multiple incremental edits
-	return edits[0].NewText
+	return content
 }
 
 func TestFormatNormalizesPlanningDirectiveIndentation(t *testing.T) {
@@ -942,3 +1035,217 @@ func TestFormatNormalizesPlanningDirectiveIndentation(t *testing.T) {
 		},
 	)
 }
+
+func TestFormatFillColumn(t *testing.T) {
+	Given("a long paragraph and fillColumn set to 40", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"fillColumn": 40})
+			content := `The quick brown fox jumps over the lazy dog while [[https://example.com][the example link]] and *bold words here* stay whole.
+Short line.
+
+
+Second paragraph.
+`
+			tc.GivenFile("fill.org", content).
+				GivenOpenFile("fill.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("fill.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("the paragraph is wrapped at word boundaries under 40 columns, never inside a link", t, func(t *testing.T) {
+					formatted := applyEdits(t, tc, "fill.org", edits)
+					testza.AssertEqual(t, `The quick brown fox jumps over the lazy
+dog while
+[[https://example.com][the example link]]
+and *bold words here* stay whole. Short
+line.
+
+Second paragraph.
+`, formatted)
+				})
+			})
+		},
+	)
+}
+
+func TestFormatTagColumn(t *testing.T) {
+	Given("headings with tags and tagColumn set to 40", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"tagColumn": 40})
+			content := `* Short :a:
+** TODO A somewhat longer heading title     :work:urgent:
+* A heading whose title runs well past the tag column :long:
+`
+			tc.GivenFile("tags.org", content).
+				GivenOpenFile("tags.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("tags.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("tag blocks start at column 40, or one space after an overlong title", t, func(t *testing.T) {
+					formatted := applyEdits(t, tc, "tags.org", edits)
+
+					var headlines []string
+					for line := range strings.SplitSeq(formatted, "\n") {
+						if strings.HasPrefix(line, "*") {
+							headlines = append(headlines, line)
+						}
+					}
+					testza.AssertLen(t, headlines, 3)
+					testza.AssertEqual(t, 40, strings.Index(headlines[0], ":a:"))
+					testza.AssertEqual(t, 40, strings.Index(headlines[1], ":work:urgent:"))
+					testza.AssertEqual(t, "* A heading whose title runs well past the tag column :long:", headlines[2])
+				})
+			})
+		},
+	)
+}
+
+func TestFormatTagAlignmentSkipsNonHeadlines(t *testing.T) {
+	Given("lines that look like tagged headlines but aren't", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			content := `* Notes :a:
+*bold* opener with a colon pair :x:
+- parent item
+  * indented list item :y:
+#+begin_src org
+* Example heading :z:
+#+end_src
+`
+			tc.GivenFile("tags.org", content).
+				GivenOpenFile("tags.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("tags.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("only the real headline's tags are padded", t, func(t *testing.T) {
+					formatted := applyEdits(t, tc, "tags.org", edits)
+					testza.AssertEqual(t, 77, strings.Index(formatted, ":a:"))
+					testza.AssertContains(t, formatted, "opener with a colon pair :x:")
+					testza.AssertContains(t, formatted, "indented list item :y:")
+					testza.AssertContains(t, formatted, "* Example heading :z:\n")
+				})
+			})
+		},
+	)
+}
+
+func TestFormatIndentSrcBlocks(t *testing.T) {
+	Given("src blocks under headings and indentSrcBlocks enabled", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"indentSrcBlocks": true})
+			content := `* Top
+:PROPERTIES:
+:ID: 11111111-1111-1111-1111-111111111111
+:END:
+** Code
+:PROPERTIES:
+:ID: 22222222-2222-2222-2222-222222222222
+:END:
+#+begin_src python
+    def greet(name):
+        if name:
+            print(name)
+#+end_src
+`
+			tc.GivenFile("code.org", content).
+				GivenOpenFile("code.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: tc.DocURI("code.org"),
+				},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("fences and code sit at the heading's content column with code structure kept", t, func(t *testing.T) {
+					formatted := applyEdits(t, tc, "code.org", edits)
+					testza.AssertContains(t, formatted, `
+   #+BEGIN_SRC python
+   def greet(name):
+       if name:
+           print(name)
+   #+END_SRC
+`)
+				})
+			})
+		},
+	)
+}
+
+func TestFormatReturnsMinimalOrderedEdits(t *testing.T) {
+	Given("a document needing fixes in several separate places", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("multi.org", `* Heading One   
+:PROPERTIES:
+:ID: one
+:END:
+Body one.
+* Heading Two
+:PROPERTIES:
+:ID: two
+:END:
+Body two.
+`).GivenOpenFile("multi.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("multi.org")},
+			}
+
+			When(t, tc, "formatting the document", "textDocument/formatting", params, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("returns one edit per changed region, ordered and non-overlapping", t, func(t *testing.T) {
+					testza.AssertGreater(t, len(edits), 1, "Expected several edits instead of a full replacement")
+					for i := 1; i < len(edits); i++ {
+						prev, next := edits[i-1].Range.End, edits[i].Range.Start
+						testza.AssertTrue(t, prev.Line < next.Line || (prev.Line == next.Line && prev.Character <= next.Character),
+							"Edit %d starts before edit %d ends", i, i-1)
+					}
+					for _, edit := range edits {
+						testza.AssertNotContains(t, edit.NewText, ":ID: one", "Unchanged lines should not be resent")
+					}
+				})
+
+				Then("applying every edit produces the formatted document", t, func(t *testing.T) {
+					testza.AssertEqual(t, `* Heading One
+:PROPERTIES:
+:ID: one
+:END:
+
+Body one.
+
+* Heading Two
+:PROPERTIES:
+:ID: two
+:END:
+
+Body two.
+`, applyEdits(t, tc, "multi.org", edits))
+				})
+			})
+		},
+	)
+}
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/MarvinJWendt/testza"
@@ -52,6 +53,44 @@ More content here.`
 	)
 }
 
+func TestHoverFileLinkTitle(t *testing.T) {
+	Given("a target file with a #+TITLE and source file with file link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			targetContent := `#+TITLE: Project Notes
+#+AUTHOR: Someone
+Some preamble text.
+* First Heading
+Body text.`
+
+			tc.GivenFile("target.org", targetContent).
+				GivenFile("source.org", "* Source File\nSee [[file:target.org][the notes]].").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     protocol.Position{Line: 1, Character: 10},
+				},
+			}
+
+			When(t, tc, "requesting hover on the file link", "textDocument/hover", params, func(t *testing.T, result *protocol.Hover) {
+				Then("shows the target's title and first heading", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					content := result.Contents.Value
+					testza.AssertContains(t, content, "**Project Notes**")
+					testza.AssertContains(t, content, "* First Heading")
+					testza.AssertNotContains(t, content, "#+AUTHOR", "raw lines should not be shown")
+				})
+			})
+		},
+	)
+}
+
 func TestHoverIDLink(t *testing.T) {
 	Given("a target file with UUID heading and source file with id link", t,
 		func(t *testing.T) *LSPTestContext {
@@ -105,6 +144,205 @@ Even more nested content here.`
 	)
 }
 
+func TestHoverContextLines(t *testing.T) {
+	Given("hoverContextLines set to 1 and an id link to a heading with a body", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"hoverContextLines": 1})
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", "* Target Heading\n:PROPERTIES:\n:ID: {{.targetID}}\n:END:\nBody text.").
+				GivenFile("source.org", "* Source\nSee [[id:{{.targetID}}][the target]].").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     protocol.Position{Line: 1, Character: 10},
+				},
+			}
+
+			When(t, tc, "requesting hover on the id link", "textDocument/hover", params, func(t *testing.T, result *protocol.Hover) {
+				Then("the preview shows only the heading line", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					content := result.Contents.Value
+					testza.AssertContains(t, content, "```org\n* Target Heading\n```")
+					testza.AssertNotContains(t, content, ":PROPERTIES:")
+				})
+			})
+		},
+	)
+}
+
+func TestHoverLinkAbbreviation(t *testing.T) {
+	Given("a #+LINK: abbreviation and a link using it", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "#+LINK: gh https://github.com/%s\n* Notes\nSee [[gh:alexispurslane/org-lsp][the repo]].\nAlso [[").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			hover := protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "See [[gh"),
+				},
+			}
+
+			When(t, tc, "hovering the abbreviated link", "textDocument/hover", hover, func(t *testing.T, result *protocol.Hover) {
+				Then("shows the expanded URL", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected hover result")
+					testza.AssertContains(t, result.Contents.Value, "GH Link")
+					testza.AssertContains(t, result.Contents.Value, "https://github.com/alexispurslane/org-lsp")
+				})
+			})
+
+			links := protocol.DocumentLinkParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+			}
+			When(t, tc, "requesting document links", "textDocument/documentLink", links, func(t *testing.T, result []protocol.DocumentLink) {
+				Then("the link targets the expanded URL", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, protocol.DocumentURI("https://github.com/alexispurslane/org-lsp"), result[0].Target)
+				})
+			})
+
+			completion := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "Also [["),
+				},
+			}
+			When(t, tc, "requesting completion after [[", "textDocument/completion", completion, func(t *testing.T, result *protocol.CompletionList) {
+				Then("offers the abbreviation as a link type", t, func(t *testing.T) {
+					var found *protocol.CompletionItem
+					for i := range result.Items {
+						if result.Items[i].Label == "gh:" {
+							found = &result.Items[i]
+						}
+					}
+					testza.AssertNotNil(t, found, "Expected gh: abbreviation item")
+					testza.AssertEqual(t, "gh:", found.TextEdit.NewText)
+				})
+			})
+		},
+	)
+
+	Given("a #+LINK: template using both %s and %h, and a tag containing %h", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "#+LINK: ex https://example.com/%s?q=%h\n* Notes\nSee [[ex:a%hb][example]].").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			links := protocol.DocumentLinkParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+			}
+			When(t, tc, "requesting document links", "textDocument/documentLink", links, func(t *testing.T, result []protocol.DocumentLink) {
+				Then("each placeholder is filled once, without touching the tag", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, protocol.DocumentURI("https://example.com/a%hb?q=a%25hb"), result[0].Target)
+				})
+			})
+		},
+	)
+}
+
+func TestHoverExternalLinks(t *testing.T) {
+	Given("man: and shell: links", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("notes.org", "* Notes\nSee [[man:ls][listing]] and [[man:printf(3)]].\nRun [[shell:rm -rf build][clean]].").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			hoverAt := func(after string) protocol.HoverParams {
+				return protocol.HoverParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+						Position:     tc.PosAfter("notes.org", after),
+					},
+				}
+			}
+
+			When(t, tc, "hovering a man: link", "textDocument/hover", hoverAt("See [[man"), func(t *testing.T, result *protocol.Hover) {
+				Then("names the manpage and how to open it", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected hover result")
+					testza.AssertContains(t, result.Contents.Value, "Manual page: `ls`")
+					testza.AssertContains(t, result.Contents.Value, "`man ls`")
+				})
+			})
+
+			When(t, tc, "hovering a man: link with a section", "textDocument/hover", hoverAt("and [[man"), func(t *testing.T, result *protocol.Hover) {
+				Then("opens the page in that section", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected hover result")
+					testza.AssertContains(t, result.Contents.Value, "`man 3 printf`")
+				})
+			})
+
+			When(t, tc, "hovering a shell: link", "textDocument/hover", hoverAt("Run [[shell"), func(t *testing.T, result *protocol.Hover) {
+				Then("shows the command without running it", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected hover result")
+					testza.AssertContains(t, result.Contents.Value, "rm -rf build")
+					testza.AssertContains(t, result.Contents.Value, "never runs")
+				})
+			})
+
+			links := protocol.DocumentLinkParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+			}
+			When(t, tc, "requesting document links", "textDocument/documentLink", links, func(t *testing.T, result []protocol.DocumentLink) {
+				Then("the shell: link is not clickable", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2)
+					for _, link := range result {
+						testza.AssertFalse(t, strings.Contains(string(link.Target), "shell"))
+					}
+				})
+			})
+		},
+	)
+}
+
+func TestHoverPlaintextClient(t *testing.T) {
+	Given("a client that only renders plaintext hovers and a macro", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithCapabilities(t, func(capabilities *protocol.ClientCapabilities) {
+				capabilities.TextDocument.Hover = &protocol.HoverTextDocumentClientCapabilities{
+					ContentFormat: []protocol.MarkupKind{protocol.PlainText},
+				}
+			})
+			tc.GivenFile("notes.org", "#+MACRO: greet Hello, $1!\n* Notes\n{{\"{{{\"}}greet(world)}}}\n").
+				GivenOpenFile("notes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.HoverParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("notes.org")},
+					Position:     tc.PosAfter("notes.org", "greet(w"),
+				},
+			}
+
+			When(t, tc, "hovering the macro", "textDocument/hover", params, func(t *testing.T, result *protocol.Hover) {
+				Then("returns plaintext without markdown", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result, "Expected hover result")
+					testza.AssertEqual(t, protocol.PlainText, result.Contents.Kind)
+					testza.AssertNotContains(t, result.Contents.Value, "```")
+					testza.AssertNotContains(t, result.Contents.Value, "**")
+					testza.AssertNotContains(t, result.Contents.Value, "`")
+					testza.AssertContains(t, result.Contents.Value, "Hello, world!")
+				})
+			})
+		},
+	)
+}
+
 func TestHoverNoLink(t *testing.T) {
 	Given("a file with regular text and no links", t,
 		func(t *testing.T) *LSPTestContext {
@@ -131,3 +369,146 @@ func TestHoverNoLink(t *testing.T) {
 		},
 	)
 }
+
+func TestHoverCitation(t *testing.T) {
+	Given("a bibliography and a document citing a known and an unknown key", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{
+				"bibliographyFiles": []string{"refs.bib"},
+			})
+			tc.GivenFile("refs.bib", `@article{dijkstra1968,
+  author = {Edsger W. Dijkstra},
+  title = {Go To Statement Considered Harmful},
+  year = {1968}
+}
+`).GivenFile("paper.org", `* Notes
+See [cite:@dijkstra1968] and [cite:@nobody2000].`).GivenOpenFile("paper.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			hoverAt := func(marker string) protocol.HoverParams {
+				return protocol.HoverParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("paper.org")},
+						Position:     tc.PosAfter("paper.org", marker),
+					},
+				}
+			}
+
+			When(t, tc, "hovering over a known citation", "textDocument/hover", hoverAt("@dijk"), func(t *testing.T, hover *protocol.Hover) {
+				Then("shows the bibliography entry", t, func(t *testing.T) {
+					testza.AssertNotNil(t, hover, "Expected hover result")
+					testza.AssertContains(t, hover.Contents.Value, "Go To Statement Considered Harmful")
+					testza.AssertContains(t, hover.Contents.Value, "Edsger W. Dijkstra")
+				})
+			})
+
+			When(t, tc, "hovering over an unknown citation", "textDocument/hover", hoverAt("@nob"), func(t *testing.T, hover *protocol.Hover) {
+				Then("notes the citation is unresolved", t, func(t *testing.T) {
+					testza.AssertNotNil(t, hover, "Expected hover result")
+					testza.AssertContains(t, hover.Contents.Value, "Unresolved citation")
+				})
+			})
+		},
+	)
+}
+
+func TestHoverMacro(t *testing.T) {
+	Given("a document defining a macro and using it", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("macros.org", `#+MACRO: greet Hello, $1 from $2!
+* Intro
+Say {{"{{{"}}greet(world,Org)}}}.
+Then {{"{{{"}}missing(x)}}}.`).GivenOpenFile("macros.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			hoverAt := func(marker string) protocol.HoverParams {
+				return protocol.HoverParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("macros.org")},
+						Position:     tc.PosAfter("macros.org", marker),
+					},
+				}
+			}
+
+			When(t, tc, "hovering over a defined macro", "textDocument/hover", hoverAt("{{{gre"), func(t *testing.T, hover *protocol.Hover) {
+				Then("shows the expanded text", t, func(t *testing.T) {
+					testza.AssertNotNil(t, hover, "Expected hover result")
+					testza.AssertContains(t, hover.Contents.Value, "Hello, world from Org!")
+				})
+			})
+
+			When(t, tc, "hovering over an undefined macro", "textDocument/hover", hoverAt("{{{mis"), func(t *testing.T, hover *protocol.Hover) {
+				Then("notes the macro is undefined", t, func(t *testing.T) {
+					testza.AssertNotNil(t, hover, "Expected hover result")
+					testza.AssertContains(t, hover.Contents.Value, "Undefined")
+				})
+			})
+		},
+	)
+}
+
+func TestHoverHeadlineStatistics(t *testing.T) {
+	content := `* Chapter One
+:PROPERTIES:
+:CUSTOM_ID: chapter-one
+:END:
+The quick brown fox jumps over the lazy dog.
+
+- one *bold* item
+- another item
+#+BEGIN_SRC python
+print("code is not prose")
+#+END_SRC
+** Section
+Five more words right here.
+* Chapter Two
+Not counted.`
+
+	headlineHover := func(tc *LSPTestContext) protocol.HoverParams {
+		return protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("stats.org")},
+				Position:     protocol.Position{Line: 0, Character: 4},
+			},
+		}
+	}
+
+	Given("subtree statistics enabled and a heading with a known amount of prose", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"subtreeStatsHover": true})
+			tc.GivenFile("stats.org", content).
+				GivenOpenFile("stats.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "hovering over the headline", "textDocument/hover", headlineHover(tc), func(t *testing.T, result *protocol.Hover) {
+				Then("reports the subtree's words, headings, and reading time", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					content := result.Contents.Value
+					testza.AssertContains(t, content, "Words: 19")
+					testza.AssertContains(t, content, "Headings: 1")
+					testza.AssertContains(t, content, "Reading time: under a minute")
+				})
+			})
+		},
+	)
+
+	Given("the default configuration", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("stats.org", content).
+				GivenOpenFile("stats.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "hovering over the headline", "textDocument/hover", headlineHover(tc), func(t *testing.T, result *protocol.Hover) {
+				Then("shows no statistics", t, func(t *testing.T) {
+					testza.AssertNil(t, result)
+				})
+			})
+		},
+	)
+}
@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MarvinJWendt/testza"
+)
+
+func TestShutdownThenExit(t *testing.T) {
+	Given("an initialized server", t,
+		func(t *testing.T) *LSPTestContext {
+			return NewTestContext(t)
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			_, err := tc.conn.Call(tc.ctx, "shutdown", nil, nil)
+			testza.AssertNoError(t, err)
+			testza.AssertNoError(t, tc.conn.Notify(tc.ctx, "exit", nil))
+
+			Then("stops serving promptly with exit code 0", t, func(t *testing.T) {
+				testza.AssertTrue(t, tc.WaitServed(2*time.Second), "Expected Serve to return after exit")
+				testza.AssertEqual(t, 0, tc.server.ExitCode())
+			})
+		},
+	)
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	Given("an initialized server", t,
+		func(t *testing.T) *LSPTestContext {
+			return NewTestContext(t)
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			testza.AssertNoError(t, tc.conn.Notify(tc.ctx, "exit", nil))
+
+			Then("stops serving promptly with exit code 1", t, func(t *testing.T) {
+				testza.AssertTrue(t, tc.WaitServed(2*time.Second), "Expected Serve to return after exit")
+				testza.AssertEqual(t, 1, tc.server.ExitCode())
+			})
+		},
+	)
+}
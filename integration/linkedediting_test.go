@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestLinkedEditingBlockDelimiters(t *testing.T) {
+	Given("a quote block containing a nested quote block", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("quotes.org", `* Quotes
+#+begin_quote
+Outer
+#+begin_quote
+Inner
+#+end_quote
+#+end_quote`).GivenOpenFile("quotes.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			linkedAt := func(line, char uint32) protocol.LinkedEditingRangeParams {
+				return protocol.LinkedEditingRangeParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("quotes.org")},
+						Position:     protocol.Position{Line: line, Character: char},
+					},
+				}
+			}
+			wordRange := func(line, start uint32) protocol.Range {
+				return protocol.Range{
+					Start: protocol.Position{Line: line, Character: start},
+					End:   protocol.Position{Line: line, Character: start + 5},
+				}
+			}
+
+			When(t, tc, "the cursor is on the outer #+begin_quote", "textDocument/linkedEditingRange", linkedAt(1, 10), func(t *testing.T, result *protocol.LinkedEditingRanges) {
+				Then("links it with the outer #+end_quote", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertEqual(t, []protocol.Range{wordRange(1, 8), wordRange(6, 6)}, result.Ranges)
+				})
+			})
+
+			When(t, tc, "the cursor is on the inner #+end_quote", "textDocument/linkedEditingRange", linkedAt(5, 8), func(t *testing.T, result *protocol.LinkedEditingRanges) {
+				Then("links it with the inner #+begin_quote", t, func(t *testing.T) {
+					testza.AssertNotNil(t, result)
+					testza.AssertEqual(t, []protocol.Range{wordRange(5, 6), wordRange(3, 8)}, result.Ranges)
+				})
+			})
+
+			When(t, tc, "the cursor is on block content", "textDocument/linkedEditingRange", linkedAt(2, 2), func(t *testing.T, result *protocol.LinkedEditingRanges) {
+				Then("returns no linked ranges", t, func(t *testing.T) {
+					testza.AssertNil(t, result)
+				})
+			})
+		},
+	)
+}
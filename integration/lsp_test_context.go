@@ -13,6 +13,8 @@ import (
 	"testing"
 	"text/template"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/alexispurslane/org-lsp/lspstream"
 	ourserver "github.com/alexispurslane/org-lsp/server"
@@ -31,10 +33,12 @@ type LSPTestContext struct {
 	rootURI      string
 	server       *ourserver.ServerImpl
 	done         chan struct{}
+	served       chan error // Receives Serve's result once the connection ends
 	listener     net.Listener
-	TestData     map[string]string // Storage for test-specific data like UUIDs
-	lastSaveTime time.Time         // Track when we last triggered a save for indexing polls
-	docVersion   int               // Track document version for didChange notifications
+	TestData     map[string]string               // Storage for test-specific data like UUIDs
+	lastSaveTime time.Time                       // Track when we last triggered a save for indexing polls
+	docVersion   int                             // Track document version for didChange notifications
+	openDocs     map[protocol.DocumentURI]string // Text the client holds for each open document
 
 	// Notification capture
 	notificationsMu sync.RWMutex
@@ -45,12 +49,58 @@ type LSPTestContext struct {
 // with that directory as root, and returns a context for testing.
 func NewTestContext(t *testing.T) *LSPTestContext {
 	t.Helper()
+	return NewTestContextWithOptions(t, nil)
+}
+
+// NewTestContextWithOptions is like NewTestContext, but sends options as the
+// initializationOptions of the initialize request.
+func NewTestContextWithOptions(t *testing.T, options map[string]any) *LSPTestContext {
+	t.Helper()
+	return newTestContext(t, options, nil, nil, nil)
+}
+
+// NewTestContextWithFiles is like NewTestContextWithOptions, but writes files
+// (path relative to the temp directory -> content, no templating) before
+// initializing, so they are part of the server's initial scan.
+func NewTestContextWithFiles(t *testing.T, options map[string]any, files map[string]string) *LSPTestContext {
+	t.Helper()
+	return newTestContext(t, options, nil, files, nil)
+}
+
+// NewTestContextWithWorkspaceFolders is like NewTestContext, but initializes
+// the server with the given subdirectories of the temp directory as its
+// workspace folders instead of a single root.
+func NewTestContextWithWorkspaceFolders(t *testing.T, folders ...string) *LSPTestContext {
+	t.Helper()
+	return newTestContext(t, nil, folders, nil, nil)
+}
+
+// NewTestContextWithCapabilities is like NewTestContext, but lets configure
+// adjust the client capabilities sent with the initialize request.
+func NewTestContextWithCapabilities(t *testing.T, configure func(*protocol.ClientCapabilities)) *LSPTestContext {
+	t.Helper()
+	return newTestContext(t, nil, nil, nil, configure)
+}
+
+func newTestContext(t *testing.T, options map[string]any, folders []string, files map[string]string, configure func(*protocol.ClientCapabilities)) *LSPTestContext {
+	t.Helper()
 
 	// Create temp directory in /tmp for automatic OS cleanup
 	tempDir, err := os.MkdirTemp("", "org-lsp-test-*")
 	if err != nil {
 		t.Fatalf("Failed to create temp directory: %v", err)
 	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			os.RemoveAll(tempDir)
+			t.Fatalf("Failed to create directory for %s: %v", path, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			os.RemoveAll(tempDir)
+			t.Fatalf("Failed to write file %s: %v", path, err)
+		}
+	}
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -60,6 +110,7 @@ func NewTestContext(t *testing.T) *LSPTestContext {
 
 	// Start server on TCP in background
 	done := make(chan struct{})
+	served := make(chan error, 1)
 	ready := make(chan struct{})
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -80,10 +131,7 @@ func NewTestContext(t *testing.T) *LSPTestContext {
 			go func(c net.Conn, server *ourserver.ServerImpl) {
 				defer c.Close()
 				logger, _ := zap.NewProduction()
-				stream := lspstream.NewLargeBufferStream(c)
-				_, srvConn, client := protocol.NewServer(ctx, server, stream, logger)
-				server.SetClient(client)
-				<-srvConn.Done()
+				served <- server.Serve(ctx, lspstream.NewLargeBufferStream(c), logger)
 			}(conn, s)
 		}
 	}(srv)
@@ -118,9 +166,11 @@ func NewTestContext(t *testing.T) *LSPTestContext {
 		rootURI:       rootURI,
 		server:        srv,
 		done:          done,
+		served:        served,
 		listener:      listener,
 		TestData:      make(map[string]string),
 		docVersion:    1,
+		openDocs:      make(map[protocol.DocumentURI]string),
 		notifications: make(map[string][]json.RawMessage),
 	}
 
@@ -129,8 +179,32 @@ func NewTestContext(t *testing.T) *LSPTestContext {
 
 	// Initialize server
 	initParams := protocol.InitializeParams{
+		WorkDoneProgressParams: protocol.WorkDoneProgressParams{
+			WorkDoneToken: protocol.NewProgressToken("initialize"),
+		},
 		ProcessID: int32(os.Getpid()),
 		RootURI:   protocol.DocumentURI(rootURI),
+		Capabilities: protocol.ClientCapabilities{
+			TextDocument: &protocol.TextDocumentClientCapabilities{
+				Completion: &protocol.CompletionTextDocumentClientCapabilities{
+					CompletionItem: &protocol.CompletionTextDocumentClientCapabilitiesItem{
+						SnippetSupport: true,
+					},
+				},
+			},
+		},
+	}
+	if options != nil {
+		initParams.InitializationOptions = options
+	}
+	if configure != nil {
+		configure(&initParams.Capabilities)
+	}
+	for _, folder := range folders {
+		initParams.WorkspaceFolders = append(initParams.WorkspaceFolders, protocol.WorkspaceFolder{
+			URI:  rootURI + "/" + folder,
+			Name: folder,
+		})
 	}
 
 	var initResult protocol.InitializeResult
@@ -160,17 +234,25 @@ func NewTestContext(t *testing.T) *LSPTestContext {
 // The path is relative to the temp directory root.
 // Content is treated as a Go text/template, with tc.TestData as the data context.
 // Use {{.KeyName}} to substitute values from TestData.
-// notificationHandler handles incoming JSON-RPC notifications from the server
+// notificationHandler handles incoming JSON-RPC messages from the server.
+// Notifications and calls are both captured by method; calls are answered
+// immediately so server handlers waiting on the client never block.
 func (tc *LSPTestContext) notificationHandler(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
-	// Only capture notifications (not calls)
-	if _, isNotification := req.(*jsonrpc2.Notification); !isNotification {
-		return nil
-	}
 	tc.notificationsMu.Lock()
 	tc.notifications[req.Method()] = append(tc.notifications[req.Method()], req.Params())
 	tc.notificationsMu.Unlock()
-	// No reply needed for notifications
-	return nil
+
+	if _, isNotification := req.(*jsonrpc2.Notification); isNotification {
+		// No reply needed for notifications
+		return nil
+	}
+
+	switch req.Method() {
+	case protocol.MethodWorkspaceApplyEdit:
+		return reply(ctx, protocol.ApplyWorkspaceEditResponse{Applied: true}, nil)
+	default:
+		return reply(ctx, nil, nil)
+	}
 }
 
 func (tc *LSPTestContext) GivenFile(path, content string) *LSPTestContext {
@@ -231,6 +313,7 @@ func (tc *LSPTestContext) GivenOpenFile(uri string) *LSPTestContext {
 	if err != nil {
 		tc.t.Fatalf("didOpen failed: %v", err)
 	}
+	tc.openDocs[fullURI] = string(content)
 
 	return tc
 }
@@ -257,6 +340,29 @@ func (tc *LSPTestContext) GivenSaveFile(uri string) *LSPTestContext {
 	return tc
 }
 
+// GivenWatchedFileChange sends a workspace/didChangeWatchedFiles notification
+// for a single file, as a client would when it changes outside the editor.
+func (tc *LSPTestContext) GivenWatchedFileChange(uri string, changeType protocol.FileChangeType) *LSPTestContext {
+	tc.t.Helper()
+
+	params := protocol.DidChangeWatchedFilesParams{
+		Changes: []*protocol.FileEvent{{
+			Type: changeType,
+			URI:  tc.resolveURI(uri),
+		}},
+	}
+
+	err := tc.conn.Notify(tc.ctx, "workspace/didChangeWatchedFiles", params)
+	if err != nil {
+		tc.t.Fatalf("didChangeWatchedFiles failed: %v", err)
+	}
+
+	// The index is updated like on save, so pollUntilIndexed waits for it
+	tc.lastSaveTime = time.Now()
+
+	return tc
+}
+
 // GivenChangeDocument triggers a didChange notification with full document sync.
 // The content parameter is the new full content of the document.
 func (tc *LSPTestContext) GivenChangeDocument(uri, content string) *LSPTestContext {
@@ -276,8 +382,7 @@ func (tc *LSPTestContext) GivenChangeDocument(uri, content string) *LSPTestConte
 		},
 		ContentChanges: []protocol.TextDocumentContentChangeEvent{
 			{
-				RangeLength: 0, // 0 indicates full document sync
-				Text:        content,
+				Text: content, // No range indicates full document sync
 			},
 		},
 	}
@@ -286,10 +391,76 @@ func (tc *LSPTestContext) GivenChangeDocument(uri, content string) *LSPTestConte
 	if err != nil {
 		tc.t.Fatalf("didChange failed: %v", err)
 	}
+	tc.openDocs[fullURI] = content
 
 	return tc
 }
 
+// GivenIncrementalChange triggers a didChange notification replacing the
+// given range of the document with text.
+func (tc *LSPTestContext) GivenIncrementalChange(uri string, r protocol.Range, text string) *LSPTestContext {
+	tc.t.Helper()
+
+	fullURI := tc.resolveURI(uri)
+	tc.docVersion++
+
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: fullURI,
+			},
+			Version: int32(tc.docVersion),
+		},
+		ContentChanges: []protocol.TextDocumentContentChangeEvent{
+			{
+				Range: &r,
+				Text:  text,
+			},
+		},
+	}
+
+	err := tc.conn.Notify(tc.ctx, "textDocument/didChange", params)
+	if err != nil {
+		tc.t.Fatalf("didChange failed: %v", err)
+	}
+	content := tc.openDocs[fullURI]
+	tc.openDocs[fullURI] = content[:offsetAt(content, r.Start)] + text + content[offsetAt(content, r.End):]
+
+	return tc
+}
+
+// DocumentContent returns the text the client holds for an open document,
+// as opened and then changed through the Given helpers. Edits the server
+// sends apply to this text.
+func (tc *LSPTestContext) DocumentContent(uri string) string {
+	tc.t.Helper()
+
+	content, ok := tc.openDocs[tc.resolveURI(uri)]
+	if !ok {
+		tc.t.Fatalf("Document %s is not open", uri)
+	}
+	return content
+}
+
+// offsetAt returns the byte offset in text of pos, whose character counts
+// UTF-16 code units as LSP positions do
+func offsetAt(text string, pos protocol.Position) int {
+	offset := 0
+	for range pos.Line {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	for units := uint32(0); units < pos.Character && offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units += uint32(utf16.RuneLen(r))
+		offset += size
+	}
+	return offset
+}
+
 // When performs an LSP operation and calls the handler with the result.
 // It wraps the operation in t.Run with a "when " prefix for Gherkin-style output.
 // For methods requiring indexed data, it polls internally until ready.
@@ -317,6 +488,17 @@ func (tc *LSPTestContext) Shutdown() {
 	<-tc.done
 }
 
+// WaitServed waits up to timeout for the server to stop serving the test
+// connection, reporting whether it did
+func (tc *LSPTestContext) WaitServed(timeout time.Duration) bool {
+	select {
+	case <-tc.served:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // NotificationCount returns the number of captured notifications for a method
 func (tc *LSPTestContext) NotificationCount(method string) int {
 	tc.notificationsMu.RLock()
@@ -368,7 +550,8 @@ func (tc *LSPTestContext) ClearNotifications(method string) {
 // requiresIndexing returns true if the method requires data to be indexed
 func requiresIndexing(method string) bool {
 	switch method {
-	case "textDocument/definition", "textDocument/references", "textDocument/codeLens":
+	case "textDocument/definition", "textDocument/references", "textDocument/codeLens", "workspace/executeCommand",
+		"callHierarchy/incomingCalls", "callHierarchy/outgoingCalls", "workspace/symbol", ourserver.MethodStatus, ourserver.MethodStartupVisibility:
 		return true
 	default:
 		return false
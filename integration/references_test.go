@@ -1,9 +1,12 @@
 package integration
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/org-lsp/orgscanner"
 	"go.lsp.dev/protocol"
 )
 
@@ -129,3 +132,245 @@ Another reference to [[id:{{.targetID}}]].`
 		},
 	)
 }
+
+func TestReferencesAreUnique(t *testing.T) {
+	Given("a table row linking to a heading twice, which go-org gives one position", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", "* Target Heading\n:PROPERTIES:\n:ID:       {{.targetID}}\n:END:\n").
+				GivenFile("table.org", "| [[id:{{.targetID}}][first]] | [[id:{{.targetID}}][second]] |\n").
+				GivenSaveFile("target.org").
+				GivenSaveFile("table.org").
+				GivenOpenFile("target.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("target.org")},
+					Position:     protocol.Position{Line: 0, Character: 3},
+				},
+			}
+
+			When(t, tc, "requesting references for the heading", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("lists each link once, at its own column", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2, "Expected both links in table.org")
+					testza.AssertEqual(t, uint32(2), result[0].Range.Start.Character)
+					testza.AssertEqual(t, uint32(len("| [[id:"+tc.TestData["targetID"]+"][first]] | ")), result[1].Range.Start.Character)
+				})
+			})
+		},
+	)
+}
+
+// linkingFileCount is well above orgscanner.DocumentCacheSize
+const linkingFileCount = 3 * orgscanner.DocumentCacheSize
+
+func TestReferencesBeyondDocumentCache(t *testing.T) {
+	Given("a target heading linked from more files than the document cache holds", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`)
+			for i := range linkingFileCount {
+				tc.GivenFile(fmt.Sprintf("notes/note%03d.org", i), "* Note\nSee [[id:{{.targetID}}]].")
+			}
+			tc.GivenSaveFile("target.org").GivenOpenFile("target.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("target.org")},
+					Position:     protocol.Position{Line: 0, Character: 5},
+				},
+			}
+
+			When(t, tc, "requesting references to the target heading", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("finds a reference in every linking file", t, func(t *testing.T) {
+					testza.AssertLen(t, result, linkingFileCount)
+				})
+			})
+		},
+	)
+}
+
+func TestReferencesRangesCoverLinkSyntax(t *testing.T) {
+	Given("links with descriptions, including one in a list item with markup", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("source.org", `* Source
+See [[id:{{.targetID}}][the target]] here.
+  - item [[id:{{.targetID}}][a *bold* target]] too`).
+				GivenSaveFile("target.org").
+				GivenSaveFile("source.org").
+				GivenOpenFile("target.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			linkLen := uint32(len("[[id:" + tc.TestData["targetID"] + "]"))
+
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("target.org")},
+					Position:     protocol.Position{Line: 0, Character: 5},
+				},
+			}
+
+			When(t, tc, "requesting references to the target heading", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("each range covers exactly the [[...][...]] syntax", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2)
+					sort.Slice(result, func(i, j int) bool { return result[i].Range.Start.Line < result[j].Range.Start.Line })
+
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 1, Character: 4},
+						End:   protocol.Position{Line: 1, Character: 4 + linkLen + uint32(len("[the target]]"))},
+					}, result[0].Range)
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 2, Character: 9},
+						End:   protocol.Position{Line: 2, Character: 9 + linkLen + uint32(len("[a *bold* target]]"))},
+					}, result[1].Range)
+				})
+			})
+
+			params.Context.IncludeDeclaration = true
+			When(t, tc, "requesting references including the declaration", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("the target headline comes first", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 3)
+					testza.AssertEqual(t, tc.DocURI("target.org"), result[0].URI)
+					testza.AssertEqual(t, protocol.Range{
+						Start: protocol.Position{Line: 0, Character: 0},
+						End:   protocol.Position{Line: 0, Character: uint32(len("* Target Heading"))},
+					}, result[0].Range)
+				})
+			})
+		},
+	)
+}
+
+func TestReferencesIncludeDeclaration(t *testing.T) {
+	Given("a target heading with an ID linked from another file", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+
+			tc.GivenFile("target.org", `* Intro
+Some text.
+* Target Heading
+:PROPERTIES:
+:ID:       {{.targetID}}
+:END:`).
+				GivenFile("source.org", `* Source
+See [[id:{{.targetID}}]].`).
+				GivenSaveFile("target.org").
+				GivenSaveFile("source.org").
+				GivenOpenFile("target.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			isDeclaration := func(loc protocol.Location) bool {
+				return loc.URI == tc.DocURI("target.org") && loc.Range.Start.Line == 2
+			}
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("target.org")},
+					Position:     protocol.Position{Line: 4, Character: 3},
+				},
+			}
+
+			When(t, tc, "requesting references without the declaration", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("only the link is returned", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertFalse(t, isDeclaration(result[0]))
+				})
+			})
+
+			params.Context.IncludeDeclaration = true
+			When(t, tc, "requesting references with the declaration", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("the declaring headline is returned alongside the link", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2)
+					testza.AssertTrue(t, isDeclaration(result[0]), "declaration should come first")
+					testza.AssertEqual(t, tc.DocURI("source.org"), result[1].URI)
+				})
+			})
+		},
+	)
+}
+
+func TestReferencesOnHeadingWithoutProperties(t *testing.T) {
+	Given("a heading with no property drawer", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("plain.org", "* Plain Heading\nSome text.\n").
+				GivenSaveFile("plain.org").
+				GivenOpenFile("plain.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("plain.org")},
+					Position:     protocol.Position{Line: 0, Character: 5},
+				},
+				Context: protocol.ReferenceContext{IncludeDeclaration: true},
+			}
+
+			When(t, tc, "requesting references to the heading", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("returns no references instead of failing", t, func(t *testing.T) {
+					testza.AssertNil(t, result)
+				})
+			})
+		},
+	)
+}
+
+func TestReferencesForTag(t *testing.T) {
+	Given("a tag used on headings in two files", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("a.org", "* Plans :work:\n* Other\n** Deep :home:work:\n").
+				GivenFile("b.org", "* Meeting :work:\n* Errands :home:\n").
+				GivenSaveFile("a.org").
+				GivenSaveFile("b.org").
+				GivenOpenFile("a.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.ReferenceParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("a.org")},
+					Position:     tc.PosAfter("a.org", "* Plans :wo"),
+				},
+			}
+
+			When(t, tc, "requesting references on the tag", "textDocument/references", params, func(t *testing.T, result []protocol.Location) {
+				Then("returns every heading carrying it across the workspace", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 3)
+					testza.AssertEqual(t, tc.DocURI("a.org"), result[0].URI)
+					testza.AssertEqual(t, uint32(0), result[0].Range.Start.Line)
+					testza.AssertEqual(t, tc.DocURI("a.org"), result[1].URI)
+					testza.AssertEqual(t, uint32(2), result[1].Range.Start.Line)
+					testza.AssertEqual(t, tc.DocURI("b.org"), result[2].URI)
+					testza.AssertEqual(t, uint32(0), result[2].Range.Start.Line)
+				})
+
+				Then("each location spans the heading line", t, func(t *testing.T) {
+					testza.AssertEqual(t, uint32(len("* Meeting :work:")), result[2].Range.End.Character)
+				})
+			})
+		},
+	)
+}
@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestPrepareRename(t *testing.T) {
+	Given("a file with a tagged headline and body text", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tags.org", `* Project Notes :work:
+Some body text here.
+`).GivenOpenFile("tags.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.PrepareRenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tags.org")},
+					Position:     tc.PosAfter("tags.org", ":wo"),
+				},
+			}
+
+			When(t, tc, "preparing a rename on a tag", "textDocument/prepareRename", params,
+				func(t *testing.T, result *protocol.Range) {
+					Then("returns the range of the tag name", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result, "Expected a range for the tag")
+						testza.AssertEqual(t, uint32(0), result.Start.Line)
+						testza.AssertEqual(t, uint32(17), result.Start.Character, "Range should start after the colon")
+						testza.AssertEqual(t, uint32(21), result.End.Character, "Range should end before the colon")
+					})
+				})
+
+			t.Run("when preparing a rename on body text", func(t *testing.T) {
+				proseParams := protocol.PrepareRenameParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tags.org")},
+						Position:     tc.PosAfter("tags.org", "Some bo"),
+					},
+				}
+				var result *protocol.Range
+				_, err := tc.conn.Call(tc.ctx, "textDocument/prepareRename", proseParams, &result)
+
+				Then("rejects the rename", t, func(t *testing.T) {
+					testza.AssertNotNil(t, err, "Expected an error on plain prose")
+					testza.AssertNil(t, result, "Expected no range on plain prose")
+				})
+			})
+		},
+	)
+}
+
+func TestRename(t *testing.T) {
+	Given("two files sharing a tag and an ID link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("a.org", `* Target :work:
+:PROPERTIES:
+:ID: 11111111-2222-3333-4444-555555555555
+:END:
+`).GivenFile("b.org", `* Other :work:home:
+See [[id:11111111-2222-3333-4444-555555555555][the target]].
+`).GivenOpenFile("a.org").GivenSaveFile("a.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			tagParams := protocol.RenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("a.org")},
+					Position:     tc.PosAfter("a.org", ":wo"),
+				},
+				NewName: "job",
+			}
+
+			When(t, tc, "renaming a tag", "textDocument/rename", tagParams,
+				func(t *testing.T, result *protocol.WorkspaceEdit) {
+					Then("renames the tag in every file", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result)
+						testza.AssertLen(t, result.Changes[tc.DocURI("a.org")], 1)
+						testza.AssertLen(t, result.Changes[tc.DocURI("b.org")], 1)
+
+						edit := result.Changes[tc.DocURI("b.org")][0]
+						testza.AssertEqual(t, "job", edit.NewText)
+						testza.AssertEqual(t, uint32(9), edit.Range.Start.Character, "Should only replace :work:, not :home:")
+					})
+				})
+
+			idParams := protocol.RenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("a.org")},
+					Position:     tc.PosAfter("a.org", ":ID: 1111"),
+				},
+				NewName: "target-id",
+			}
+
+			When(t, tc, "renaming an ID", "textDocument/rename", idParams,
+				func(t *testing.T, result *protocol.WorkspaceEdit) {
+					Then("renames the property and links to it", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result)
+						testza.AssertLen(t, result.Changes[tc.DocURI("a.org")], 1, "Should rename the :ID: property")
+						testza.AssertLen(t, result.Changes[tc.DocURI("b.org")], 1, "Should rename the id: link")
+						testza.AssertEqual(t, uint32(1), result.Changes[tc.DocURI("b.org")][0].Range.Start.Line)
+					})
+				})
+		},
+	)
+}
+
+func TestRenameCustomID(t *testing.T) {
+	Given("a heading with a CUSTOM_ID, two links to it, and another file using the same id", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("a.org", `* Setup
+:PROPERTIES:
+:CUSTOM_ID: setup
+:END:
+* Usage
+Finish [[#setup]] first, see [[#setup][the setup section]].
+Not [[#setup-extra]].
+`).GivenFile("b.org", `* Elsewhere
+[[#setup]]
+`).GivenOpenFile("a.org").GivenOpenFile("b.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			prepareParams := protocol.PrepareRenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("a.org")},
+					Position:     tc.PosAfter("a.org", "Finish [[#se"),
+				},
+			}
+
+			When(t, tc, "preparing a rename on a link", "textDocument/prepareRename", prepareParams,
+				func(t *testing.T, result *protocol.Range) {
+					Then("returns the range of the custom id", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result)
+						testza.AssertEqual(t, uint32(5), result.Start.Line)
+						testza.AssertEqual(t, uint32(10), result.Start.Character)
+						testza.AssertEqual(t, uint32(15), result.End.Character)
+					})
+				})
+
+			renameParams := protocol.RenameParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("a.org")},
+					Position:     tc.PosAfter("a.org", ":CUSTOM_ID: se"),
+				},
+				NewName: "installation",
+			}
+
+			When(t, tc, "renaming the CUSTOM_ID property", "textDocument/rename", renameParams,
+				func(t *testing.T, result *protocol.WorkspaceEdit) {
+					Then("renames the property and both links in the same file only", t, func(t *testing.T) {
+						testza.AssertNotNil(t, result)
+						edits := result.Changes[tc.DocURI("a.org")]
+						testza.AssertLen(t, edits, 3)
+						testza.AssertEqual(t, uint32(2), edits[0].Range.Start.Line)
+						testza.AssertEqual(t, uint32(5), edits[1].Range.Start.Line)
+						testza.AssertEqual(t, uint32(5), edits[2].Range.Start.Line)
+						for _, edit := range edits {
+							testza.AssertEqual(t, "installation", edit.NewText)
+						}
+						testza.AssertLen(t, result.Changes[tc.DocURI("b.org")], 0, "Custom ids are file-scoped")
+					})
+				})
+		},
+	)
+}
@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestMacroSignatureHelp(t *testing.T) {
+	Given("a two-argument macro and a call being typed", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("macros.org", `#+MACRO: greet Hello, $1 from $2!
+* Intro
+Say {{"{{{"}}greet(world,`).GivenOpenFile("macros.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			helpAt := func(marker string) protocol.SignatureHelpParams {
+				return protocol.SignatureHelpParams{
+					TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+						TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("macros.org")},
+						Position:     tc.PosAfter("macros.org", marker),
+					},
+				}
+			}
+
+			When(t, tc, "requesting signature help after the opening parenthesis", "textDocument/signatureHelp", helpAt("greet("), func(t *testing.T, help *protocol.SignatureHelp) {
+				Then("lists both parameters with the first active", t, func(t *testing.T) {
+					testza.AssertNotNil(t, help, "Expected signature help")
+					testza.AssertLen(t, help.Signatures, 1)
+					testza.AssertEqual(t, "greet($1, $2)", help.Signatures[0].Label)
+					testza.AssertEqual(t, []protocol.ParameterInformation{{Label: "$1"}, {Label: "$2"}}, help.Signatures[0].Parameters)
+					testza.AssertEqual(t, uint32(0), help.ActiveParameter)
+				})
+			})
+
+			When(t, tc, "requesting signature help after the first comma", "textDocument/signatureHelp", helpAt("greet(world,"), func(t *testing.T, help *protocol.SignatureHelp) {
+				Then("the second parameter is active", t, func(t *testing.T) {
+					testza.AssertNotNil(t, help, "Expected signature help")
+					testza.AssertEqual(t, uint32(1), help.ActiveParameter)
+				})
+			})
+		},
+	)
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/org-lsp/server"
+)
+
+func TestStatusRequest(t *testing.T) {
+	Given("a workspace with indexed files, IDs, and tags", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("firstID").WithUUID("secondID")
+
+			tc.GivenFile("a.org", `* First :work:
+:PROPERTIES:
+:ID:       {{.firstID}}
+:END:
+* Second
+:PROPERTIES:
+:ID:       {{.secondID}}
+:END:`).
+				GivenFile("b.org", "* Other :home:work:\nNo IDs here.").
+				GivenSaveFile("a.org").
+				GivenSaveFile("b.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "requesting org/status", server.MethodStatus, struct{}{}, func(t *testing.T, result server.ScanStatus) {
+				Then("counts match the created files", t, func(t *testing.T) {
+					testza.AssertEqual(t, 2, result.FileCount)
+					testza.AssertEqual(t, 2, result.UUIDCount)
+					testza.AssertEqual(t, 2, result.TagCount)
+				})
+
+				Then("reports when the last scan finished", t, func(t *testing.T) {
+					testza.AssertFalse(t, result.LastScanTime.IsZero())
+				})
+			})
+		},
+	)
+}
+
+func TestIndexingProgress(t *testing.T) {
+	Given("a workspace with several files present before initialize", t,
+		func(t *testing.T) *LSPTestContext {
+			files := make(map[string]string)
+			for i := range 4 {
+				files[fmt.Sprintf("note%d.org", i)] = fmt.Sprintf("* Note %d\nBody.", i)
+			}
+			return NewTestContextWithFiles(t, nil, files)
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			testza.AssertNotNil(t, tc.PollNotification("$/progress", time.Second))
+
+			var kinds []string
+			var last struct {
+				Token string `json:"token"`
+				Value struct {
+					Kind       string `json:"kind"`
+					Title      string `json:"title"`
+					Message    string `json:"message"`
+					Percentage uint32 `json:"percentage"`
+				} `json:"value"`
+			}
+			for _, raw := range tc.GetNotifications("$/progress") {
+				testza.AssertNoError(t, json.Unmarshal(raw, &last))
+				kinds = append(kinds, last.Value.Kind)
+			}
+
+			Then("progress begins and ends on the initialize token", t, func(t *testing.T) {
+				testza.AssertGreater(t, len(kinds), 2)
+				testza.AssertEqual(t, "begin", kinds[0])
+				testza.AssertEqual(t, "end", kinds[len(kinds)-1])
+				testza.AssertEqual(t, "initialize", last.Token)
+			})
+
+			Then("reports are sent as files are parsed", t, func(t *testing.T) {
+				testza.AssertContains(t, kinds, "report")
+			})
+		},
+	)
+}
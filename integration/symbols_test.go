@@ -80,6 +80,39 @@ Final content.
 	)
 }
 
+func TestDocumentSymbolKindsForTasks(t *testing.T) {
+	Given("a file with TODO, DONE, and plain headings", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("tasks.org", "* TODO Write the report\n** DONE Gather figures\n* Meeting notes\n").
+				GivenOpenFile("tasks.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.DocumentSymbolParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("tasks.org")},
+			}
+
+			When(t, tc, "requesting document symbols", "textDocument/documentSymbol", params, func(t *testing.T, result []protocol.DocumentSymbol) {
+				testza.AssertLen(t, result, 2)
+
+				Then("a TODO heading is an event", t, func(t *testing.T) {
+					testza.AssertEqual(t, protocol.SymbolKindEvent, result[0].Kind)
+				})
+
+				Then("a DONE heading is a constant", t, func(t *testing.T) {
+					testza.AssertLen(t, result[0].Children, 1)
+					testza.AssertEqual(t, protocol.SymbolKindConstant, result[0].Children[0].Kind)
+				})
+
+				Then("a plain heading keeps its level's kind", t, func(t *testing.T) {
+					testza.AssertEqual(t, protocol.SymbolKindNamespace, result[1].Kind)
+				})
+			})
+		},
+	)
+}
+
 func TestWorkspaceSymbols(t *testing.T) {
 	Given("multiple files with UUID headings", t,
 		func(t *testing.T) *LSPTestContext {
@@ -163,3 +196,258 @@ Items to buy.
 		},
 	)
 }
+
+func TestMultiRootWorkspaceSymbols(t *testing.T) {
+	Given("two workspace folders each with a UUID heading", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithWorkspaceFolders(t, "work", "personal")
+			tc.WithUUID("workID").WithUUID("personalID")
+
+			tc.GivenFile("work/projects.org", `* Quarterly Report
+:PROPERTIES:
+:ID:       {{.workID}}
+:END:
+`).
+				GivenFile("personal/garden.org", `* Garden Plans
+:PROPERTIES:
+:ID:       {{.personalID}}
+:END:
+`).
+				GivenSaveFile("work/projects.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "requesting all workspace symbols", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: ""}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("returns headings from both roots with their absolute URIs", t, func(t *testing.T) {
+					uris := make(map[string]protocol.DocumentURI)
+					for _, sym := range result {
+						uris[sym.Name] = sym.Location.URI
+					}
+
+					testza.AssertEqual(t, tc.DocURI("work/projects.org"), uris["Quarterly Report"])
+					testza.AssertEqual(t, tc.DocURI("personal/garden.org"), uris["Garden Plans"])
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsHeadingsWithoutIDs(t *testing.T) {
+	Given("a file whose headings have no IDs", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("plain.org", "* Meeting Notes\nSome notes.\n** Action Items\n- follow up\n").
+				GivenSaveFile("plain.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "searching for a heading", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "action"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("returns the heading at its position", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, "Action Items", result[0].Name)
+					testza.AssertEqual(t, "Meeting Notes", result[0].ContainerName)
+					testza.AssertEqual(t, tc.DocURI("plain.org"), result[0].Location.URI)
+					testza.AssertEqual(t, uint32(2), result[0].Location.Range.Start.Line)
+				})
+			})
+
+			When(t, tc, "searching with an empty query", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: ""}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("returns every heading", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2)
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsFileTitle(t *testing.T) {
+	Given("a file titled Meeting Notes", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("2024-05-01.org", "#+TITLE: Meeting Notes\n* Agenda\n").
+				GivenSaveFile("2024-05-01.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "searching for the title", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "meeting"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("returns a file symbol at the start of the file", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, "Meeting Notes", result[0].Name)
+					testza.AssertEqual(t, protocol.SymbolKindFile, result[0].Kind)
+					testza.AssertEqual(t, tc.DocURI("2024-05-01.org"), result[0].Location.URI)
+					testza.AssertEqual(t, protocol.Range{}, result[0].Location.Range)
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsFuzzyMatching(t *testing.T) {
+	Given("UUID headings with contiguous and scattered matches for a query", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("alphaID").WithUUID("hatsID").WithUUID("groceriesID")
+
+			content := `* Project Alpha
+:PROPERTIES:
+:ID:       {{.alphaID}}
+:END:
+* A Lot of Purple Hats Arranged
+:PROPERTIES:
+:ID:       {{.hatsID}}
+:END:
+* Groceries
+:PROPERTIES:
+:ID:       {{.groceriesID}}
+:END:
+`
+
+			tc.GivenFile("fuzzy.org", content).
+				GivenSaveFile("fuzzy.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "searching with a non-contiguous query", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "prjalp"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("matches the heading containing the query as a subsequence", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1, "Expected exactly 1 result for 'prjalp'")
+					testza.AssertEqual(t, "Project Alpha", result[0].Name)
+				})
+			})
+
+			When(t, tc, "searching with a query matched both contiguously and scattered", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "alpha"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("sorts the contiguous match before the scattered one", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 2, "Expected 2 results for 'alpha'")
+					testza.AssertEqual(t, "Project Alpha", result[0].Name)
+					testza.AssertEqual(t, "A Lot of Purple Hats Arranged", result[1].Name)
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsInheritedTags(t *testing.T) {
+	Given("a heading tagged :project: with an untagged child, in a file with #+FILETAGS", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("parentID").WithUUID("childID").WithUUID("otherID")
+
+			content := `#+FILETAGS: :notes:
+* Parent :project:
+:PROPERTIES:
+:ID:       {{.parentID}}
+:END:
+** Child
+:PROPERTIES:
+:ID:       {{.childID}}
+:END:
+* Other
+:PROPERTIES:
+:ID:       {{.otherID}}
+:END:
+`
+
+			tc.GivenFile("tags.org", content).
+				GivenSaveFile("tags.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "searching for #project", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "#project"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("finds the tagged parent and its untagged child", t, func(t *testing.T) {
+					var names []string
+					for _, symbol := range result {
+						names = append(names, symbol.Name)
+					}
+					testza.AssertLen(t, names, 2)
+					testza.AssertContains(t, names, "Parent")
+					testza.AssertContains(t, names, "Child")
+				})
+			})
+
+			When(t, tc, "searching for a #+FILETAGS tag", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "#notes"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("finds every heading in the file", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 3)
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsContainerName(t *testing.T) {
+	Given("nested UUID headings in a titled file and a top-level heading in an untitled one", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("parentID").WithUUID("childID").WithUUID("looseID")
+
+			tc.GivenFile("titled.org", `#+TITLE: Research Log
+* Parent Heading
+:PROPERTIES:
+:ID:       {{.parentID}}
+:END:
+** Child Heading
+:PROPERTIES:
+:ID:       {{.childID}}
+:END:
+`).
+				GivenFile("untitled.org", `* Loose Heading
+:PROPERTIES:
+:ID:       {{.looseID}}
+:END:
+`).
+				GivenSaveFile("titled.org").
+				GivenSaveFile("untitled.org")
+
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "listing all workspace symbols", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: ""}, func(t *testing.T, result []protocol.SymbolInformation) {
+				containers := make(map[string]string)
+				kinds := make(map[string]protocol.SymbolKind)
+				for _, symbol := range result {
+					containers[symbol.Name] = symbol.ContainerName
+					kinds[symbol.Name] = symbol.Kind
+				}
+
+				Then("a sub-heading is contained by its parent heading", t, func(t *testing.T) {
+					testza.AssertEqual(t, "Parent Heading", containers["Child Heading"])
+					testza.AssertNotEqual(t, kinds["Parent Heading"], kinds["Child Heading"], "Kinds should follow heading level")
+				})
+
+				Then("top-level headings are contained by the file title or name", t, func(t *testing.T) {
+					testza.AssertEqual(t, "Research Log", containers["Parent Heading"])
+					testza.AssertEqual(t, "untitled.org", containers["Loose Heading"])
+				})
+			})
+		},
+	)
+}
+
+func TestWorkspaceSymbolsCustomTodoKeywords(t *testing.T) {
+	Given("indexed files using custom TODO keywords from #+TODO: and the configuration", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContextWithOptions(t, map[string]any{"todoKeywords": "NEXT | DONE"})
+			tc.GivenFile("own.org", "#+TODO: WAIT | DONE\n* WAIT Call the bank\n").
+				GivenFile("configured.org", "* NEXT Renew the lease\n").
+				GivenSaveFile("own.org").
+				GivenSaveFile("configured.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			When(t, tc, "searching for the heading of a file with its own keywords", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "bank"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("leaves the keyword out of the name", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, "Call the bank", result[0].Name)
+				})
+			})
+
+			When(t, tc, "searching for the heading using the configured keywords", "workspace/symbol", protocol.WorkspaceSymbolParams{Query: "lease"}, func(t *testing.T, result []protocol.SymbolInformation) {
+				Then("leaves the keyword out of the name", t, func(t *testing.T) {
+					testza.AssertLen(t, result, 1)
+					testza.AssertEqual(t, "Renew the lease", result[0].Name)
+				})
+			})
+		},
+	)
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"go.lsp.dev/protocol"
+)
+
+func TestCRLFLineEndings(t *testing.T) {
+	Given("a document with CRLF line endings typing an id link", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.WithUUID("targetID")
+			tc.GivenFile("target.org", "* Target Heading\r\n:PROPERTIES:\r\n:ID: {{.targetID}}\r\n:END:\r\n").
+				GivenFile("source.org", "* Source   \r\nSee [[id:\r\nMore text.\r\n").
+				GivenSaveFile("target.org").
+				GivenOpenFile("source.org")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			completion := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+					Position:     protocol.Position{Line: 1, Character: uint32(len("See [[id:"))},
+				},
+			}
+
+			When(t, tc, "requesting completion after [[id:", "textDocument/completion", completion, func(t *testing.T, result *protocol.CompletionList) {
+				Then("the id prefix is still detected", t, func(t *testing.T) {
+					testza.AssertLen(t, result.Items, 1)
+					testza.AssertEqual(t, "Target Heading", result.Items[0].Label)
+					testza.AssertEqual(t, tc.TestData["targetID"]+"]]", result.Items[0].InsertText)
+				})
+			})
+
+			formatting := protocol.DocumentFormattingParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("source.org")},
+			}
+			When(t, tc, "formatting the document", "textDocument/formatting", formatting, func(t *testing.T, edits []protocol.TextEdit) {
+				Then("edits keep the document's CRLF line endings", t, func(t *testing.T) {
+					testza.AssertGreater(t, len(edits), 0)
+					for _, edit := range edits {
+						testza.AssertNotContains(t, strings.ReplaceAll(edit.NewText, "\r\n", ""), "\n")
+					}
+				})
+			})
+		},
+	)
+}
+
+func TestCRLFLineEndingsFromIncrementalChanges(t *testing.T) {
+	Given("a document whose client starts typing CRLF line breaks", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", "* Notes\n").GivenOpenFile("test.org")
+			tc.GivenIncrementalChange("test.org", protocol.Range{
+				Start: protocol.Position{Line: 1, Character: 0},
+				End:   protocol.Position{Line: 1, Character: 0},
+			}, "Body.\r\n")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			params := protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 0},
+					End:   protocol.Position{Line: 0, Character: 0},
+				},
+			}
+
+			When(t, tc, "requesting a multi-line code action", "textDocument/codeAction", params, func(t *testing.T, actions []protocol.CodeAction) {
+				action := findAction(actions, "Org: Set CUSTOM_ID")
+				testza.AssertNotNil(t, action)
+
+				Then("its edit uses CRLF line endings", t, func(t *testing.T) {
+					edit := action.Edit.Changes[tc.DocURI("test.org")][0]
+					testza.AssertEqual(t, ":PROPERTIES:\r\n:CUSTOM_ID: notes\r\n:END:\r\n", edit.NewText)
+				})
+			})
+		},
+	)
+}
+
+func TestLineIndexAfterIncrementalEdits(t *testing.T) {
+	Given("an open document edited line by line", t,
+		func(t *testing.T) *LSPTestContext {
+			tc := NewTestContext(t)
+			tc.GivenFile("test.org", "* Notes\nFirst line.\n").GivenOpenFile("test.org")
+			// Add lines, join two, then start a block on the last one
+			tc.GivenIncrementalChange("test.org", protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 0},
+				End:   protocol.Position{Line: 2, Character: 0},
+			}, "Second line.\nThird line.\n").
+				GivenIncrementalChange("test.org", protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 11},
+					End:   protocol.Position{Line: 2, Character: 0},
+				}, " ").
+				GivenIncrementalChange("test.org", protocol.Range{
+					Start: protocol.Position{Line: 3, Character: 0},
+					End:   protocol.Position{Line: 3, Character: 0},
+				}, "#+begin_s")
+			return tc
+		},
+		func(t *testing.T, tc *LSPTestContext) {
+			completion := protocol.CompletionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: tc.DocURI("test.org")},
+					Position:     protocol.Position{Line: 3, Character: uint32(len("#+begin_s"))},
+				},
+			}
+			When(t, tc, "requesting completion on the edited line", "textDocument/completion", completion, func(t *testing.T, result *protocol.CompletionList) {
+				Then("the block prefix is detected", t, func(t *testing.T) {
+					labels := make([]string, len(result.Items))
+					for i, item := range result.Items {
+						labels[i] = item.Label
+					}
+					testza.AssertContains(t, labels, "#+begin_src")
+				})
+			})
+		},
+	)
+}
@@ -0,0 +1,119 @@
+package orgscanner
+
+import (
+	"container/list"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alexispurslane/go-org/org"
+)
+
+// DocumentCacheSize is the number of parsed documents an OrgScanner keeps in
+// memory. Other files are re-parsed from disk when needed.
+const DocumentCacheSize = 64
+
+// documentCache is a small LRU cache of parsed documents keyed by file path.
+type documentCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front is most recently used
+}
+
+// cachedDocument is a parsed document and the ModTime it was parsed at.
+type cachedDocument struct {
+	path    string
+	modTime time.Time
+	doc     *org.Document
+}
+
+func newDocumentCache(capacity int) *documentCache {
+	return &documentCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the cached document for path if it was parsed at modTime.
+func (c *documentCache) get(path string, modTime time.Time) (*org.Document, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedDocument)
+	if !entry.modTime.Equal(modTime) {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.doc, true
+}
+
+// put stores doc for path, evicting the least recently used document when
+// the cache is full.
+func (c *documentCache) put(path string, modTime time.Time, doc *org.Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[path]; ok {
+		elem.Value = &cachedDocument{path: path, modTime: modTime, doc: doc}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[path] = c.order.PushFront(&cachedDocument{path: path, modTime: modTime, doc: doc})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedDocument).path)
+	}
+}
+
+// remove drops the cached document for path, if any.
+func (c *documentCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[path]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, path)
+	}
+}
+
+// len returns the number of cached documents.
+func (c *documentCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Document returns the parsed document for an indexed file, re-parsing it
+// from disk unless a recent parse is cached. Returns nil if the file can't
+// be read.
+func (s *OrgScanner) Document(info *FileInfo) *org.Document {
+	if doc, ok := s.docs.get(info.Path, info.ModTime); ok {
+		return doc
+	}
+
+	absPath := filepath.Join(s.Root, info.Path)
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		slog.Debug("Failed to re-read indexed file", "path", info.Path, "error", err)
+		return nil
+	}
+
+	doc := ParseDocument(string(data), absPath, s.TodoKeywords)
+	s.docs.put(info.Path, info.ModTime, doc)
+	return doc
+}
+
+// CachedDocumentCount returns the number of parsed documents held in memory.
+func (s *OrgScanner) CachedDocumentCount() int {
+	return s.docs.len()
+}
@@ -4,20 +4,59 @@ package orgscanner
 
 import (
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/alexispurslane/go-org/org"
 )
 
-func NewOrgScanner(root string) *OrgScanner {
+// NewOrgScanner creates a scanner that indexes root and any extraRoots into
+// a single index, with file paths relative to root.
+func NewOrgScanner(root string, extraRoots ...string) *OrgScanner {
 	return &OrgScanner{
 		ProcessedFiles: &ProcessedFiles{
-			Files:     sync.Map{},
-			UuidIndex: sync.Map{},
-			TagMap:    make(map[string]map[string]bool),
+			Files:          sync.Map{},
+			UuidIndex:      sync.Map{},
+			RoamRefs:       sync.Map{},
+			HeadingIndex:   sync.Map{},
+			TagMap:         make(map[string]map[string][]org.Position),
+			PropertyValues: make(map[string]map[string]int),
 		},
 		LastScanTime: time.Now(),
 		Root:         root,
+		Roots:        append([]string{root}, extraRoots...),
+		docs:         newDocumentCache(DocumentCacheSize),
+	}
+}
+
+// AddRoot adds a directory to the set of scanned roots. Its files are
+// indexed on the next Process.
+func (s *OrgScanner) AddRoot(root string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.Roots {
+		if r == root {
+			return
+		}
 	}
+	s.Roots = append(s.Roots, root)
+}
+
+// RemoveRoot removes a directory from the set of scanned roots. Its files are
+// pruned from the index on the next Process.
+func (s *OrgScanner) RemoveRoot(root string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roots := s.Roots[:0]
+	for _, r := range s.Roots {
+		if r != root {
+			roots = append(roots, r)
+		}
+	}
+	s.Roots = roots
 }
 
 // GetLastScanTime returns the time of the last completed scan in a thread-safe manner.
@@ -30,6 +69,13 @@ func (s *OrgScanner) GetLastScanTime() time.Time {
 // Process performs an incremental scan and processes all file messages.
 // It executes the appropriate action (parse or delete) for each file.
 func (s *OrgScanner) Process() error {
+	return s.ProcessWithProgress(nil)
+}
+
+// ProcessWithProgress is like Process, but calls progress, if non-nil, each
+// time a file has been parsed, with the number of files parsed so far and
+// the total to parse. Calls are serialized, so done only increases.
+func (s *OrgScanner) ProcessWithProgress(progress func(done, total int)) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -47,32 +93,26 @@ func (s *OrgScanner) Process() error {
 		if msg.Action != ShouldDelete {
 			continue
 		}
-		path := msg.Info.Path
-
-		// Cleanup UUIDs
-		for uuid := range msg.Info.UUIDs {
-			s.ProcessedFiles.UuidIndex.Delete(uuid)
-		}
-
-		// Cleanup TagMap - remove this file from all tag sets
-		for _, tag := range msg.Info.Tags {
-			if tagSet, ok := s.ProcessedFiles.TagMap[tag]; ok {
-				delete(tagSet, path)
-				// Clean up empty tag sets
-				if len(tagSet) == 0 {
-					delete(s.ProcessedFiles.TagMap, tag)
-				}
-			}
-		}
-
-		// Remove from Files map
-		s.ProcessedFiles.Files.Delete(path)
-		slog.Debug("Removed file from index", "path", path)
+		s.removeFileUnlocked(msg.Info)
 	}
 
 	// Phase 2: Process all parses concurrently
 	var wg sync.WaitGroup
-	var mu sync.Mutex // Protects Files and TagMap updates
+	var mu sync.Mutex // Protects Files and TagMap updates, and done
+
+	total := 0
+	for _, msg := range messages {
+		if msg.Action == ShouldParse {
+			total++
+		}
+	}
+	done := 0
+	finished := func() {
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+	}
 
 	for _, msg := range messages {
 		if msg.Action != ShouldParse {
@@ -84,44 +124,16 @@ func (s *OrgScanner) Process() error {
 			defer wg.Done()
 
 			// Do what we can concurrently
-			parsed, err := ParseFile(m.Info.Path, s.Root)
-			if err != nil || parsed == nil {
-				return
-			}
-
-			// Remove old UUIDs for this file if it exists (re-parsing case)
-			if oldFileData, exists := s.ProcessedFiles.Files.Load(parsed.Path); exists {
-				if oldFile, ok := oldFileData.(*FileInfo); ok {
-					for uuid := range oldFile.UUIDs {
-						s.ProcessedFiles.UuidIndex.Delete(uuid)
-					}
-				}
-			}
-
-			// Put the new UUIDs in
-			for uuid, info := range parsed.UUIDs {
-				s.ProcessedFiles.UuidIndex.Store(uuid, HeaderLocation{
-					FilePath: parsed.Path,
-					Position: info.Position,
-					Title:    info.Title,
-					Level:    info.Level,
-				})
-			}
+			parsed, err := ParseFile(m.Info.Path, s.Root, s.TodoKeywords)
 
 			// Now we need to lock to update the tags and file list
 			mu.Lock()
 			defer mu.Unlock()
-
-			// Update tag map - add this file's path to each tag set
-			for _, tag := range parsed.Tags {
-				if s.ProcessedFiles.TagMap[tag] == nil {
-					s.ProcessedFiles.TagMap[tag] = make(map[string]bool)
-				}
-				s.ProcessedFiles.TagMap[tag][parsed.Path] = true
+			defer finished()
+			if err != nil || parsed == nil {
+				return
 			}
-
-			// Store/Update in Files map (as pointer)
-			s.ProcessedFiles.Files.Store(parsed.Path, parsed)
+			s.indexFileUnlocked(parsed)
 		}(msg)
 	}
 	wg.Wait()
@@ -138,3 +150,184 @@ func (s *OrgScanner) Process() error {
 
 	return nil
 }
+
+// UpdateFile re-parses a single file, given relative to Root, into the index.
+// Files that no longer exist or are empty are removed instead.
+func (s *OrgScanner) UpdateFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parsed, err := ParseFile(path, s.Root, s.TodoKeywords)
+	if err != nil || parsed == nil {
+		s.removePathUnlocked(path)
+		s.LastScanTime = time.Now()
+		return err
+	}
+	s.indexFileUnlocked(parsed)
+	s.LastScanTime = time.Now()
+	slog.Debug("Re-indexed file", "path", path)
+	return nil
+}
+
+// RemoveFile prunes a single file, given relative to Root, from the index.
+func (s *OrgScanner) RemoveFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removePathUnlocked(path)
+	s.LastScanTime = time.Now()
+}
+
+// removePathUnlocked removes the indexed file at path, if any.
+func (s *OrgScanner) removePathUnlocked(path string) {
+	if data, exists := s.ProcessedFiles.Files.Load(path); exists {
+		if info, ok := data.(*FileInfo); ok {
+			s.removeFileUnlocked(info)
+		}
+	}
+}
+
+// removeFileUnlocked drops a file's UUIDs, roam refs, headings, tags,
+// property values, and entry from the index.
+func (s *OrgScanner) removeFileUnlocked(info *FileInfo) {
+	path := info.Path
+
+	// Cleanup UUIDs and roam refs
+	for uuid := range info.UUIDs {
+		s.ProcessedFiles.UuidIndex.Delete(uuid)
+	}
+	for ref := range info.RoamRefs {
+		s.ProcessedFiles.RoamRefs.Delete(ref)
+	}
+	s.ProcessedFiles.HeadingIndex.Delete(path)
+
+	s.unindexTagsUnlocked(info)
+	s.unindexPropertyValuesUnlocked(info)
+
+	// Remove from Files map and drop any cached parse
+	s.ProcessedFiles.Files.Delete(path)
+	s.docs.remove(path)
+	slog.Debug("Removed file from index", "path", path)
+}
+
+// indexFileUnlocked stores a parsed file in the index, replacing the UUIDs,
+// roam refs, headings, tags, and property values of any previous version. Callers must
+// serialize TagMap and PropertyValues access.
+func (s *OrgScanner) indexFileUnlocked(parsed *FileInfo) {
+	// Remove old UUIDs and roam refs for this file if it exists (re-parsing case)
+	if oldFileData, exists := s.ProcessedFiles.Files.Load(parsed.Path); exists {
+		if oldFile, ok := oldFileData.(*FileInfo); ok {
+			for uuid := range oldFile.UUIDs {
+				s.ProcessedFiles.UuidIndex.Delete(uuid)
+			}
+			for ref := range oldFile.RoamRefs {
+				s.ProcessedFiles.RoamRefs.Delete(ref)
+			}
+			s.unindexTagsUnlocked(oldFile)
+			s.unindexPropertyValuesUnlocked(oldFile)
+		}
+	}
+
+	// Put the new UUIDs in
+	for uuid, info := range parsed.UUIDs {
+		s.ProcessedFiles.UuidIndex.Store(uuid, HeaderLocation{
+			FilePath: parsed.Path,
+			Position: info.Position,
+			Title:    info.Title,
+			Level:    info.Level,
+			Tags:     info.Tags,
+			Parent:   info.Parent,
+		})
+	}
+
+	for ref, info := range parsed.RoamRefs {
+		s.ProcessedFiles.RoamRefs.Store(ref, HeaderLocation{
+			FilePath: parsed.Path,
+			Position: info.Position,
+			Title:    info.Title,
+			Level:    info.Level,
+			Tags:     info.Tags,
+		})
+	}
+
+	// Headings are stored per file, so re-indexing replaces them wholesale
+	headings := make([]HeaderLocation, len(parsed.Headings))
+	for i, info := range parsed.Headings {
+		headings[i] = HeaderLocation{
+			FilePath: parsed.Path,
+			Position: info.Position,
+			Title:    info.Title,
+			Level:    info.Level,
+			Tags:     info.Tags,
+			Parent:   info.Parent,
+		}
+	}
+	s.ProcessedFiles.HeadingIndex.Store(parsed.Path, headings)
+
+	// Update tag map - add this file's path and headlines to each tag
+	for _, tag := range fileTagNames(parsed) {
+		if s.ProcessedFiles.TagMap[tag] == nil {
+			s.ProcessedFiles.TagMap[tag] = make(map[string][]org.Position)
+		}
+		s.ProcessedFiles.TagMap[tag][parsed.Path] = parsed.TagHeadlines[tag]
+	}
+
+	// Count this file's use of each property value
+	for key, values := range parsed.PropertyValues {
+		if s.ProcessedFiles.PropertyValues[key] == nil {
+			s.ProcessedFiles.PropertyValues[key] = make(map[string]int)
+		}
+		for _, value := range values {
+			s.ProcessedFiles.PropertyValues[key][value]++
+		}
+	}
+
+	// Store/Update in Files map (as pointer), dropping any stale parse
+	s.ProcessedFiles.Files.Store(parsed.Path, parsed)
+	s.docs.remove(parsed.Path)
+}
+
+// unindexTagsUnlocked drops a file from the TagMap entries of its tags,
+// removing tags no file uses anymore.
+func (s *OrgScanner) unindexTagsUnlocked(info *FileInfo) {
+	for _, tag := range fileTagNames(info) {
+		if files, ok := s.ProcessedFiles.TagMap[tag]; ok {
+			delete(files, info.Path)
+			// Clean up tags no file uses anymore
+			if len(files) == 0 {
+				delete(s.ProcessedFiles.TagMap, tag)
+			}
+		}
+	}
+}
+
+// fileTagNames returns every tag info's file uses: its file-level tags and
+// those of any of its headlines
+func fileTagNames(info *FileInfo) []string {
+	tags := slices.Clone(info.Tags)
+	for tag := range info.TagHeadlines {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// unindexPropertyValuesUnlocked drops a file's property values from the
+// PropertyValues counts, removing values and keys no file uses anymore.
+func (s *OrgScanner) unindexPropertyValuesUnlocked(info *FileInfo) {
+	for key, values := range info.PropertyValues {
+		counts, ok := s.ProcessedFiles.PropertyValues[key]
+		if !ok {
+			continue
+		}
+		for _, value := range values {
+			if counts[value]--; counts[value] <= 0 {
+				delete(counts, value)
+			}
+		}
+		if len(counts) == 0 {
+			delete(s.ProcessedFiles.PropertyValues, key)
+		}
+	}
+}
@@ -3,19 +3,23 @@
 package orgscanner
 
 import (
-	"bytes"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/alexispurslane/go-org/org"
 )
 
-// ParseFile reads and parses an org-mode file relative to root, extracting metadata.
-func ParseFile(filePath, root string) (*FileInfo, error) {
+// ParseFile reads and parses an org-mode file relative to root, extracting
+// metadata. todoKeywords is the TODO sequence used when the file doesn't
+// define its own; see ParseDocument.
+func ParseFile(filePath, root, todoKeywords string) (*FileInfo, error) {
 	absPath := filepath.Join(root, filePath)
 	slog.Debug("Parsing org file", "path", filePath)
 
@@ -34,17 +38,25 @@ func ParseFile(filePath, root string) (*FileInfo, error) {
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	conf := org.New()
-	doc := conf.Parse(bytes.NewReader(data), absPath)
+	doc := ParseDocument(string(data), absPath, todoKeywords)
 
 	result := &FileInfo{
-		Path:      filePath,
-		ModTime:   info.ModTime(),
-		Preview:   extractPreview(doc, 500),
-		Title:     extractTitle(doc),
-		Tags:      extractTags(doc),
-		UUIDs:     extractUUIDs(doc),
-		ParsedOrg: doc,
+		Path:           filePath,
+		ModTime:        info.ModTime(),
+		Preview:        extractPreview(doc, 500),
+		Title:          extractTitle(doc),
+		DocTitle:       extractDocTitle(doc),
+		Tags:           extractTags(doc),
+		UUIDs:          extractUUIDs(doc),
+		DenoteID:       ParseDenoteID(filePath),
+		RoamRefs:       extractRoamRefs(doc),
+		PropertyValues: extractPropertyValues(doc),
+		TagHeadlines:   extractTagHeadlines(doc),
+		Headings:       extractHeadings(doc),
+		Links:          Links(doc),
+		Startup:        StartupVisibility(doc),
+		Category:       Keyword(doc, "CATEGORY"),
+		Archive:        Keyword(doc, "ARCHIVE"),
 	}
 
 	slog.Debug("Extracted file metadata",
@@ -56,16 +68,81 @@ func ParseFile(filePath, root string) (*FileInfo, error) {
 	return result, nil
 }
 
+// ParseDocument parses content, recognizing the TODO keywords it defines
+// or, failing that, todoKeywords, the workspace's configured sequence. go-org
+// only reads #+TODO: itself, so the effective sequences are handed to it as
+// the default TODO setting, where doc.Get("TODO") also finds them later.
+func ParseDocument(content, path, todoKeywords string) *org.Document {
+	conf := org.New()
+	conf.DefaultSettings["TODO"] = TodoSequence(content, todoKeywords)
+	return conf.Parse(strings.NewReader(content), path)
+}
+
+// DefaultTodoSequence is org's TODO keyword sequence when neither the
+// document nor the workspace configuration defines one
+const DefaultTodoSequence = "TODO | DONE"
+
+// todoSettingRegexp matches #+TODO:, #+SEQ_TODO:, and #+TYP_TODO: lines,
+// capturing the keyword sequence they define
+var todoSettingRegexp = regexp.MustCompile(`(?im)^[ \t]*#\+(?:SEQ_|TYP_)?TODO:[ \t]*(.*?)[ \t]*$`)
+
+// TodoSequence returns the TODO keyword sequences defined in content, one
+// per line, falling back to todoKeywords and then to DefaultTodoSequence
+func TodoSequence(content, todoKeywords string) string {
+	var sequences []string
+	for _, match := range todoSettingRegexp.FindAllStringSubmatch(content, -1) {
+		if match[1] != "" {
+			sequences = append(sequences, match[1])
+		}
+	}
+	if len(sequences) > 0 {
+		return strings.Join(sequences, "\n")
+	}
+	if strings.TrimSpace(todoKeywords) != "" {
+		return todoKeywords
+	}
+	return DefaultTodoSequence
+}
+
+// extractDocTitle gets the title from the #+TITLE directive only
+func extractDocTitle(doc *org.Document) string {
+	return Keyword(doc, "TITLE")
+}
+
+// Keyword returns the value of the file-level #+KEY: keyword, written in
+// either upper or lower case. Returns "" if the file doesn't set it.
+func Keyword(doc *org.Document, key string) string {
+	if value := doc.Get(strings.ToUpper(key)); value != "" {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(doc.Get(strings.ToLower(key)))
+}
+
+// StartupVisibility returns the initial visibility a #+STARTUP: keyword
+// asks for, normalized to overview, content, or showall ("fold" is an alias
+// for overview, "nofold" and "showeverything" for showall). Returns "" if
+// the file doesn't set one; the last one wins if it sets several.
+func StartupVisibility(doc *org.Document) string {
+	visibility := ""
+	for _, option := range strings.Fields(doc.Get("STARTUP") + " " + doc.Get("startup")) {
+		switch strings.ToLower(option) {
+		case "overview", "fold":
+			visibility = "overview"
+		case "content":
+			visibility = "content"
+		case "showall", "nofold", "showeverything":
+			visibility = "showall"
+		}
+	}
+	return visibility
+}
+
 // extractTitle gets the title from #+TITLE directive or first headline.
 func extractTitle(doc *org.Document) string {
-	if title := doc.Get("TITLE"); title != "" {
+	if title := extractDocTitle(doc); title != "" {
 		slog.Debug("Found title in #+TITLE: directive", "title", title)
 		return title
 	}
-	if title := doc.Get("title"); title != "" {
-		slog.Debug("Found title in #+title: directive", "title", title)
-		return title
-	}
 
 	for _, node := range doc.Nodes {
 		if headline, ok := node.(org.Headline); ok {
@@ -81,17 +158,46 @@ func extractTitle(doc *org.Document) string {
 	return ""
 }
 
-// extractTags gets tags from the first headline.
+// extractTags gets the file-level tags from #+FILETAGS merged with the tags
+// of the first tagged headline.
 func extractTags(doc *org.Document) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, tag := range extractFileTags(doc) {
+		add(tag)
+	}
+	if len(tags) > 0 {
+		slog.Debug("Extracted tags from #+FILETAGS", "tags", tags)
+	}
+
 	for _, node := range doc.Nodes {
 		if headline, ok := node.(org.Headline); ok {
 			if len(headline.Tags) > 0 {
 				slog.Debug("Extracted tags from headline", "tags", headline.Tags)
-				return headline.Tags
+				for _, tag := range headline.Tags {
+					add(tag)
+				}
+				break
 			}
 		}
 	}
-	return nil
+	return tags
+}
+
+// extractFileTags parses #+FILETAGS: :a:b:, which applies to the whole file.
+func extractFileTags(doc *org.Document) []string {
+	fileTags := doc.Get("FILETAGS")
+	if fileTags == "" {
+		fileTags = doc.Get("filetags")
+	}
+	return strings.Fields(strings.ReplaceAll(fileTags, ":", " "))
 }
 
 // normalizePosition ensures that end position is at least as valid as start position.
@@ -110,18 +216,28 @@ func normalizePosition(pos org.Position) org.Position {
 func extractUUIDs(doc *org.Document) FileUUIDPositions {
 	uuidToPosition := make(FileUUIDPositions)
 
-	var walkSections func(sections []*org.Section)
-	walkSections = func(sections []*org.Section) {
+	// Tags are inherited, so carry the file's and each ancestor's tags down
+	// along with the parent's title
+	var walkSections func(sections []*org.Section, inherited []string, parent string)
+	walkSections = func(sections []*org.Section, inherited []string, parent string) {
 		for _, section := range sections {
-			if section.Headline != nil && section.Headline.Properties != nil {
-				headline := section.Headline
-				extractUUID(headline, uuidToPosition)
+			tags, title := inherited, parent
+			if section.Headline != nil {
+				tags = mergeTags(inherited, section.Headline.Tags)
+				title = strings.TrimSpace(org.String(section.Headline.Title...))
+				if section.Headline.Properties != nil {
+					extractUUID(section.Headline, tags, parent, uuidToPosition)
+				}
 			}
-			walkSections(section.Children)
+			walkSections(section.Children, tags, title)
 		}
 	}
 
-	walkSections(doc.Outline.Children)
+	fileTitle := doc.Get("TITLE")
+	if fileTitle == "" {
+		fileTitle = doc.Get("title")
+	}
+	walkSections(doc.Outline.Children, extractFileTags(doc), fileTitle)
 
 	if len(uuidToPosition) > 0 {
 		slog.Debug("Extracted UUIDs from property drawers", "uuid_count", len(uuidToPosition))
@@ -129,11 +245,57 @@ func extractUUIDs(doc *org.Document) FileUUIDPositions {
 	return uuidToPosition
 }
 
+// extractHeadings walks the document outline collecting every headline,
+// whether or not it has an ID, with its inherited tags and parent title.
+func extractHeadings(doc *org.Document) []UUIDInfo {
+	var headings []UUIDInfo
+
+	var walkSections func(sections []*org.Section, inherited []string, parent string)
+	walkSections = func(sections []*org.Section, inherited []string, parent string) {
+		for _, section := range sections {
+			tags, title := inherited, parent
+			if section.Headline != nil {
+				tags = mergeTags(inherited, section.Headline.Tags)
+				title = strings.TrimSpace(org.String(section.Headline.Title...))
+				headings = append(headings, UUIDInfo{
+					Position: normalizePosition(section.Headline.Pos),
+					Title:    title,
+					Level:    section.Headline.Lvl,
+					Tags:     tags,
+					Parent:   parent,
+				})
+			}
+			walkSections(section.Children, tags, title)
+		}
+	}
+
+	fileTitle := doc.Get("TITLE")
+	if fileTitle == "" {
+		fileTitle = doc.Get("title")
+	}
+	walkSections(doc.Outline.Children, extractFileTags(doc), fileTitle)
+
+	return headings
+}
+
+// mergeTags returns inherited followed by any of own not already in it,
+// without modifying inherited
+func mergeTags(inherited, own []string) []string {
+	merged := append([]string(nil), inherited...)
+	for _, tag := range own {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
 // extractUUID takes a headline and finds all of the ID properties with valid
-// UUIDs in its property drawer and adds them to uuidToPosition
+// UUIDs in its property drawer and adds them to uuidToPosition along with
+// the headline's inherited tags and parent title
 //
 // IMPORTANT: modifies uuidToPosition!
-func extractUUID(headline *org.Headline, uuidToPosition FileUUIDPositions) {
+func extractUUID(headline *org.Headline, tags []string, parent string, uuidToPosition FileUUIDPositions) {
 	for _, prop := range headline.Properties.Properties {
 		if prop[0] == "ID" && prop[1] != "" {
 			id := UUID(prop[1])
@@ -142,19 +304,172 @@ func extractUUID(headline *org.Headline, uuidToPosition FileUUIDPositions) {
 					Position: normalizePosition(headline.Pos),
 					Title:    strings.TrimSpace(org.String(headline.Title...)),
 					Level:    headline.Lvl,
+					Tags:     tags,
+					Parent:   parent,
 				}
 			}
 		}
 	}
 }
 
+// extractPropertyValues collects the distinct values every headline gives
+// each property, keyed by the upper-cased property name. IDs are left out,
+// since no two headings share one and UuidIndex already holds them.
+func extractPropertyValues(doc *org.Document) map[string][]string {
+	values := make(map[string][]string)
+
+	var walkSections func(sections []*org.Section)
+	walkSections = func(sections []*org.Section) {
+		for _, section := range sections {
+			if headline := section.Headline; headline != nil && headline.Properties != nil {
+				for _, prop := range headline.Properties.Properties {
+					if len(prop) < 2 || prop[1] == "" {
+						continue
+					}
+					key := strings.ToUpper(prop[0])
+					if key != "ID" && !slices.Contains(values[key], prop[1]) {
+						values[key] = append(values[key], prop[1])
+					}
+				}
+			}
+			walkSections(section.Children)
+		}
+	}
+	walkSections(doc.Outline.Children)
+
+	return values
+}
+
+// extractTagHeadlines maps each tag to the lines of the headlines carrying
+// it themselves, not by inheritance.
+func extractTagHeadlines(doc *org.Document) map[string][]org.Position {
+	headlines := make(map[string][]org.Position)
+
+	var walkSections func(sections []*org.Section)
+	walkSections = func(sections []*org.Section) {
+		for _, section := range sections {
+			if headline := section.Headline; headline != nil {
+				line := headline.Pos.StartLine
+				for _, tag := range headline.Tags {
+					headlines[tag] = append(headlines[tag], org.Position{StartLine: line, EndLine: line})
+				}
+			}
+			walkSections(section.Children)
+		}
+	}
+	walkSections(doc.Outline.Children)
+
+	return headlines
+}
+
+// Links returns every link in doc, including links nested in other markup
+// such as list items, tables, and link descriptions, in document order.
+func Links(doc *org.Document) []org.RegularLink {
+	var links []org.RegularLink
+
+	var walkNodes func(node org.Node)
+	walkNodes = func(node org.Node) {
+		if link, ok := node.(org.RegularLink); ok {
+			links = append(links, link)
+		}
+		node.Range(func(n org.Node) bool {
+			walkNodes(n)
+			return true
+		})
+	}
+	for _, node := range doc.Nodes {
+		walkNodes(node)
+	}
+
+	return links
+}
+
+// extractRoamRefs collects the org-roam :ROAM_REFS: of the file-level
+// property drawer and of every headline, mapping each ref to its node.
+func extractRoamRefs(doc *org.Document) map[string]UUIDInfo {
+	refs := make(map[string]UUIDInfo)
+
+	// A property drawer before the first headline makes the file a node
+	for _, node := range doc.Nodes {
+		if _, ok := node.(org.Headline); ok {
+			break
+		}
+		if drawer, ok := node.(org.PropertyDrawer); ok {
+			if value, found := drawer.Get("ROAM_REFS"); found {
+				for _, ref := range ParseRoamRefs(value) {
+					refs[ref] = UUIDInfo{Title: extractTitle(doc), Tags: extractFileTags(doc)}
+				}
+			}
+			break
+		}
+	}
+
+	var walkSections func(sections []*org.Section)
+	walkSections = func(sections []*org.Section) {
+		for _, section := range sections {
+			if headline := section.Headline; headline != nil && headline.Properties != nil {
+				if value, found := headline.Properties.Get("ROAM_REFS"); found {
+					for _, ref := range ParseRoamRefs(value) {
+						refs[ref] = UUIDInfo{
+							Position: normalizePosition(headline.Pos),
+							Title:    strings.TrimSpace(org.String(headline.Title...)),
+							Level:    headline.Lvl,
+							Tags:     headline.Tags,
+						}
+					}
+				}
+			}
+			walkSections(section.Children)
+		}
+	}
+	walkSections(doc.Outline.Children)
+
+	if len(refs) > 0 {
+		slog.Debug("Extracted roam refs", "ref_count", len(refs))
+	}
+	return refs
+}
+
+// ParseRoamRefs splits a :ROAM_REFS: value into its refs. Refs are separated
+// by whitespace; a double-quoted ref may contain spaces, and a ref written
+// as an org link ([[url]] or [[url][desc]]) is reduced to its url.
+func ParseRoamRefs(value string) []string {
+	var refs []string
+	for value = strings.TrimSpace(value); value != ""; value = strings.TrimSpace(value) {
+		var ref string
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				ref, value = value[1:], ""
+			} else {
+				ref, value = value[1:end+1], value[end+2:]
+			}
+		} else if end := strings.IndexFunc(value, unicode.IsSpace); end >= 0 {
+			ref, value = value[:end], value[end:]
+		} else {
+			ref, value = value, ""
+		}
+
+		if inner, ok := strings.CutPrefix(ref, "[["); ok {
+			inner = strings.TrimSuffix(inner, "]]")
+			ref, _, _ = strings.Cut(inner, "][")
+		}
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
 // extractPreview extracts a text preview from the document.
 func extractPreview(doc *org.Document, maxLen int) string {
 	var builder strings.Builder
+	// maxLen counts runes, so collect enough bytes for maxLen of the widest
+	limit := maxLen * utf8.UTFMax
 
 	var collectText func(org.Node) bool
 	collectText = func(node org.Node) bool {
-		if builder.Len() >= maxLen {
+		if builder.Len() >= limit {
 			return false
 		}
 		switch n := node.(type) {
@@ -184,7 +499,7 @@ func extractPreview(doc *org.Document, maxLen int) string {
 				}
 			}
 		}
-		return builder.Len() < maxLen
+		return builder.Len() < limit
 	}
 
 	for _, node := range doc.Nodes {
@@ -195,22 +510,23 @@ func extractPreview(doc *org.Document, maxLen int) string {
 
 	text := builder.String()
 
-	re := regexp.MustCompile(`([.!?])([A-Za-z])`)
-	text = re.ReplaceAllString(text, "$1 $2")
+	text = sentenceGapRegexp.ReplaceAllString(text, "$1 $2")
 
 	text = strings.Join(strings.Fields(text), " ")
 
-	if len(text) > maxLen {
-		cutAt := maxLen
-		for cutAt > 0 && text[cutAt-1] > 127 {
-			cutAt--
-		}
-		return text[:cutAt] + "..."
+	// Cut on a rune boundary, so multi-byte characters like emoji are
+	// either kept whole or dropped
+	if runes := []rune(text); len(runes) > maxLen {
+		return string(runes[:maxLen]) + "..."
 	}
 
 	return text
 }
 
+// sentenceGapRegexp matches sentence punctuation run into the next word,
+// as happens when text from separate lines is joined
+var sentenceGapRegexp = regexp.MustCompile(`([.!?])([A-Za-z])`)
+
 // getChildren extracts child nodes from different org node types.
 func getChildren(node org.Node) []org.Node {
 	switch n := node.(type) {
@@ -225,6 +541,19 @@ func getChildren(node org.Node) []org.Node {
 	}
 }
 
+// denoteIDRegexp matches the timestamp identifier that starts a Denote
+// filename, followed by the signature, title, keywords, or extension
+var denoteIDRegexp = regexp.MustCompile(`^(\d{8}T\d{6})(?:==|--|__|\.)`)
+
+// ParseDenoteID returns the Denote identifier (e.g. "20240115T103000") of a
+// filename like "20240115T103000--my-note__tag.org", or "" if it has none.
+func ParseDenoteID(filePath string) string {
+	if m := denoteIDRegexp.FindStringSubmatch(filepath.Base(filePath)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
 // isValidUUID checks if a string is a valid UUID format.
 func isValidUUID(s string) bool {
 	if len(s) != 36 {
@@ -0,0 +1,48 @@
+package orgscanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/go-org/org"
+)
+
+func TestExtractPreviewKeepsRunesWhole(t *testing.T) {
+	preview := func(content string, maxLen int) string {
+		doc := org.New().Parse(strings.NewReader(content), "preview.org")
+		return extractPreview(doc, maxLen)
+	}
+
+	cases := []struct {
+		name, content string
+		maxLen        int
+		expected      string
+	}{
+		{"emoji at the cut point", "Party time 🎉🎉🎉 tonight", 12, "Party time 🎉..."},
+		{"non-Latin text", "日本語のテキストです", 3, "日本語..."},
+		{"text exactly at the limit", "Done ✅", 6, "Done ✅"},
+		{"text under the limit", "Short 🙂", 500, "Short 🙂"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := preview(c.content, c.maxLen)
+			testza.AssertTrue(t, utf8.ValidString(got), "Preview has a broken rune: %q", got)
+			testza.AssertEqual(t, c.expected, got)
+		})
+	}
+}
+
+func TestParseFileCapturesCategoryAndArchive(t *testing.T) {
+	root := t.TempDir()
+	content := "#+category: work\n#+ARCHIVE: done.org::* Finished\n* TODO Ship it\n"
+	testza.AssertNoError(t, os.WriteFile(filepath.Join(root, "tasks.org"), []byte(content), 0o644))
+
+	info, err := ParseFile("tasks.org", root, "")
+	testza.AssertNoError(t, err)
+	testza.AssertEqual(t, "work", info.Category)
+	testza.AssertEqual(t, "done.org::* Finished", info.Archive)
+}
@@ -21,18 +21,23 @@ func (s *OrgScanner) Scan() ([]FileMessage, error) {
 
 // scanUnlocked is the internal scan implementation that assumes lock is held.
 func (s *OrgScanner) scanUnlocked() ([]FileMessage, error) {
-	// Get current files on disk
-	diskFiles, err := scanFilesystem(s.Root)
-	if err != nil {
-		return nil, err
-	}
-
 	var messages []FileMessage
 
-	// Build a lookup set of on-disk files
+	// Get current files on disk across all roots, building a lookup set as we
+	// go. Nested roots would otherwise report the same file twice.
+	var diskFiles []*FileInfo
 	currentFiles := make(map[string]*FileInfo)
-	for _, f := range diskFiles {
-		currentFiles[f.Path] = f
+	for _, root := range s.Roots {
+		files, err := scanFilesystem(root, s.Root)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if _, seen := currentFiles[f.Path]; !seen {
+				currentFiles[f.Path] = f
+				diskFiles = append(diskFiles, f)
+			}
+		}
 	}
 
 	// Build a lookup set for the files we've already processed
@@ -78,8 +83,9 @@ func (s *OrgScanner) scanUnlocked() ([]FileMessage, error) {
 	return messages, nil
 }
 
-// scanFilesystem is the internal implementation that walks the directory tree.
-func scanFilesystem(root string) ([]*FileInfo, error) {
+// scanFilesystem is the internal implementation that walks the directory tree
+// at root. Returned paths are relative to base.
+func scanFilesystem(root, base string) ([]*FileInfo, error) {
 	slog.Debug("Scanning directory for .org files", "root", root)
 	var files []*FileInfo
 
@@ -95,9 +101,9 @@ func scanFilesystem(root string) ([]*FileInfo, error) {
 				return err
 			}
 
-			relPath, err := filepath.Rel(root, path)
+			relPath, err := filepath.Rel(base, path)
 			if err != nil {
-				slog.Error("Error getting relative path", "path", path, "root", root, "error", err)
+				slog.Error("Error getting relative path", "path", path, "base", base, "error", err)
 				return err
 			}
 
@@ -16,6 +16,8 @@ type HeaderLocation struct {
 	Position org.Position
 	Title    string
 	Level    int
+	Tags     []string // Own and inherited tags, including #+FILETAGS
+	Parent   string   // Parent heading's title, or #+TITLE for top-level headings
 }
 
 // UUID represents a globally unique org mode header identifier.
@@ -30,20 +32,32 @@ type UUIDInfo struct {
 	Position org.Position
 	Title    string
 	Level    int
+	Tags     []string // Own and inherited tags, including #+FILETAGS
+	Parent   string   // Parent heading's title, or #+TITLE for top-level headings
 }
 
 // FileUUIDPositions maps UUID strings to their info (position + title) within a file.
 type FileUUIDPositions map[UUID]UUIDInfo
 
-// FileInfo contains extracted metadata and content from a parsed org-mode file.
+// FileInfo contains extracted metadata from a parsed org-mode file. The parsed
+// document itself is not kept; use OrgScanner.Document to get it.
 type FileInfo struct {
-	Path      string
-	ModTime   time.Time
-	Preview   string
-	Title     string
-	Tags      []string
-	UUIDs     FileUUIDPositions
-	ParsedOrg *org.Document
+	Path           string
+	ModTime        time.Time
+	Preview        string
+	Title          string
+	DocTitle       string // #+TITLE alone; empty when Title fell back to a headline
+	Tags           []string
+	UUIDs          FileUUIDPositions
+	DenoteID       string                    // Denote identifier from the filename, if any
+	RoamRefs       map[string]UUIDInfo       // org-roam :ROAM_REFS: entry -> node carrying it
+	PropertyValues map[string][]string       // upper-cased property key -> distinct values in this file
+	TagHeadlines   map[string][]org.Position // tag -> headline lines carrying it in this file
+	Headings       []UUIDInfo                // every headline in the file, with or without an ID
+	Links          []org.RegularLink         // every link in the file, so references are found without re-parsing
+	Startup        string                    // initial visibility from #+STARTUP: overview, content, showall, or ""
+	Category       string                    // #+CATEGORY value, empty if unset
+	Archive        string                    // raw #+ARCHIVE location, e.g. "done.org::* Old"; empty if unset
 }
 
 // Equal compares two FileInfo values based on Path.
@@ -54,9 +68,12 @@ func (f FileInfo) Equal(other FileInfo) bool {
 
 // ProcessedFiles holds the results of scanning and parsing org files.
 type ProcessedFiles struct {
-	Files     sync.Map                   // map[string]*FileInfo - path -> file info pointer
-	UuidIndex sync.Map                   // map[UUID]HeaderLocation
-	TagMap    map[string]map[string]bool // tag -> set of file paths
+	Files          sync.Map                             // map[string]*FileInfo - path -> file info pointer
+	UuidIndex      sync.Map                             // map[UUID]HeaderLocation
+	RoamRefs       sync.Map                             // map[string]HeaderLocation - org-roam ref -> node
+	HeadingIndex   sync.Map                             // map[string][]HeaderLocation - path -> every heading in the file
+	TagMap         map[string]map[string][]org.Position // tag -> file path -> headline lines carrying it there
+	PropertyValues map[string]map[string]int            // upper-cased property key -> value -> number of files using it
 }
 
 // FileAction indicates what action should be taken for a file during scanning.
@@ -79,9 +96,16 @@ type FileMessage struct {
 
 // OrgScanner provides incremental scanning capabilities for org-mode files.
 // It maintains state between scans to avoid re-parsing unchanged files.
+//
+// Every directory in Roots is scanned into the same index. File paths in the
+// index are always relative to Root, so files in other roots are stored as
+// paths like "../notes/todo.org".
 type OrgScanner struct {
 	Root           string
+	Roots          []string
 	ProcessedFiles *ProcessedFiles
 	LastScanTime   time.Time
+	TodoKeywords   string // #+TODO: sequence for files that don't define one; empty means TODO | DONE
 	mu             sync.RWMutex
+	docs           *documentCache
 }
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+)
+
+// agendaDateLayout is the date format of org.agenda range arguments and of
+// the dates it returns
+const agendaDateLayout = "2006-01-02"
+
+// planningKeywords are the planning line keywords that put a heading on the
+// agenda
+var planningKeywords = []string{"SCHEDULED", "DEADLINE"}
+
+// AgendaItem is one heading returned by the org.agenda command
+type AgendaItem struct {
+	Title    string `json:"title"`
+	File     string `json:"file"`     // URI of the file containing the heading
+	Line     int    `json:"line"`     // Line of the headline, 0-based
+	Date     string `json:"date"`     // YYYY-MM-DD
+	Kind     string `json:"kind"`     // SCHEDULED or DEADLINE
+	State    string `json:"state"`    // TODO keyword, empty for plain headings
+	Category string `json:"category"` // #+CATEGORY, or the file name without extension
+}
+
+// agendaCommand returns every indexed heading with a SCHEDULED or DEADLINE
+// date within [start, end], both inclusive and given as YYYY-MM-DD. Items are
+// sorted by date, then file and line.
+func (s *ServerImpl) agendaCommand(ctx context.Context, args []any) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("expected [start, end] arguments, got %d", len(args))
+	}
+	start, err := decodeAgendaDate(args[0], "start")
+	if err != nil {
+		return nil, err
+	}
+	end, err := decodeAgendaDate(args[1], "end")
+	if err != nil {
+		return nil, err
+	}
+
+	s.state.Mu.RLock()
+	items := collectAgendaItems(s.state, start, end)
+	s.state.Mu.RUnlock()
+
+	slog.Debug("Agenda generated", "start", start, "end", end, "itemCount", len(items))
+	return items, nil
+}
+
+// decodeAgendaDate decodes a YYYY-MM-DD range argument, returning it in the
+// same layout so dates can be compared as strings
+func decodeAgendaDate(v any, name string) (string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s argument: %v", name, v)
+	}
+	if _, err := time.Parse(agendaDateLayout, str); err != nil {
+		return "", fmt.Errorf("invalid %s date %q: expected YYYY-MM-DD", name, str)
+	}
+	return str, nil
+}
+
+// collectAgendaItems walks the headings of every indexed file for planning
+// timestamps within [start, end]
+func collectAgendaItems(state *State, start, end string) []AgendaItem {
+	items := []AgendaItem{}
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return items
+	}
+
+	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
+		fileInfo, ok := value.(*orgscanner.FileInfo)
+		if !ok {
+			return true // continue iteration
+		}
+		doc := state.Scanner.Document(fileInfo)
+		if doc == nil {
+			return true // continue iteration
+		}
+
+		fileURI := pathToURI(indexPathToAbs(state, fileInfo.Path))
+		category := fileCategory(fileInfo)
+		for _, headline := range collectHeadlines(doc) {
+			for _, keyword := range planningKeywords {
+				ts := findPlanningTimestamp(headline.Children, keyword)
+				if ts == nil {
+					continue
+				}
+				date := ts.Time.Format(agendaDateLayout)
+				if date < start || date > end {
+					continue
+				}
+				items = append(items, AgendaItem{
+					Title:    strings.TrimSpace(org.String(headline.Title...)),
+					File:     fileURI,
+					Line:     headline.Pos.StartLine,
+					Date:     date,
+					Kind:     keyword,
+					State:    headline.Status,
+					Category: category,
+				})
+			}
+		}
+		return true
+	})
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Date != items[j].Date {
+			return items[i].Date < items[j].Date
+		}
+		if items[i].File != items[j].File {
+			return items[i].File < items[j].File
+		}
+		return items[i].Line < items[j].Line
+	})
+	return items
+}
+
+// fileCategory returns the agenda category of an indexed file: its
+// #+CATEGORY, falling back to the file name without extension like Emacs does
+func fileCategory(fileInfo *orgscanner.FileInfo) string {
+	if fileInfo.Category != "" {
+		return fileInfo.Category
+	}
+	name := filepath.Base(fileInfo.Path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// defaultArchiveLocation is org-archive-location's default: a sibling file
+// named after the source with an _archive suffix, at top level
+const defaultArchiveLocation = "%s_archive::"
+
+// getArchiveAction creates a code action that moves the heading's subtree to
+// the document's archive location
+func getArchiveAction(headline org.Headline, uri protocol.DocumentURI) protocol.CodeAction {
+	title := "Org: Archive subtree"
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Command: &protocol.Command{
+			Title:     title,
+			Command:   CommandArchive,
+			Arguments: []any{string(uri), headline.Pos.StartLine, headline.Pos.StartColumn},
+		},
+	}
+}
+
+// archiveCommand moves the subtree at [uri, line, column] to the location
+// named by the document's #+ARCHIVE: keyword, or to <file>_archive when it
+// has none. The archive file is created if it doesn't exist yet, but only
+// once the move is known to work, and like a tangle target it must be
+// inside the workspace unless tangleOutsideWorkspace is set.
+func (s *ServerImpl) archiveCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, _, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.state.Mu.RLock()
+	doc, ok := s.state.OpenDocs[uri]
+	s.state.Mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+
+	targetPath, heading := archiveTarget(orgscanner.Keyword(doc, "ARCHIVE"), uriToPath(string(uri)))
+	s.state.Mu.RLock()
+	inside := inWorkspace(s.state, targetPath)
+	s.state.Mu.RUnlock()
+	if !inside && !s.state.Config.TangleOutsideWorkspace {
+		return nil, fmt.Errorf("archive file %s is outside the workspace; set tangleOutsideWorkspace to allow it", targetPath)
+	}
+	targetURI := protocol.DocumentURI(pathToURI(targetPath))
+
+	s.state.Mu.RLock()
+	create, err := archiveFileMissing(s.state, targetURI)
+	var edit *protocol.WorkspaceEdit
+	if err == nil && create {
+		// Build the edit against the file as it will be created, so nothing
+		// is written to disk unless the subtree can actually be moved
+		seed := archiveSeed(heading)
+		edit, err = refileEditTo(s.state, uri, line, targetURI, parseOrgDocument(seed, targetPath, s.state.Config), seed, heading)
+	} else if err == nil {
+		edit, err = refileEdit(s.state, uri, line, targetURI, heading)
+	}
+	s.state.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if create {
+		if err := createArchiveFile(targetPath, archiveSeed(heading)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyWorkspaceEdit(ctx, "Org: Archive subtree", *edit); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Archived subtree", "from", uri, "line", line, "to", targetURI, "heading", heading)
+	return edit, nil
+}
+
+// archiveTarget resolves an org-archive-location style "file::heading"
+// location for the document at sourcePath. In the file part %s stands for
+// the source file name, a relative path is taken from the source directory,
+// and an empty one means the source itself. The heading is returned without
+// its stars; empty means top level.
+func archiveTarget(location, sourcePath string) (string, string) {
+	if location == "" {
+		location = defaultArchiveLocation
+	}
+	file, heading, _ := strings.Cut(location, "::")
+
+	file = strings.TrimSpace(strings.ReplaceAll(file, "%s", filepath.Base(sourcePath)))
+	switch {
+	case file == "":
+		file = sourcePath
+	case !filepath.IsAbs(file):
+		file = filepath.Join(filepath.Dir(sourcePath), file)
+	}
+
+	heading = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(heading), "*"))
+	return file, heading
+}
+
+// archiveFileMissing reports whether the archive file at uri is neither open
+// nor present on disk, and so has to be created
+func archiveFileMissing(state *State, uri protocol.DocumentURI) (bool, error) {
+	if _, open := state.OpenDocs[uri]; open {
+		return false, nil
+	}
+	if _, err := os.Stat(uriToPath(string(uri))); err == nil || !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// archiveSeed is the content a new archive file starts with: the archive
+// heading, if there is one
+func archiveSeed(heading string) string {
+	if heading == "" {
+		return ""
+	}
+	return "* " + heading + "\n"
+}
+
+// createArchiveFile writes a new archive file, creating its directory
+func createArchiveFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	slog.Info("Created archive file", "path", path)
+	return nil
+}
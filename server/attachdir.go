@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// openAttachmentDirCommand returns the attachment directory of the heading at
+// [uri, line, column], from its :DIR: or :ID: the way org-attach lays it out,
+// so the client can open it in a file manager. With [uri, line, column, true]
+// the directory is created when it doesn't exist yet.
+func (s *ServerImpl) openAttachmentDirCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, column, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	create := false
+	if len(args) > 3 {
+		var ok bool
+		if create, ok = args[3].(bool); !ok {
+			return nil, fmt.Errorf("invalid create argument: %v", args[3])
+		}
+	}
+
+	s.state.Mu.RLock()
+	dir, err := attachmentDirAt(s.state, uri, line, column)
+	s.state.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if create {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create attachment directory: %w", err)
+		}
+		slog.Info("Ensured attachment directory", "uri", uri, "dir", dir)
+	}
+
+	return dir, nil
+}
+
+// attachmentDirAt computes the attachment directory of the heading at line
+// and column of the open document uri
+func attachmentDirAt(state *State, uri protocol.DocumentURI, line, column int) (string, error) {
+	doc, ok := state.OpenDocs[uri]
+	if !ok {
+		return "", fmt.Errorf("document not open: %s", uri)
+	}
+	headline, found := findNodeAtPosition[org.Headline](doc, protocol.Position{Line: uint32(line), Character: uint32(column)})
+	if !found {
+		return "", fmt.Errorf("no heading found at line %d", line)
+	}
+	return attachmentDir(*headline, uriToPath(string(uri)))
+}
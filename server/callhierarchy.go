@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// The call hierarchy is reinterpreted over the id-link graph: a heading's
+// incoming calls are the headings linking to its ID, and its outgoing calls
+// are the headings its subtree links to.
+
+func (s *ServerImpl) PrepareCallHierarchy(ctx context.Context, params *protocol.CallHierarchyPrepareParams) (result []protocol.CallHierarchyItem, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	uri := params.TextDocument.URI
+	doc, found := s.state.OpenDocs[uri]
+	if !found {
+		return nil, nil
+	}
+
+	headline, found := findNodeAtPosition[org.Headline](doc, params.Position)
+	if !found {
+		return nil, nil
+	}
+
+	lines := strings.Split(s.state.RawContent[uri], "\n")
+	return []protocol.CallHierarchyItem{headlineCallHierarchyItem(uri, *headline, lines)}, nil
+}
+
+func (s *ServerImpl) IncomingCalls(ctx context.Context, params *protocol.CallHierarchyIncomingCallsParams) (result []protocol.CallHierarchyIncomingCall, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	headline, _, found := callHierarchyHeadline(s.state, params.Item)
+	if !found {
+		return nil, nil
+	}
+	uuid := getPropertyValue(headline, "ID")
+	if uuid == "" {
+		return []protocol.CallHierarchyIncomingCall{}, nil
+	}
+
+	locations, err := findIDReferences(s.state, uuid, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the links by the heading (or file) that contains them
+	calls := []protocol.CallHierarchyIncomingCall{}
+	callIndex := make(map[string]int)
+	for _, loc := range locations {
+		doc, content, err := documentForURI(s.state, loc.URI)
+		if err != nil {
+			slog.Debug("Skipping unreadable referencing file", "uri", loc.URI, "error", err)
+			continue
+		}
+		lines := strings.Split(content, "\n")
+
+		var from protocol.CallHierarchyItem
+		if container, found := findNodeAtPosition[org.Headline](doc, loc.Range.Start); found {
+			from = headlineCallHierarchyItem(loc.URI, *container, lines)
+		} else {
+			from = fileCallHierarchyItem(loc.URI, lines)
+		}
+
+		key := fmt.Sprintf("%s:%d", from.URI, from.Range.Start.Line)
+		if i, ok := callIndex[key]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, loc.Range)
+			continue
+		}
+		callIndex[key] = len(calls)
+		calls = append(calls, protocol.CallHierarchyIncomingCall{From: from, FromRanges: []protocol.Range{loc.Range}})
+	}
+
+	slog.Debug("Incoming calls resolved", "uuid", uuid, "callers", len(calls))
+	return calls, nil
+}
+
+func (s *ServerImpl) OutgoingCalls(ctx context.Context, params *protocol.CallHierarchyOutgoingCallsParams) (result []protocol.CallHierarchyOutgoingCall, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	headline, _, found := callHierarchyHeadline(s.state, params.Item)
+	if !found || s.state.Scanner == nil || s.state.Scanner.ProcessedFiles == nil {
+		return nil, nil
+	}
+
+	// Collect the id links in the subtree, grouped by target
+	calls := []protocol.CallHierarchyOutgoingCall{}
+	callIndex := make(map[string]int)
+	var walkNodes func(node org.Node)
+	walkNodes = func(node org.Node) {
+		if link, ok := node.(org.RegularLink); ok {
+			if uuid, isID := strings.CutPrefix(link.URL, "id:"); isID {
+				if i, seen := callIndex[uuid]; seen {
+					calls[i].FromRanges = append(calls[i].FromRanges, toProtocolRange(link.Pos))
+				} else if to, resolved := uuidCallHierarchyItem(s.state, uuid); resolved {
+					callIndex[uuid] = len(calls)
+					calls = append(calls, protocol.CallHierarchyOutgoingCall{To: to, FromRanges: []protocol.Range{toProtocolRange(link.Pos)}})
+				}
+			}
+		}
+		node.Range(func(n org.Node) bool {
+			walkNodes(n)
+			return true
+		})
+	}
+	walkNodes(headline)
+
+	slog.Debug("Outgoing calls resolved", "uri", params.Item.URI, "callees", len(calls))
+	return calls, nil
+}
+
+// callHierarchyHeadline re-finds the headline an item was prepared from
+func callHierarchyHeadline(state *State, item protocol.CallHierarchyItem) (org.Headline, []string, bool) {
+	doc, content, err := documentForURI(state, item.URI)
+	if err != nil {
+		return org.Headline{}, nil, false
+	}
+	headline, found := findNodeAtPosition[org.Headline](doc, item.SelectionRange.Start)
+	if !found {
+		return org.Headline{}, nil, false
+	}
+	return *headline, strings.Split(content, "\n"), true
+}
+
+// headlineCallHierarchyItem builds the item for a headline, spanning its
+// subtree with the headline line selected
+func headlineCallHierarchyItem(uri protocol.DocumentURI, headline org.Headline, lines []string) protocol.CallHierarchyItem {
+	line := headline.Pos.StartLine
+	lineLen := 0
+	if line < len(lines) {
+		lineLen = len(lines[line])
+	}
+
+	return protocol.CallHierarchyItem{
+		Name:   strings.TrimSpace(renderNodesToString(headline.Title)),
+		Kind:   levelToSymbolKind(headline.Lvl, "", nil),
+		Detail: filepath.Base(uriToPath(string(uri))),
+		URI:    uri,
+		Range:  toProtocolRange(headline.Pos),
+		SelectionRange: protocol.Range{
+			Start: protocol.Position{Line: uint32(line), Character: 0},
+			End:   protocol.Position{Line: uint32(line), Character: uint32(lineLen)},
+		},
+		Data: getPropertyValue(headline, "ID"),
+	}
+}
+
+// fileCallHierarchyItem builds the item for links that sit before the
+// first heading of a file
+func fileCallHierarchyItem(uri protocol.DocumentURI, lines []string) protocol.CallHierarchyItem {
+	last := len(lines) - 1
+	fileRange := protocol.Range{
+		End: protocol.Position{Line: uint32(last), Character: uint32(len(lines[last]))},
+	}
+	return protocol.CallHierarchyItem{
+		Name:           filepath.Base(uriToPath(string(uri))),
+		Kind:           protocol.SymbolKindFile,
+		URI:            uri,
+		Range:          fileRange,
+		SelectionRange: protocol.Range{},
+	}
+}
+
+// uuidCallHierarchyItem builds the item for the heading with the given ID
+// from the index
+func uuidCallHierarchyItem(state *State, uuid string) (protocol.CallHierarchyItem, bool) {
+	value, found := state.Scanner.ProcessedFiles.UuidIndex.Load(orgscanner.UUID(uuid))
+	if !found {
+		return protocol.CallHierarchyItem{}, false
+	}
+	location, ok := value.(orgscanner.HeaderLocation)
+	if !ok {
+		return protocol.CallHierarchyItem{}, false
+	}
+
+	uri := protocol.DocumentURI(pathToURI(indexPathToAbs(state, location.FilePath)))
+	if doc, content, err := documentForURI(state, uri); err == nil {
+		if headline, found := findNodeAtPosition[org.Headline](doc, protocol.Position{Line: uint32(location.Position.StartLine)}); found {
+			return headlineCallHierarchyItem(uri, *headline, strings.Split(content, "\n")), true
+		}
+	}
+
+	headerRange := toProtocolRange(location.Position)
+	return protocol.CallHierarchyItem{
+		Name:           location.Title,
+		Kind:           levelToSymbolKind(location.Level, "", nil),
+		Detail:         filepath.Base(location.FilePath),
+		URI:            uri,
+		Range:          headerRange,
+		SelectionRange: headerRange,
+		Data:           uuid,
+	}, true
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// DanglingLink is one unresolved link returned by the org.checkLinks command
+type DanglingLink struct {
+	SourceFile string `json:"sourceFile"` // URI of the file containing the link
+	Line       int    `json:"line"`       // Line of the link, 0-based
+	LinkText   string `json:"linkText"`   // The link as written, e.g. [[id:...][desc]]
+	Reason     string `json:"reason"`
+}
+
+// checkLinksCommand reports every id and file link across the indexed files
+// that doesn't resolve, using the same checks as diagnostics. Links are
+// sorted by file, then line.
+func (s *ServerImpl) checkLinksCommand(ctx context.Context, args []any) (any, error) {
+	s.state.Mu.RLock()
+	links := collectDanglingLinks(s.state)
+	s.state.Mu.RUnlock()
+
+	slog.Debug("Link check complete", "danglingCount", len(links))
+	return links, nil
+}
+
+// collectDanglingLinks validates the links of every indexed file
+func collectDanglingLinks(state *State) []DanglingLink {
+	links := []DanglingLink{}
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return links
+	}
+
+	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
+		fileInfo, ok := value.(*orgscanner.FileInfo)
+		if !ok {
+			return true // continue iteration
+		}
+		fileURI := pathToURI(indexPathToAbs(state, fileInfo.Path))
+		for _, link := range fileInfo.Links {
+			if d := validateLink(state, protocol.DocumentURI(fileURI), link); d != nil {
+				links = append(links, DanglingLink{
+					SourceFile: fileURI,
+					Line:       link.Pos.StartLine,
+					LinkText:   org.String(link),
+					Reason:     d.Message,
+				})
+			}
+		}
+		return true
+	})
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].SourceFile != links[j].SourceFile {
+			return links[i].SourceFile < links[j].SourceFile
+		}
+		return links[i].Line < links[j].Line
+	})
+	return links
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// bibEntry is a single BibTeX entry, reduced to the fields shown to users
+type bibEntry struct {
+	Key    string
+	Type   string // article, book, ...
+	Author string
+	Title  string
+	Year   string
+}
+
+// citationPrefixRegexp matches an unfinished citation key before the cursor,
+// e.g. "[cite:@kn" or "[cite/t:@foo; @ba", capturing the partial key
+var citationPrefixRegexp = regexp.MustCompile(`\[cite(?:/[\w/-]+)?:[^\]]*@([^\s;\]@]*)$`)
+
+// citationRegexp matches a complete [cite:...] or [cite/style:...] citation
+var citationRegexp = regexp.MustCompile(`\[cite(?:/[\w/-]+)?:[^\]]*\]`)
+
+// citationKeyRegexp matches an @key inside a citation
+var citationKeyRegexp = regexp.MustCompile(`@([^\s;\]@]+)`)
+
+// bibliographyKeywordRegexp matches a #+BIBLIOGRAPHY: keyword line
+var bibliographyKeywordRegexp = regexp.MustCompile(`(?i)^\s*#\+bibliography:\s*(.+?)\s*$`)
+
+// detectCitationContext checks if cursor is on a citation key (after "[cite:@")
+func detectCitationContext(state *State, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+
+	match := citationPrefixRegexp.FindStringSubmatch(line[:pos.Character])
+	if match == nil {
+		return ctx
+	}
+
+	ctx.Type = ContextTypeCitation
+	ctx.FilterPrefix = strings.ToLower(match[1])
+	return ctx
+}
+
+// findCitationKeyAtPosition returns the citation key under col on line,
+// with the byte columns of the @key
+func findCitationKeyAtPosition(line string, col int) (key string, start, end int, found bool) {
+	for _, citation := range citationRegexp.FindAllStringIndex(line, -1) {
+		if col < citation[0] || col > citation[1] {
+			continue
+		}
+		body := line[citation[0]:citation[1]]
+		for _, match := range citationKeyRegexp.FindAllStringSubmatchIndex(body, -1) {
+			start, end = citation[0]+match[0], citation[0]+match[1]
+			if col >= start && col <= end {
+				return body[match[2]:match[3]], start, end, true
+			}
+		}
+	}
+	return "", 0, 0, false
+}
+
+// citationHover builds the hover for the citation key at pos, if any
+func citationHover(state *State, uri protocol.DocumentURI, pos protocol.Position) (*protocol.Hover, bool) {
+	lines := strings.Split(state.RawContent[uri], "\n")
+	if int(pos.Line) >= len(lines) {
+		return nil, false
+	}
+
+	key, start, end, found := findCitationKeyAtPosition(lines[pos.Line], int(pos.Character))
+	if !found {
+		return nil, false
+	}
+
+	content := fmt.Sprintf("**%s**\n\nUnresolved citation: not found in any bibliography", key)
+	for _, entry := range loadBibliography(state, uri) {
+		if entry.Key == key {
+			content = bibEntryMarkdown(entry)
+			break
+		}
+	}
+
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  "markdown",
+			Value: content,
+		},
+		Range: &protocol.Range{
+			Start: protocol.Position{Line: pos.Line, Character: uint32(start)},
+			End:   protocol.Position{Line: pos.Line, Character: uint32(end)},
+		},
+	}, true
+}
+
+// completeCitations returns completion items for bibliography keys
+func completeCitations(state *State, uri protocol.DocumentURI, ctx CompletionContext) []protocol.CompletionItem {
+	var items []protocol.CompletionItem
+
+	for _, entry := range loadBibliography(state, uri) {
+		if ctx.FilterPrefix != "" && !strings.Contains(strings.ToLower(entry.Key), ctx.FilterPrefix) {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:      entry.Key,
+			Kind:       protocol.CompletionItemKindReference,
+			Detail:     bibEntryDetail(entry),
+			InsertText: entry.Key,
+			Documentation: protocol.MarkupContent{
+				Kind:  "markdown",
+				Value: bibEntryMarkdown(entry),
+			},
+		})
+	}
+
+	slog.Debug("Citation completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
+
+// bibEntryDetail renders a one-line "Author (Year)" summary of an entry
+func bibEntryDetail(entry bibEntry) string {
+	detail := entry.Author
+	if detail == "" {
+		detail = entry.Title
+	}
+	if entry.Year != "" {
+		detail += " (" + entry.Year + ")"
+	}
+	return strings.TrimSpace(detail)
+}
+
+// bibEntryMarkdown renders an entry as markdown for completion docs and hover
+func bibEntryMarkdown(entry bibEntry) string {
+	var builder strings.Builder
+	builder.WriteString("**" + entry.Key + "**")
+	if entry.Type != "" {
+		builder.WriteString(" (" + entry.Type + ")")
+	}
+	if entry.Title != "" {
+		builder.WriteString("\n\n*" + entry.Title + "*")
+	}
+	if entry.Author != "" {
+		builder.WriteString("\n\n" + entry.Author)
+	}
+	if entry.Year != "" {
+		builder.WriteString(", " + entry.Year)
+	}
+	return builder.String()
+}
+
+// bibliographyFiles returns the .bib files that apply to a document: those
+// named by its #+BIBLIOGRAPHY: keywords (relative to the document) followed
+// by the configured bibliographyFiles (relative to the workspace root).
+func bibliographyFiles(state *State, uri protocol.DocumentURI) []string {
+	var files []string
+
+	docDir := filepath.Dir(uriToPath(string(uri)))
+	for _, line := range strings.Split(state.RawContent[uri], "\n") {
+		if match := bibliographyKeywordRegexp.FindStringSubmatch(line); match != nil {
+			files = append(files, resolveBibPath(match[1], docDir))
+		}
+	}
+
+	for _, path := range state.Config.BibliographyFiles {
+		files = append(files, resolveBibPath(path, state.OrgScanRoot))
+	}
+
+	return files
+}
+
+// resolveBibPath expands ~ and makes path absolute relative to baseDir
+func resolveBibPath(path, baseDir string) string {
+	path = strings.Trim(path, `"`)
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// loadBibliography reads and parses every bibliography file for a document.
+// Files that can't be read are logged and skipped.
+func loadBibliography(state *State, uri protocol.DocumentURI) []bibEntry {
+	var entries []bibEntry
+	for _, path := range bibliographyFiles(state, uri) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Debug("Failed to read bibliography", "path", path, "error", err)
+			continue
+		}
+		entries = append(entries, parseBibEntries(string(data))...)
+	}
+	return entries
+}
+
+// parseBibEntries scans BibTeX source for entries. It only understands as
+// much BibTeX as completion needs: the entry type, key, and the author,
+// title, and year fields, with {braced} or "quoted" values.
+func parseBibEntries(data string) []bibEntry {
+	var entries []bibEntry
+
+	for offset := 0; offset < len(data); {
+		at := strings.IndexByte(data[offset:], '@')
+		if at == -1 {
+			break
+		}
+		start := offset + at + 1
+
+		open := strings.IndexAny(data[start:], "{(")
+		if open == -1 {
+			break
+		}
+		entryType := strings.ToLower(strings.TrimSpace(data[start : start+open]))
+		bodyStart := start + open + 1
+		bodyEnd := matchingBrace(data, bodyStart)
+		offset = bodyEnd
+
+		if entryType == "comment" || entryType == "string" || entryType == "preamble" || strings.ContainsAny(entryType, " \t\n") {
+			continue
+		}
+
+		body := data[bodyStart:bodyEnd]
+		comma := strings.IndexByte(body, ',')
+		if comma == -1 {
+			continue
+		}
+
+		entry := bibEntry{Key: strings.TrimSpace(body[:comma]), Type: entryType}
+		if entry.Key == "" {
+			continue
+		}
+
+		fields := parseBibFields(body[comma+1:])
+		entry.Author = fields["author"]
+		entry.Title = fields["title"]
+		entry.Year = fields["year"]
+		if entry.Year == "" && len(fields["date"]) >= 4 {
+			entry.Year = fields["date"][:4]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// matchingBrace returns the index of the brace closing the group that starts
+// at start, or len(data) if it is unterminated
+func matchingBrace(data string, start int) int {
+	depth := 1
+	for i := start; i < len(data); i++ {
+		switch data[i] {
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(data)
+}
+
+// parseBibFields parses "name = value" pairs from the body of an entry
+func parseBibFields(body string) map[string]string {
+	fields := make(map[string]string)
+
+	for i := 0; i < len(body); {
+		eq := strings.IndexByte(body[i:], '=')
+		if eq == -1 {
+			break
+		}
+		name := strings.ToLower(strings.Trim(strings.TrimSpace(body[i:i+eq]), ","))
+		name = strings.TrimSpace(name[strings.LastIndexAny(name, ", \t\n")+1:])
+		i += eq + 1
+
+		for i < len(body) && strings.ContainsRune(" \t\r\n", rune(body[i])) {
+			i++
+		}
+		if i >= len(body) {
+			break
+		}
+
+		var value string
+		switch body[i] {
+		case '{':
+			end := matchingBrace(body, i+1)
+			value = body[i+1 : end]
+			i = end + 1
+		case '"':
+			end := strings.IndexByte(body[i+1:], '"')
+			if end == -1 {
+				end = len(body) - i - 1
+			}
+			value = body[i+1 : i+1+end]
+			i += end + 2
+		default:
+			end := strings.IndexByte(body[i:], ',')
+			if end == -1 {
+				end = len(body) - i
+			}
+			value = body[i : i+end]
+			i += end
+		}
+
+		fields[name] = cleanBibValue(value)
+	}
+
+	return fields
+}
+
+// cleanBibValue strips nested braces and collapses whitespace in a value
+func cleanBibValue(value string) string {
+	value = strings.NewReplacer("{", "", "}", "").Replace(value)
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -2,11 +2,16 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alexispurslane/go-org/org"
 	protocol "go.lsp.dev/protocol"
@@ -60,16 +65,58 @@ func (s *ServerImpl) CodeAction(ctx context.Context, params *protocol.CodeAction
 	}
 	if list, found := findNodeAtPosition[org.List](doc, cursorPos); found {
 		actions = append(actions, getListConversionAction(*list, doc, uri, params.Range))
+		actions = append(actions, getSortListActions(*list, uri, s.state.RawContent[uri])...)
 	}
 
-	// Check for code block evaluation (single block at cursor only)
+	// Check for code block evaluation (single block at cursor only, and only
+	// for languages the user has opted in to executing)
 	if block, found := findNodeAtPosition[org.Block](doc, cursorPos); found && strings.EqualFold(block.Name, "src") {
-		actions = append(actions, getCodeBlockAction(*block, uri))
+		if lang := blockLanguage(*block); lang != "unknown" && s.state.Config.AllowsCodeLanguage(lang) {
+			actions = append(actions, getCodeBlockAction(*block, uri))
+		}
+	}
+
+	// Check for a bare URL under the cursor to wrap in a bracket link
+	if action, found := getBareURLAction(s.state.RawContent[uri], uri, params.Range); found {
+		actions = append(actions, action)
+	}
+
+	// Check for a checkbox list item to toggle
+	if action, found := getCheckboxToggleAction(s.state.RawContent[uri], uri, cursorPos); found {
+		actions = append(actions, action)
+	}
+
+	// Check for a timestamp to toggle between active and inactive
+	if action, found := getTimestampToggleAction(s.state.RawContent[uri], uri, cursorPos); found {
+		actions = append(actions, action)
+	}
+
+	// Check for a table of contents marker to fill in
+	if action, found := getTOCAction(s.state.RawContent[uri], uri, doc, cursorPos); found {
+		actions = append(actions, action)
 	}
 
 	// Check for snippet-based code actions on headlines
 	if headline, found := findNodeAtPosition[org.Headline](doc, cursorPos); found {
-		actions = append(actions, getSnippetCodeActions(*headline, uri, doc, cursorPos, params.Range)...)
+		actions = append(actions, getRefileAction(*headline, uri))
+		actions = append(actions, getArchiveAction(*headline, uri))
+		if action, found := getSplitHeadingAction(*headline, s.state.RawContent[uri], uri, cursorPos); found {
+			actions = append(actions, action)
+		}
+		if action, found := getMergeHeadingAction(*headline, s.state.RawContent[uri], uri, cursorPos); found {
+			actions = append(actions, action)
+		}
+		actions = append(actions, getSnippetCodeActions(*headline, uri, doc, s.state.RawContent[uri], cursorPos, params.Range)...)
+
+		// Mark the ID quick fix as the fix for this heading's missing-ID hint
+		if fixed := missingIDDiagnostics(params.Context.Diagnostics, headline.Pos.StartLine); len(fixed) > 0 {
+			for i := range actions {
+				if actions[i].Title == addIDActionTitle {
+					actions[i].Diagnostics = fixed
+					actions[i].IsPreferred = true
+				}
+			}
+		}
 	}
 
 	// Check for selected text to wrap in link
@@ -104,9 +151,75 @@ func (s *ServerImpl) CodeAction(ctx context.Context, params *protocol.CodeAction
 		}
 	}
 
+	// Check for selected lines to wrap in a block
+	if hasSelection(params.Range) {
+		actions = append(actions, getWrapInBlockActions(s.state.RawContent[uri], uri, params.Range)...)
+	}
+
+	for i := range actions {
+		clientEdit(s.state, actions[i].Edit)
+	}
 	return actions, nil
 }
 
+// wrapBlockTypes are the blocks offered by the wrap selection in block actions
+var wrapBlockTypes = []string{"src", "quote", "example"}
+
+// getWrapInBlockActions returns actions wrapping the lines covered by r in a
+// #+begin_X/#+end_X block. Whole lines are wrapped, and the block keywords
+// take the indentation of the first selected line. For src blocks the
+// language is left as a snippet placeholder defaulting to "text".
+func getWrapInBlockActions(content string, uri protocol.DocumentURI, r protocol.Range) []protocol.CodeAction {
+	lines := strings.Split(content, "\n")
+	startLine := int(r.Start.Line)
+	endLine := int(r.End.Line)
+	// A selection ending at the start of a line doesn't include that line
+	if endLine > startLine && r.End.Character == 0 {
+		endLine--
+	}
+	if startLine >= len(lines) || endLine >= len(lines) {
+		return nil
+	}
+
+	first := lines[startLine]
+	indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	selected := strings.Join(lines[startLine:endLine+1], "\n")
+
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine), Character: 0},
+		End:   protocol.Position{Line: uint32(endLine), Character: uint32(len(lines[endLine]))},
+	}
+
+	var actions []protocol.CodeAction
+	for _, blockType := range wrapBlockTypes {
+		title := fmt.Sprintf("Org: Wrap selection in #+begin_%s", blockType)
+		if blockType == "src" {
+			snippet := indent + "#+begin_src ${1:text}\n" + escapeSnippetText(selected) + "\n" + indent + "#+end_src$0"
+			actions = append(actions, createSnippetAction(title, protocol.RefactorRewrite, uri, editRange, snippet))
+			continue
+		}
+
+		actions = append(actions, protocol.CodeAction{
+			Title: title,
+			Kind:  protocol.RefactorRewrite,
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+					uri: {{
+						Range:   editRange,
+						NewText: indent + "#+begin_" + blockType + "\n" + selected + "\n" + indent + "#+end_" + blockType,
+					}},
+				},
+			},
+		})
+	}
+	return actions
+}
+
+// escapeSnippetText escapes characters with special meaning in LSP snippets
+func escapeSnippetText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`).Replace(text)
+}
+
 // hasSelection returns true if the range represents a selection (not just cursor position)
 func hasSelection(r protocol.Range) bool {
 	return r.Start.Line != r.End.Line || r.Start.Character != r.End.Character
@@ -162,6 +275,72 @@ func getSelectedText(doc *org.Document, r protocol.Range) string {
 	return selected.String()
 }
 
+// bareURLRegexp matches URLs written directly in text
+var bareURLRegexp = regexp.MustCompile(`(?:https?|ftp)://[^\s\[\]<>"]+`)
+
+// findBareURL returns the byte columns of the bare URL on line covering col.
+// URLs already inside [[...]] links are skipped, as is trailing punctuation
+// that is almost always part of the surrounding sentence.
+func findBareURL(line string, col int) (start, end int, found bool) {
+	for _, match := range bareURLRegexp.FindAllStringIndex(line, -1) {
+		start, end = match[0], match[1]
+		for end > start && strings.ContainsRune(".,;:!?)'", rune(line[end-1])) {
+			end--
+		}
+		if col < start || col > end {
+			continue
+		}
+		if strings.HasSuffix(line[:start], "[[") || strings.HasSuffix(line[:start], "][") {
+			return 0, 0, false
+		}
+		return start, end, true
+	}
+	return 0, 0, false
+}
+
+// getBareURLAction offers to wrap the bare URL at the start of r in a
+// bracket link. If r extends past the URL on the same line, the following
+// text becomes the link description. go-org doesn't model bare URLs as
+// links, so detection works on the raw line.
+func getBareURLAction(content string, uri protocol.DocumentURI, r protocol.Range) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	if int(r.Start.Line) >= len(lines) {
+		return protocol.CodeAction{}, false
+	}
+	line := lines[r.Start.Line]
+
+	start, end, found := findBareURL(line, int(r.Start.Character))
+	if !found {
+		return protocol.CodeAction{}, false
+	}
+	url := line[start:end]
+
+	replaceEnd := end
+	newText := "[[" + url + "]]"
+	if r.End.Line == r.Start.Line && int(r.End.Character) > end && int(r.End.Character) <= len(line) {
+		if description := strings.TrimSpace(line[end:r.End.Character]); description != "" {
+			replaceEnd = int(r.End.Character)
+			newText = "[[" + url + "][" + description + "]]"
+		}
+	}
+
+	return protocol.CodeAction{
+		Title: "Org: Wrap in org link",
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: r.Start.Line, Character: uint32(start)},
+						End:   protocol.Position{Line: r.Start.Line, Character: uint32(replaceEnd)},
+					},
+					NewText: newText,
+				}},
+			},
+		},
+	}, true
+}
+
 // getHeadingConversionActions returns actions to convert headings to lists.
 func getHeadingConversionActions(nodes []org.Node, uri protocol.DocumentURI) []protocol.CodeAction {
 	kindRefactor := protocol.RefactorRewrite
@@ -283,6 +462,134 @@ func getListConversionAction(list org.List, doc *org.Document, uri protocol.Docu
 	}
 }
 
+// getSortListActions returns actions that reorder the items of list:
+// alphabetically, and for checkbox lists also with checked items last.
+// Items move together with their nested sublists.
+func getSortListActions(list org.List, uri protocol.DocumentURI, content string) []protocol.CodeAction {
+	if len(list.Items) < 2 {
+		return nil
+	}
+
+	alphabetical := slices.Clone(list.Items)
+	slices.SortStableFunc(alphabetical, func(a, b org.Node) int {
+		return strings.Compare(listItemSortKey(a), listItemSortKey(b))
+	})
+	actions := []protocol.CodeAction{
+		sortListAction("Org: Sort list items alphabetically", list, alphabetical, uri, content),
+	}
+
+	hasCheckboxes := slices.ContainsFunc(list.Items, func(n org.Node) bool {
+		item, ok := n.(org.ListItem)
+		return ok && item.Status != ""
+	})
+	if hasCheckboxes {
+		checkedLast := slices.Clone(list.Items)
+		slices.SortStableFunc(checkedLast, func(a, b org.Node) int {
+			return listItemCheckedRank(a) - listItemCheckedRank(b)
+		})
+		actions = append(actions, sortListAction("Org: Sort list items with checked last", list, checkedLast, uri, content))
+	}
+
+	return actions
+}
+
+// sortListAction builds the edit replacing list with its items in the given
+// order. go-org renders list items without their surrounding indentation, so the
+// original indentation of the first line is reapplied for nested lists.
+func sortListAction(title string, list org.List, items []org.Node, uri protocol.DocumentURI, content string) protocol.CodeAction {
+	lines := strings.Split(content, "\n")
+	startLine := list.Pos.StartLine
+	endLine := min(list.Pos.EndLine, len(lines)-1)
+	// go-org may count trailing blank lines as part of the list
+	for endLine > startLine && strings.TrimSpace(lines[endLine]) == "" {
+		endLine--
+	}
+
+	if list.Kind == org.OrderedList {
+		items = renumberListItems(items)
+	}
+	// Render items one at a time so blank lines trailing the original last
+	// item don't travel with it
+	renderedItems := make([]string, len(items))
+	for i, item := range items {
+		renderedItems[i] = strings.TrimRight(org.String(item), "\n")
+	}
+	rendered := strings.Join(renderedItems, "\n")
+
+	first := lines[startLine]
+	indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	if indent != "" {
+		renderedLines := strings.Split(rendered, "\n")
+		for i, line := range renderedLines {
+			if line != "" {
+				renderedLines[i] = indent + line
+			}
+		}
+		rendered = strings.Join(renderedLines, "\n")
+	}
+
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(startLine), Character: 0},
+						End:   protocol.Position{Line: uint32(endLine), Character: uint32(len(lines[endLine]))},
+					},
+					NewText: rendered,
+				}},
+			},
+		},
+	}
+}
+
+// listItemSortKey returns the case-insensitive text an item sorts by
+func listItemSortKey(node org.Node) string {
+	switch item := node.(type) {
+	case org.ListItem:
+		return strings.ToLower(strings.TrimSpace(org.String(item.Children...)))
+	case org.DescriptiveListItem:
+		return strings.ToLower(strings.TrimSpace(org.String(item.Term...)))
+	default:
+		return strings.ToLower(org.String(node))
+	}
+}
+
+// listItemCheckedRank orders unchecked and partial items before checked ones
+func listItemCheckedRank(node org.Node) int {
+	if item, ok := node.(org.ListItem); ok && item.Status == "X" {
+		return 1
+	}
+	return 0
+}
+
+// renumberListItems rewrites numeric ordered-list bullets to count up from
+// the lowest number in the list, keeping each bullet's "." or ")" delimiter
+func renumberListItems(items []org.Node) []org.Node {
+	start := -1
+	for _, node := range items {
+		if item, ok := node.(org.ListItem); ok {
+			if n, err := strconv.Atoi(strings.TrimRight(item.Bullet, ".)")); err == nil && (start == -1 || n < start) {
+				start = n
+			}
+		}
+	}
+	if start == -1 {
+		return items
+	}
+
+	renumbered := slices.Clone(items)
+	for i, node := range renumbered {
+		if item, ok := node.(org.ListItem); ok && len(item.Bullet) >= 2 {
+			item.Bullet = strconv.Itoa(start+i) + item.Bullet[len(item.Bullet)-1:]
+			renumbered[i] = item
+		}
+	}
+	return renumbered
+}
+
 // listConfig holds the variations between ordered and unordered list conversion.
 type listConfig struct {
 	Kind   org.ListKind
@@ -501,13 +808,20 @@ func listToHeadingSubtree(list org.List, startLevel int) []org.Node {
 	return headings
 }
 
+// blockLanguage returns the language of a src block. go-org keeps "src" in
+// Name and everything after it in Parameters, so the language is the first
+// parameter and any switches (-n, :results) follow it. Blocks without a
+// language report "unknown".
+func blockLanguage(block org.Block) string {
+	if len(block.Parameters) == 0 || strings.HasPrefix(block.Parameters[0], ":") || strings.HasPrefix(block.Parameters[0], "-") {
+		return "unknown"
+	}
+	return strings.ToLower(block.Parameters[0])
+}
+
 // getCodeBlockAction returns action to evaluate a code block.
 func getCodeBlockAction(block org.Block, uri protocol.DocumentURI) protocol.CodeAction {
-	lang := "unknown"
-	if len(block.Parameters) > 0 {
-		lang = block.Parameters[0]
-	}
-	title := fmt.Sprintf("Evaluate %s code block", lang)
+	title := fmt.Sprintf("Evaluate %s code block", blockLanguage(block))
 
 	kindQuickFix := protocol.QuickFix
 
@@ -517,7 +831,7 @@ func getCodeBlockAction(block org.Block, uri protocol.DocumentURI) protocol.Code
 		Diagnostics: nil,
 		Command: &protocol.Command{
 			Title:     title,
-			Command:   "org.executeCodeBlock",
+			Command:   CommandExecuteCodeBlock,
 			Arguments: []any{string(uri), block.Pos.StartLine, block.Pos.StartColumn},
 		},
 	}
@@ -549,12 +863,14 @@ func (s *ServerImpl) ExecuteCodeBlock(uri protocol.DocumentURI, line, column int
 		return "", fmt.Errorf("no src block found at position")
 	}
 
-	lang := "unknown"
-	if len(block.Parameters) > 0 {
-		lang = block.Parameters[0]
-	}
+	lang := blockLanguage(*block)
 	slog.Debug("Block details", "lang", lang, "pos", block.Pos, "parameters", block.Parameters)
 
+	if !s.state.Config.AllowsCodeLanguage(lang) {
+		slog.Warn("Refusing to execute code block in non-allowed language", "lang", lang)
+		return "", fmt.Errorf("execution of %s code blocks is not allowed (see allowedCodeLanguages)", lang)
+	}
+
 	// Extract code from block children
 	code := org.String(block.Children...)
 	if code == "" {
@@ -564,29 +880,79 @@ func (s *ServerImpl) ExecuteCodeBlock(uri protocol.DocumentURI, line, column int
 	slog.Debug("Code extracted", "codeLen", len(code), "code", code)
 
 	// Map language to executable
+	timeout := s.state.Config.CodeExecutionTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
 	var cmd *exec.Cmd
 	switch lang {
 	case "python", "python3":
-		cmd = exec.Command("python3", "-c", code)
+		cmd = exec.CommandContext(ctx, "python3", "-c", code)
 	case "bash", "sh", "shell":
-		cmd = exec.Command("bash", "-c", code)
+		cmd = exec.CommandContext(ctx, "bash", "-c", code)
 	case "js", "javascript":
-		cmd = exec.Command("node", "-e", code)
+		cmd = exec.CommandContext(ctx, "node", "-e", code)
 	case "ruby":
-		cmd = exec.Command("ruby", "-e", code)
+		cmd = exec.CommandContext(ctx, "ruby", "-e", code)
 	default:
 		slog.Debug("Language not supported", "lang", lang, "code", code)
 		return "", fmt.Errorf("unsupported language: %s", lang)
 	}
-	slog.Debug("Command created", "command", cmd, "cmdName", cmd.Args[0])
+	slog.Debug("Command created", "command", cmd, "cmdName", cmd.Args[0], "timeout", timeout)
+
+	// Don't wait on grandchildren holding the output pipe open after a kill
+	cmd.WaitDelay = time.Second
 
-	// Execute and capture output
-	output, err := cmd.CombinedOutput()
+	// Execute and capture (bounded) output
+	output := &limitedBuffer{limit: s.state.Config.CodeOutputLimit()}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		slog.Warn("Code execution timed out", "lang", lang, "timeout", timeout)
+		return "", fmt.Errorf("code block execution timed out after %s", timeout)
+	}
 	if err != nil {
-		slog.Error("Code execution failed", "error", err, "exitCode", cmd.ProcessState.ExitCode(), "output", string(output))
-		return fmt.Sprintf("Error: %v\nOutput: %s", err, string(output)), nil
+		slog.Error("Code execution failed", "error", err, "exitCode", cmd.ProcessState.ExitCode(), "output", output.String())
+		return fmt.Sprintf("Error: %v\nOutput: %s", err, output.String()), nil
+	}
+
+	slog.Debug("Code execution successful", "outputLen", output.Len(), "truncated", output.truncated)
+	return output.String(), nil
+}
+
+// limitedBuffer collects process output up to limit bytes and silently
+// discards the rest, so a runaway block can't exhaust server memory. Writes
+// always report success so the process isn't killed by a short write.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = len(p) > 0 || b.truncated
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
 	}
+	return b.buf.Write(p)
+}
+
+// Len returns the number of bytes kept
+func (b *limitedBuffer) Len() int {
+	return b.buf.Len()
+}
 
-	slog.Debug("Code execution successful", "outputLen", len(output))
-	return string(output), nil
+// String returns the kept output, followed by a notice if any was dropped
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + fmt.Sprintf("\n[output truncated at %d bytes]", b.limit)
+	}
+	return b.buf.String()
 }
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// checkboxItemRegexp matches a list item with a checkbox, capturing the
+// indentation and the checkbox state character
+var checkboxItemRegexp = regexp.MustCompile(`^(\s*)(?:[-+*]|\d+[.)]|[a-zA-Z][.)])\s+\[([ X-])\]`)
+
+// statisticsCookieRegexp matches a [n/m] or [n%] statistics cookie
+var statisticsCookieRegexp = regexp.MustCompile(`\[(\d*)/(\d*)\]|\[(\d*)%\]`)
+
+// checkboxItem is a checkbox list item found on a raw line
+type checkboxItem struct {
+	Indent    int
+	StatusCol int    // Byte column of the state character inside [ ]
+	Status    string // " ", "X", or "-"
+}
+
+// parseCheckboxItem returns the checkbox item on line, if any
+func parseCheckboxItem(line string) (checkboxItem, bool) {
+	match := checkboxItemRegexp.FindStringSubmatchIndex(line)
+	if match == nil || isHeadlineLine(line) {
+		return checkboxItem{}, false
+	}
+	return checkboxItem{
+		Indent:    match[3] - match[2],
+		StatusCol: match[4],
+		Status:    line[match[4]:match[5]],
+	}, true
+}
+
+// getCheckboxToggleAction returns an action toggling the checkbox on the
+// cursor line, along with an edit recomputing the statistics cookie of the
+// parent heading or list item if it has one.
+func getCheckboxToggleAction(content string, uri protocol.DocumentURI, cursorPos protocol.Position) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	lineNum := int(cursorPos.Line)
+	if lineNum >= len(lines) {
+		return protocol.CodeAction{}, false
+	}
+
+	item, found := parseCheckboxItem(lines[lineNum])
+	if !found {
+		return protocol.CodeAction{}, false
+	}
+
+	newStatus := "X"
+	title := "Org: Check item"
+	if item.Status == "X" {
+		newStatus = " "
+		title = "Org: Uncheck item"
+	}
+
+	edits := []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(lineNum), Character: uint32(item.StatusCol)},
+			End:   protocol.Position{Line: uint32(lineNum), Character: uint32(item.StatusCol + 1)},
+		},
+		NewText: newStatus,
+	}}
+
+	if cookieEdit, ok := statisticsCookieEdit(lines, lineNum, item, newStatus); ok {
+		edits = append(edits, cookieEdit)
+	}
+
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: edits,
+			},
+		},
+	}, true
+}
+
+// statisticsCookieEdit recomputes the [n/m] or [n%] cookie on the line that
+// owns the item at itemLine: the nearest heading, or the nearest list item
+// indented less than it. Sibling checkboxes are counted with the toggled
+// item's state replaced by newStatus.
+func statisticsCookieEdit(lines []string, itemLine int, item checkboxItem, newStatus string) (protocol.TextEdit, bool) {
+	parentLine := -1
+	parentIsHeading := false
+	for i := itemLine - 1; i >= 0; i-- {
+		line := lines[i]
+		if isHeadlineLine(line) {
+			parentLine, parentIsHeading = i, true
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent := len(line) - len(strings.TrimLeft(line, " \t")); indent < item.Indent {
+			parentLine = i
+			break
+		}
+	}
+	if parentLine == -1 {
+		return protocol.TextEdit{}, false
+	}
+
+	cookie := statisticsCookieRegexp.FindStringSubmatchIndex(lines[parentLine])
+	if cookie == nil {
+		return protocol.TextEdit{}, false
+	}
+
+	done, total := 0, 0
+	for i := parentLine + 1; i < len(lines); i++ {
+		line := lines[i]
+		if isHeadlineLine(line) {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if !parentIsHeading && indent < item.Indent {
+			break
+		}
+		sibling, ok := parseCheckboxItem(line)
+		if !ok || sibling.Indent != item.Indent {
+			continue
+		}
+		status := sibling.Status
+		if i == itemLine {
+			status = newStatus
+		}
+		total++
+		if status == "X" {
+			done++
+		}
+	}
+
+	var newCookie string
+	if cookie[6] != -1 {
+		percent := 0
+		if total > 0 {
+			percent = done * 100 / total
+		}
+		newCookie = fmt.Sprintf("[%d%%]", percent)
+	} else {
+		newCookie = fmt.Sprintf("[%d/%d]", done, total)
+	}
+
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(parentLine), Character: uint32(cookie[0])},
+			End:   protocol.Position{Line: uint32(parentLine), Character: uint32(cookie[1])},
+		},
+		NewText: newCookie,
+	}, true
+}
+
+// isHeadlineLine reports whether a raw line starts an org headline
+func isHeadlineLine(line string) bool {
+	stars := len(line) - len(strings.TrimLeft(line, "*"))
+	return stars > 0 && stars < len(line) && line[stars] == ' '
+}
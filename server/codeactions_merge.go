@@ -0,0 +1,141 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// getMergeHeadingAction returns an action folding the heading on the cursor
+// line into its previous sibling: its title, without the stars, and body
+// are appended to the sibling's body, ahead of the sibling's subheadings,
+// and its own subheadings follow the sibling's. Its planning line and
+// properties move up into the sibling's, which win on conflicts, since they
+// would mean nothing in the middle of a body. A first sibling has nothing to
+// merge into.
+func getMergeHeadingAction(headline org.Headline, content string, uri protocol.DocumentURI, cursorPos protocol.Position) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	start := headline.Pos.StartLine
+	if int(cursorPos.Line) != start || start >= len(lines) {
+		return protocol.CodeAction{}, false
+	}
+	level := getHeadingLevel(lines[start])
+	if level == 0 {
+		return protocol.CodeAction{}, false
+	}
+
+	// The nearest heading above at this level or higher must be a sibling,
+	// not the parent
+	prev := -1
+	for i := start - 1; i >= 0; i-- {
+		if lvl := getHeadingLevel(lines[i]); lvl > 0 && lvl <= level {
+			if lvl == level {
+				prev = i
+			}
+			break
+		}
+	}
+	if prev < 0 {
+		return protocol.CodeAction{}, false
+	}
+
+	// Where the sibling's subheadings start, where this heading's own
+	// subheadings start, and where its subtree ends
+	prevChildren := start
+	for i := prev + 1; i < start; i++ {
+		if getHeadingLevel(lines[i]) > 0 {
+			prevChildren = i
+			break
+		}
+	}
+	ownChildren, end := -1, len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		lvl := getHeadingLevel(lines[i])
+		if lvl == 0 {
+			continue
+		}
+		if ownChildren < 0 {
+			ownChildren = i
+		}
+		if lvl <= level {
+			end = i
+			break
+		}
+	}
+	if ownChildren < 0 || ownChildren > end {
+		ownChildren = end
+	}
+
+	prevPlanning, prevDrawer, prevDrawerEnd := headlineMetaLines(lines, prev)
+	ownPlanning, ownDrawer, ownDrawerEnd := headlineMetaLines(lines, start)
+	prevBody := max(prev+1, prevPlanning+1, prevDrawerEnd+1)
+	ownBody := max(start+1, ownPlanning+1, ownDrawerEnd+1)
+
+	merged := []string{lines[prev]}
+	if prevPlanning >= 0 {
+		merged = append(merged, lines[prevPlanning])
+	} else if ownPlanning >= 0 {
+		merged = append(merged, lines[ownPlanning])
+	}
+	merged = append(merged, mergePropertyDrawers(lines, prevDrawer, prevDrawerEnd, ownDrawer, ownDrawerEnd)...)
+	merged = append(merged, lines[prevBody:prevChildren]...)
+	merged = append(merged, strings.TrimLeft(lines[start][level:], " "))
+	merged = append(merged, lines[ownBody:ownChildren]...)
+	merged = append(merged, lines[prevChildren:start]...)
+	merged = append(merged, lines[ownChildren:end]...)
+
+	// Replace through the start of the next heading, or to the end of the
+	// document if this is the last subtree
+	editRange := protocol.Range{Start: protocol.Position{Line: uint32(prev)}}
+	newText := strings.Join(merged, "\n")
+	if end < len(lines) {
+		editRange.End = protocol.Position{Line: uint32(end)}
+		newText += "\n"
+	} else {
+		last := len(lines) - 1
+		editRange.End = protocol.Position{Line: uint32(last), Character: uint32(len(lines[last]))}
+	}
+
+	return protocol.CodeAction{
+		Title: "Org: Merge with previous heading",
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {{Range: editRange, NewText: newText}},
+			},
+		},
+	}, true
+}
+
+// mergePropertyDrawers returns the lines of a drawer holding every property
+// of the first drawer, plus those of the second whose keys it lacks. Either
+// drawer may be missing (start -1); with neither there is no drawer.
+func mergePropertyDrawers(lines []string, first, firstEnd, second, secondEnd int) []string {
+	if first < 0 {
+		if second < 0 {
+			return nil
+		}
+		return lines[second : secondEnd+1]
+	}
+
+	drawer := append([]string{}, lines[first:firstEnd]...)
+	if second >= 0 {
+		keys := map[string]bool{}
+		for _, line := range lines[first+1 : firstEnd] {
+			keys[propertyLineKey(line)] = true
+		}
+		for _, line := range lines[second+1 : secondEnd] {
+			if !keys[propertyLineKey(line)] {
+				drawer = append(drawer, line)
+			}
+		}
+	}
+	return append(drawer, lines[firstEnd])
+}
+
+// propertyLineKey returns the upper-cased key of a ":KEY: value" line
+func propertyLineKey(line string) string {
+	key, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), ":"), ":")
+	return strings.ToUpper(key)
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -11,7 +12,7 @@ import (
 
 // getSnippetCodeActions returns all applicable snippet-based code actions
 // for the given headline at the cursor position.
-func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc *org.Document, cursorPos protocol.Position, selectionRange protocol.Range) []protocol.CodeAction {
+func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc *org.Document, content string, cursorPos protocol.Position, selectionRange protocol.Range) []protocol.CodeAction {
 	var actions []protocol.CodeAction
 
 	// 1. Add DEADLINE (only if headline doesn't have DEADLINE)
@@ -127,29 +128,17 @@ func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc
 	// We should probably insert properties using the OpenDocument AST from server state, modifying it and then refreshing all the text in the file. Less performance-efficient, but far more reliable.
 	// We might want to do the same for all of these, tbh, if the parser supports CLOCK, DEADLINE. SCHEDULE, etc. Or extend it to do so!
 
-	// 9. Set Custom ID (only if headline doesn't have CUSTOM_ID)
+	// 9. Add ID (only if headline doesn't have one)
+	if !hasIDProperty(headline) {
+		actions = append(actions, getAddIDAction(headline, uri, content))
+	}
+
+	// 10. Set Custom ID (only if headline doesn't have CUSTOM_ID)
 	if !hasCustomID(headline) {
-		// Generate a suggested ID from the headline title
-		suggestedID := slugify(org.String(headline.Title...))
-		editRange, drawerExists := findPropertyDrawerInsertionPoint(headline, doc)
-		var customIDSnippet string
-		if drawerExists {
-			// Drawer exists, just add the property line before it
-			customIDSnippet = ":CUSTOM_ID: ${1:" + suggestedID + "}\n"
-		} else {
-			// No drawer, wrap in full PROPERTIES drawer with leading newline
-			customIDSnippet = "\n:PROPERTIES:\n:CUSTOM_ID: ${1:" + suggestedID + "}\n:END:"
-		}
-		actions = append(actions, createSnippetAction(
-			"Org: Set CUSTOM_ID",
-			protocol.CodeActionKind("source"),
-			uri,
-			editRange,
-			customIDSnippet,
-		))
+		actions = append(actions, getSetCustomIDAction(headline, uri, doc, content))
 	}
 
-	// 10. Set Effort (only if headline doesn't have EFFORT)
+	// 11. Set Effort (only if headline doesn't have EFFORT)
 	if !hasEffort(headline) {
 		editRange, drawerExists := findPropertyDrawerInsertionPoint(headline, doc)
 		var effortSnippet string
@@ -169,7 +158,7 @@ func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc
 		))
 	}
 
-	// 11. Add Generic Property (always available)
+	// 12. Add Generic Property (always available)
 	genericPropRange, drawerExists := findPropertyDrawerInsertionPoint(headline, doc)
 	var genericPropSnippet string
 	if drawerExists {
@@ -187,7 +176,7 @@ func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc
 		genericPropSnippet,
 	))
 
-	// 12. Insert Link (only when no text is selected - otherwise "Wrap selection in link" handles this)
+	// 13. Insert Link (only when no text is selected - otherwise "Wrap selection in link" handles this)
 	if !hasSelection(selectionRange) {
 		linkSnippet := "[[${1:url}][${2:description}]]$0"
 		linkRange := protocol.Range{
@@ -202,7 +191,7 @@ func getSnippetCodeActions(headline org.Headline, uri protocol.DocumentURI, doc
 			linkSnippet,
 		))
 
-		// 13. Insert ID Link (only when no text is selected)
+		// 14. Insert ID Link (only when no text is selected)
 		idLinkSnippet := "[[id:${1:id}]]$0"
 		idLinkRange := protocol.Range{
 			Start: cursorPos,
@@ -405,6 +394,120 @@ func slugify(text string) string {
 	return text
 }
 
+// uniqueCustomID slugifies title into a CUSTOM_ID, appending -2, -3, ...
+// if the slug is already used by another heading in the document
+func uniqueCustomID(doc *org.Document, title string) string {
+	return nextCustomID(customIDsInUse(doc), title)
+}
+
+// customIDsInUse returns the set of CUSTOM_IDs of doc's headings
+func customIDsInUse(doc *org.Document) map[string]bool {
+	used := make(map[string]bool)
+	for _, headline := range collectHeadlines(doc) {
+		if id := getPropertyValue(headline, "CUSTOM_ID"); id != "" {
+			used[id] = true
+		}
+	}
+	return used
+}
+
+// nextCustomID slugifies title into a CUSTOM_ID not in used, appending -2,
+// -3, ... as needed, and marks it used
+func nextCustomID(used map[string]bool, title string) string {
+	base := slugify(title)
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for n := 2; used[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[id] = true
+	return id
+}
+
+// getSetCustomIDAction returns an action that adds a CUSTOM_ID slugified
+// from the heading title, made unique within the file, writing the drawer
+// the same way as adding an :ID: does
+func getSetCustomIDAction(headline org.Headline, uri protocol.DocumentURI, doc *org.Document, content string) protocol.CodeAction {
+	customID := uniqueCustomID(doc, org.String(headline.Title...))
+	updated := setHeadlineProperty(headline, "CUSTOM_ID", customID)
+
+	return protocol.CodeAction{
+		Title: "Org: Set CUSTOM_ID",
+		Kind:  protocol.QuickFix,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {propertyDrawerEdit(headline, updated, content)},
+			},
+		},
+	}
+}
+
+// addIDActionTitle is the title of the action adding an :ID: to one heading
+const addIDActionTitle = "Org: Add ID to this heading"
+
+// getAddIDAction returns an action that gives just this heading an :ID:,
+// the same one formatting would add, without touching the rest of the file
+func getAddIDAction(headline org.Headline, uri protocol.DocumentURI, content string) protocol.CodeAction {
+	updated := ensureHeadlineUUID(headline)
+
+	return protocol.CodeAction{
+		Title: addIDActionTitle,
+		Kind:  protocol.QuickFix,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {propertyDrawerEdit(headline, updated, content)},
+			},
+		},
+	}
+}
+
+// missingIDDiagnostics returns the missing-ID hints among diagnostics that
+// are on the headline at line
+func missingIDDiagnostics(diagnostics []protocol.Diagnostic, line int) []protocol.Diagnostic {
+	var matched []protocol.Diagnostic
+	for _, d := range diagnostics {
+		if code, ok := d.Code.(string); ok && code == missingIDDiagnosticCode && int(d.Range.Start.Line) == line {
+			matched = append(matched, d)
+		}
+	}
+	return matched
+}
+
+// propertyDrawerEdit returns the edit replacing headline's property drawer
+// with updated's, or inserting updated's drawer when it has none yet: below
+// the planning line if there is one, since org only finds a drawer there,
+// else below the headline line
+func propertyDrawerEdit(headline, updated org.Headline, content string) protocol.TextEdit {
+	drawer := org.String(*updated.Properties)
+
+	lines := strings.Split(content, "\n")
+	if headline.Properties != nil {
+		// Replace the existing drawer, :PROPERTIES: through :END:
+		pos := headline.Properties.Pos
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(pos.StartLine), Character: 0},
+				End:   protocol.Position{Line: uint32(pos.EndLine + 1), Character: 0},
+			},
+			NewText: drawer,
+		}
+	}
+	after := headline.Pos.StartLine
+	if planning, _, _ := headlineMetaLines(lines, after); planning >= 0 {
+		after = planning
+	}
+	if after+1 < len(lines) {
+		insertAt := protocol.Position{Line: uint32(after + 1), Character: 0}
+		return protocol.TextEdit{Range: protocol.Range{Start: insertAt, End: insertAt}, NewText: drawer}
+	}
+	// The drawer goes after the last line of a file without a trailing newline
+	insertAt := protocol.Position{Line: uint32(after), Character: uint32(len(lines[after]))}
+	return protocol.TextEdit{Range: protocol.Range{Start: insertAt, End: insertAt}, NewText: "\n" + strings.TrimSuffix(drawer, "\n")}
+}
+
 // findInsertionPoint finds the position to insert new content after the headline title
 // Calculates the correct position based on the end of the title (or first child if no title)
 // Always inserts at the end of the headline line (on the same line as the title)
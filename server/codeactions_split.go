@@ -0,0 +1,72 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// drawerLineRegexp matches a drawer or property line, like :PROPERTIES:,
+// :ID: abc, or :END:, which can't become a heading title
+var drawerLineRegexp = regexp.MustCompile(`^\s*:[\w-]+:`)
+
+// keywordLineRegexp matches a #+KEYWORD: line or block delimiter, which
+// can't become a heading title either
+var keywordLineRegexp = regexp.MustCompile(`^\s*#\+`)
+
+// getSplitHeadingAction returns an action turning the cursor line in
+// headline's body into the title of a new sibling heading, so the lines
+// below it become the new heading's body.
+func getSplitHeadingAction(headline org.Headline, content string, uri protocol.DocumentURI, cursorPos protocol.Position) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	lineNum := int(cursorPos.Line)
+	if lineNum <= headline.Pos.StartLine || lineNum >= len(lines) {
+		return protocol.CodeAction{}, false
+	}
+
+	line := strings.TrimRight(lines[lineNum], "\r")
+	title := strings.TrimLeft(line, " \t")
+	if title == "" || isHeadlineLine(line) || drawerLineRegexp.MatchString(line) ||
+		keywordLineRegexp.MatchString(line) || planningLineRegexp.MatchString(line) ||
+		strings.HasPrefix(title, "|") || insideBlock(lines[headline.Pos.StartLine+1:lineNum]) {
+		return protocol.CodeAction{}, false
+	}
+
+	return protocol.CodeAction{
+		Title: "Org: Split heading here",
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(lineNum), Character: 0},
+						End:   protocol.Position{Line: uint32(lineNum), Character: uint32(len(line) - len(title))},
+					},
+					NewText: strings.Repeat("*", headline.Lvl) + " ",
+				}},
+			},
+		},
+	}, true
+}
+
+// insideBlock reports whether the lines leave a #+BEGIN_ block open, so the
+// line after them is block content
+func insideBlock(lines []string) bool {
+	open := ""
+	for _, line := range lines {
+		m := blockDelimiterRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := strings.ToLower(m[2])
+		switch {
+		case open == "" && strings.EqualFold(m[1], "begin"):
+			open = name
+		case open == name && strings.EqualFold(m[1], "end"):
+			open = ""
+		}
+	}
+	return open != ""
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// getTimestampToggleAction returns an action toggling the timestamp under the
+// cursor between its active <...> and inactive [...] forms. The raw line is
+// scanned rather than the parsed document because inactive timestamps are
+// not parsed as org.Timestamp nodes, and node positions drift inside list
+// items.
+func getTimestampToggleAction(content string, uri protocol.DocumentURI, cursorPos protocol.Position) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	lineNum := int(cursorPos.Line)
+	if lineNum >= len(lines) {
+		return protocol.CodeAction{}, false
+	}
+	line := lines[lineNum]
+	col := int(cursorPos.Character)
+
+	for _, loc := range timestampLikeRegexp.FindAllStringIndex(line, -1) {
+		if col < loc[0] || col > loc[1] {
+			continue
+		}
+
+		text := line[loc[0]:loc[1]]
+		inner := text[1 : len(text)-1]
+		var newText, title string
+		switch {
+		case text[0] == '<' && text[len(text)-1] == '>':
+			newText, title = "["+inner+"]", "Org: Make timestamp inactive"
+		case text[0] == '[' && text[len(text)-1] == ']':
+			newText, title = "<"+inner+">", "Org: Make timestamp active"
+		default:
+			// Mismatched brackets aren't a timestamp; diagnostics flag those
+			continue
+		}
+
+		return protocol.CodeAction{
+			Title: title,
+			Kind:  protocol.RefactorRewrite,
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+					uri: {{
+						Range: protocol.Range{
+							Start: protocol.Position{Line: uint32(lineNum), Character: uint32(loc[0])},
+							End:   protocol.Position{Line: uint32(lineNum), Character: uint32(loc[1])},
+						},
+						NewText: newText,
+					}},
+				},
+			},
+		}, true
+	}
+
+	return protocol.CodeAction{}, false
+}
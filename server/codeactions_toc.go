@@ -0,0 +1,114 @@
+package server
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// tocKeywordRegexp matches a #+TOC: headlines marker, capturing the optional
+// depth limit
+var tocKeywordRegexp = regexp.MustCompile(`(?i)^\s*#\+TOC:\s*headlines(?:\s+(\d+))?\s*$`)
+
+// tocTargetRegexp matches a <<toc>> target on a line of its own
+var tocTargetRegexp = regexp.MustCompile(`(?i)^\s*<<toc>>\s*$`)
+
+// tocEntryRegexp matches a generated table of contents line, linking to a
+// heading by title or by CUSTOM_ID
+var tocEntryRegexp = regexp.MustCompile(`^\s*- \[\[[*#][^\]]*\]\[[^\]]*\]\]\s*$`)
+
+// tocDescriptionReplacer swaps brackets in a heading title for braces, since
+// a bracket would end the link description early
+var tocDescriptionReplacer = strings.NewReplacer("[", "{", "]", "}")
+
+// findTOCMarker returns the line of the first table of contents marker and
+// its depth limit, zero meaning unlimited
+func findTOCMarker(lines []string) (line, depth int, found bool) {
+	for i, text := range lines {
+		if match := tocKeywordRegexp.FindStringSubmatch(text); match != nil {
+			depth, _ = strconv.Atoi(match[1])
+			return i, depth, true
+		}
+		if tocTargetRegexp.MatchString(text) {
+			return i, 0, true
+		}
+	}
+	return 0, 0, false
+}
+
+// getTOCAction returns an action inserting a table of contents of the
+// document's headings after its #+TOC: headlines or <<toc>> marker, or
+// refreshing the one already there. It is offered with the cursor on the
+// marker or inside the generated list, which is the run of [[*Heading]]
+// items directly below the marker. A title with brackets can't be a link
+// target, so those headings are linked by CUSTOM_ID, which is added to
+// the ones without.
+func getTOCAction(content string, uri protocol.DocumentURI, doc *org.Document, cursorPos protocol.Position) (protocol.CodeAction, bool) {
+	lines := strings.Split(content, "\n")
+	markerLine, depth, found := findTOCMarker(lines)
+	if !found {
+		return protocol.CodeAction{}, false
+	}
+
+	regionEnd := markerLine + 1
+	for regionEnd < len(lines) && tocEntryRegexp.MatchString(lines[regionEnd]) {
+		regionEnd++
+	}
+	if cursor := int(cursorPos.Line); cursor < markerLine || cursor >= regionEnd {
+		return protocol.CodeAction{}, false
+	}
+
+	var entries []string
+	var drawerEdits []protocol.TextEdit
+	usedIDs := customIDsInUse(doc)
+	for _, headline := range collectHeadlines(doc) {
+		if depth > 0 && headline.Lvl > depth {
+			continue
+		}
+		title := strings.TrimSpace(org.String(headline.Title...))
+		if title == "" {
+			continue
+		}
+		target := "*" + title
+		if strings.ContainsAny(title, "[]") {
+			id := getPropertyValue(headline, "CUSTOM_ID")
+			if id == "" {
+				id = nextCustomID(usedIDs, title)
+				updated := setHeadlineProperty(headline, "CUSTOM_ID", id)
+				drawerEdits = append(drawerEdits, propertyDrawerEdit(headline, updated, content))
+			}
+			target = "#" + id
+		}
+		entries = append(entries, strings.Repeat("  ", headline.Lvl-1)+"- [["+target+"]["+tocDescriptionReplacer.Replace(title)+"]]")
+	}
+	if len(entries) == 0 {
+		return protocol.CodeAction{}, false
+	}
+
+	title := "Org: Insert table of contents"
+	if regionEnd > markerLine+1 {
+		title = "Org: Update table of contents"
+	}
+
+	// Replace from the end of the marker line through the old list, so the
+	// edit works the same whether or not a list is already there
+	lastLine := regionEnd - 1
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: append([]protocol.TextEdit{{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(markerLine), Character: uint32(len(lines[markerLine]))},
+						End:   protocol.Position{Line: uint32(lastLine), Character: uint32(len(lines[lastLine]))},
+					},
+					NewText: "\n" + strings.Join(entries, "\n"),
+				}}, drawerEdits...),
+			},
+		},
+	}, true
+}
@@ -149,7 +149,7 @@ func countBacklinks(state *State, targetFilePath, targetUUID string) int {
 	// Walk through all processed files
 	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
 		fileInfo, ok := value.(*orgscanner.FileInfo)
-		if !ok || fileInfo.ParsedOrg == nil {
+		if !ok {
 			return true // continue iteration
 		}
 
@@ -158,51 +158,38 @@ func countBacklinks(state *State, targetFilePath, targetUUID string) int {
 			return true // continue iteration
 		}
 
-		// Search for links in this file
-		var walkNodes func(node org.Node)
-		walkNodes = func(node org.Node) {
-			if link, ok := node.(org.RegularLink); ok {
-				// Check for id: link
-				if targetUUID != "" {
-					if linkUUID, ok0 := strings.CutPrefix(link.URL, "id:"); ok0 && linkUUID == targetUUID {
-						count++
-						return
-					}
+		// Search the links indexed for this file
+		for _, link := range fileInfo.Links {
+			// Check for id: link
+			if targetUUID != "" {
+				if linkUUID, ok0 := strings.CutPrefix(link.URL, "id:"); ok0 && linkUUID == targetUUID {
+					count++
+					continue
 				}
+			}
 
-				// Check for file: link pointing to target file
-				if after, ok0 := strings.CutPrefix(link.URL, "file:"); ok0 {
-					linkPath := after
+			// Check for file: link pointing to target file
+			if after, ok0 := strings.CutPrefix(link.URL, "file:"); ok0 {
+				linkPath := after
 
-					// Resolve the link path to compare with target
-					// Get the source file's directory
-					sourceRelPath := fileInfo.Path
-					sourceAbsPath := filepath.Join(state.OrgScanRoot, sourceRelPath)
-					sourceDir := filepath.Dir(sourceAbsPath)
+				// Resolve the link path to compare with target
+				// Get the source file's directory
+				sourceRelPath := fileInfo.Path
+				sourceAbsPath := filepath.Join(state.OrgScanRoot, sourceRelPath)
+				sourceDir := filepath.Dir(sourceAbsPath)
 
-					// Resolve the link relative to source file's directory
-					resolvedPath := filepath.Join(sourceDir, linkPath)
-					resolvedPath = filepath.Clean(resolvedPath)
+				// Resolve the link relative to source file's directory
+				resolvedPath := filepath.Join(sourceDir, linkPath)
+				resolvedPath = filepath.Clean(resolvedPath)
 
-					// Compare with target file's absolute path
-					targetAbsPath := filepath.Join(state.OrgScanRoot, targetFilePath)
-					targetAbsPath = filepath.Clean(targetAbsPath)
+				// Compare with target file's absolute path
+				targetAbsPath := filepath.Join(state.OrgScanRoot, targetFilePath)
+				targetAbsPath = filepath.Clean(targetAbsPath)
 
-					if resolvedPath == targetAbsPath {
-						count++
-					}
+				if resolvedPath == targetAbsPath {
+					count++
 				}
 			}
-
-			// Walk children
-			node.Range(func(n org.Node) bool {
-				walkNodes(n)
-				return true
-			})
-		}
-
-		for _, node := range fileInfo.ParsedOrg.Nodes {
-			walkNodes(node)
 		}
 
 		return true // continue iteration
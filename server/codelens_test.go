@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// linkingNotes is how many files link to the target headings, well above
+// what the scanner's document cache holds
+const linkingNotes = 3 * orgscanner.DocumentCacheSize
+
+// linkedWorkspace indexes a target file with a few ID headings and
+// linkingNotes notes, each linking to every heading and to a missing ID
+func linkedWorkspace(t *testing.T) (*State, []string) {
+	root := t.TempDir()
+	ids := []string{
+		"11111111-1111-1111-1111-111111111111",
+		"22222222-2222-2222-2222-222222222222",
+		"33333333-3333-3333-3333-333333333333",
+	}
+
+	var target, note strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&target, "* Heading %s\n:PROPERTIES:\n:ID: %s\n:END:\n", id, id)
+		fmt.Fprintf(&note, "See [[id:%s]].\n", id)
+	}
+	note.WriteString("And [[id:missing]].\n")
+
+	testza.AssertNoError(t, os.WriteFile(filepath.Join(root, "target.org"), []byte(target.String()), 0o644))
+	for i := range linkingNotes {
+		path := filepath.Join(root, fmt.Sprintf("note%03d.org", i))
+		testza.AssertNoError(t, os.WriteFile(path, []byte("* Note\n"+note.String()), 0o644))
+	}
+
+	scanner := orgscanner.NewOrgScanner(root)
+	testza.AssertNoError(t, scanner.Process())
+	return &State{
+		OrgScanRoot: root,
+		Roots:       []string{root},
+		Scanner:     scanner,
+		OpenDocs:    map[protocol.DocumentURI]*org.Document{},
+		RawContent:  map[protocol.DocumentURI]string{},
+	}, ids
+}
+
+func TestLinkSearchesUseTheIndex(t *testing.T) {
+	state, ids := linkedWorkspace(t)
+
+	for _, id := range ids {
+		testza.AssertEqual(t, linkingNotes, countBacklinks(state, "target.org", id))
+
+		refs, err := findIDReferences(state, id, false)
+		testza.AssertNoError(t, err)
+		testza.AssertLen(t, refs, linkingNotes)
+	}
+	testza.AssertLen(t, collectDanglingLinks(state), linkingNotes)
+
+	testza.AssertEqual(t, 0, state.Scanner.CachedDocumentCount(), "Link searches should not re-parse indexed files")
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// codeRefLabelRegexp matches a (ref:name) label inside a src block
+var codeRefLabelRegexp = regexp.MustCompile(`\(ref:([^()\s]+)\)`)
+
+// codeRefLabels maps every (ref:name) label in the src blocks of doc to the
+// range of the label on its line
+func codeRefLabels(doc *org.Document, content string) map[string]protocol.Range {
+	labels := make(map[string]protocol.Range)
+	lines := strings.Split(content, "\n")
+	for _, block := range collectSrcBlocks(doc) {
+		for i := block.Pos.StartLine; i <= block.Pos.EndLine && i < len(lines); i++ {
+			for _, match := range codeRefLabelRegexp.FindAllStringSubmatchIndex(lines[i], -1) {
+				name := lines[i][match[2]:match[3]]
+				if _, seen := labels[name]; !seen {
+					labels[name] = protocol.Range{
+						Start: protocol.Position{Line: uint32(i), Character: uint32(match[0])},
+						End:   protocol.Position{Line: uint32(i), Character: uint32(match[1])},
+					}
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// resolveCodeRefLink resolves a [[(name)]] coderef link to the line in the
+// current document where the (ref:name) label appears
+func resolveCodeRefLink(state *State, uri protocol.DocumentURI, doc *org.Document, linkURL string) (protocol.Location, error) {
+	name := strings.TrimSuffix(strings.TrimPrefix(linkURL, "("), ")")
+	labelRange, found := codeRefLabels(doc, state.RawContent[uri])[name]
+	if !found {
+		return protocol.Location{}, fmt.Errorf("coderef label not found: %s", name)
+	}
+	return protocol.Location{URI: uri, Range: labelRange}, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// hexColorRegexp matches #RRGGBB and #RRGGBBAA color codes that aren't part
+// of a longer word. Three-digit codes are skipped since they clash with
+// ordinary words like #add.
+var hexColorRegexp = regexp.MustCompile(`(?:^|[^\w&#])(#[0-9a-fA-F]{6}(?:[0-9a-fA-F]{2})?)\b`)
+
+func (s *ServerImpl) DocumentColor(ctx context.Context, params *protocol.DocumentColorParams) (result []protocol.ColorInformation, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	content, found := s.state.RawContent[params.TextDocument.URI]
+	if !found {
+		return nil, nil
+	}
+
+	colors := []protocol.ColorInformation{}
+	for lineNum, line := range strings.Split(content, "\n") {
+		for _, loc := range hexColorRegexp.FindAllStringSubmatchIndex(line, -1) {
+			start, end := loc[2], loc[3]
+			colors = append(colors, protocol.ColorInformation{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(lineNum), Character: uint32(start)},
+					End:   protocol.Position{Line: uint32(lineNum), Character: uint32(end)},
+				},
+				Color: parseHexColor(line[start+1 : end]),
+			})
+		}
+	}
+
+	slog.Debug("Document colors found", "uri", params.TextDocument.URI, "count", len(colors))
+	return colors, nil
+}
+
+func (s *ServerImpl) ColorPresentation(ctx context.Context, params *protocol.ColorPresentationParams) (result []protocol.ColorPresentation, err error) {
+	label := formatHexColor(params.Color)
+	return []protocol.ColorPresentation{{
+		Label:    label,
+		TextEdit: &protocol.TextEdit{Range: params.Range, NewText: label},
+	}}, nil
+}
+
+// parseHexColor converts RRGGBB or RRGGBBAA digits to a color with
+// components in [0, 1]
+func parseHexColor(hex string) protocol.Color {
+	component := func(i int) float64 {
+		v, _ := strconv.ParseUint(hex[i:i+2], 16, 8)
+		return float64(v) / 255
+	}
+
+	color := protocol.Color{Red: component(0), Green: component(2), Blue: component(4), Alpha: 1}
+	if len(hex) == 8 {
+		color.Alpha = component(6)
+	}
+	return color
+}
+
+// formatHexColor renders color as #rrggbb, or #rrggbbaa when translucent
+func formatHexColor(color protocol.Color) string {
+	byteOf := func(v float64) int {
+		return int(min(max(v, 0), 1)*255 + 0.5)
+	}
+
+	hex := fmt.Sprintf("#%02x%02x%02x", byteOf(color.Red), byteOf(color.Green), byteOf(color.Blue))
+	if color.Alpha < 1 {
+		hex += fmt.Sprintf("%02x", byteOf(color.Alpha))
+	}
+	return hex
+}
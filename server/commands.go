@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// Command names understood by workspace/executeCommand.
+const (
+	CommandExecuteCodeBlock  = "org.executeCodeBlock"
+	CommandRefile            = "org.refile"
+	CommandArchive           = "org.archiveSubtree"
+	CommandAgenda            = "org.agenda"
+	CommandTodoTree          = "org.todoTree"
+	CommandCheckLinks        = "org.checkLinks"
+	CommandExportMarkdown    = "org.exportMarkdown"
+	CommandTangle            = "org.tangle"
+	CommandCopyIDLink        = "org.copyIdLink"
+	CommandInsertJournalLink = "org.insertJournalLink"
+	CommandOpenAttachmentDir = "org.openAttachmentDir"
+)
+
+// supportedCommands lists every command advertised in ExecuteCommandProvider.
+var supportedCommands = []string{
+	CommandExecuteCodeBlock,
+	CommandRefile,
+	CommandArchive,
+	CommandAgenda,
+	CommandTodoTree,
+	CommandCheckLinks,
+	CommandExportMarkdown,
+	CommandTangle,
+	CommandCopyIDLink,
+	CommandInsertJournalLink,
+	CommandOpenAttachmentDir,
+}
+
+// ExecuteCommand handles workspace/executeCommand requests by dispatching on
+// the command name to the matching server-side implementation.
+func (s *ServerImpl) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCommandParams) (result interface{}, err error) {
+	slog.Debug("ExecuteCommand called", "command", params.Command, "args", params.Arguments)
+	if s.state == nil {
+		return nil, fmt.Errorf("server not initialized")
+	}
+
+	switch params.Command {
+	case CommandExecuteCodeBlock:
+		return s.executeCodeBlockCommand(ctx, params.Arguments)
+	case CommandRefile:
+		return s.refileCommand(ctx, params.Arguments)
+	case CommandArchive:
+		return s.archiveCommand(ctx, params.Arguments)
+	case CommandAgenda:
+		return s.agendaCommand(ctx, params.Arguments)
+	case CommandTodoTree:
+		return s.todoTreeCommand(ctx, params.Arguments)
+	case CommandCheckLinks:
+		return s.checkLinksCommand(ctx, params.Arguments)
+	case CommandExportMarkdown:
+		return s.exportMarkdownCommand(ctx, params.Arguments)
+	case CommandTangle:
+		return s.tangleCommand(ctx, params.Arguments)
+	case CommandCopyIDLink:
+		return s.copyIDLinkCommand(ctx, params.Arguments)
+	case CommandInsertJournalLink:
+		return s.insertJournalLinkCommand(ctx, params.Arguments)
+	case CommandOpenAttachmentDir:
+		return s.openAttachmentDirCommand(ctx, params.Arguments)
+	default:
+		slog.Warn("Unknown command", "command", params.Command)
+		return nil, fmt.Errorf("unknown command: %s", params.Command)
+	}
+}
+
+// executeCodeBlockCommand runs the src block identified by [uri, line, column],
+// asks the client to insert the output as a #+RESULTS: section below the
+// block, and returns the output.
+func (s *ServerImpl) executeCodeBlockCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, column, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.ExecuteCodeBlock(uri, line, column)
+	if err != nil {
+		return nil, err
+	}
+
+	edit, err := s.codeBlockResultsEdit(uri, line, column, output)
+	if err != nil {
+		slog.Warn("Failed to build code block results edit", "uri", uri, "error", err)
+		return output, nil
+	}
+
+	if err := s.applyWorkspaceEdit(ctx, "Org: Insert code block results", *edit); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// applyWorkspaceEdit asks the client to apply edit, under label in its undo
+// history. It fails when the request does or the client declines the edit;
+// without a client there is nothing to apply.
+func (s *ServerImpl) applyWorkspaceEdit(ctx context.Context, label string, edit protocol.WorkspaceEdit) error {
+	client := s.GetClient()
+	if client == nil {
+		return nil
+	}
+
+	s.state.Mu.RLock()
+	clientEdit(s.state, &edit)
+	s.state.Mu.RUnlock()
+
+	resp, err := client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{Label: label, Edit: edit})
+	if err != nil {
+		slog.Error("Failed to apply workspace edit", "label", label, "error", err)
+		return fmt.Errorf("failed to apply %q: %w", label, err)
+	}
+	if resp != nil && !resp.Applied {
+		slog.Debug("Client declined workspace edit", "label", label, "reason", resp.FailureReason)
+		return fmt.Errorf("client declined %q: %s", label, resp.FailureReason)
+	}
+	return nil
+}
+
+// decodeLocationArgs decodes the positional [uri, line, column] arguments
+// shared by commands that target a location in a document. JSON numbers
+// arrive as float64, so both integer and float encodings are accepted.
+func decodeLocationArgs(args []any) (protocol.DocumentURI, int, int, error) {
+	if len(args) < 3 {
+		return "", 0, 0, fmt.Errorf("expected [uri, line, column] arguments, got %d", len(args))
+	}
+
+	uri, ok := args[0].(string)
+	if !ok || uri == "" {
+		return "", 0, 0, fmt.Errorf("invalid uri argument: %v", args[0])
+	}
+
+	line, ok := toInt(args[1])
+	if !ok {
+		return "", 0, 0, fmt.Errorf("invalid line argument: %v", args[1])
+	}
+
+	column, ok := toInt(args[2])
+	if !ok {
+		return "", 0, 0, fmt.Errorf("invalid column argument: %v", args[2])
+	}
+
+	return protocol.DocumentURI(uri), line, column, nil
+}
+
+// toInt converts a decoded JSON number to an int
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
+
+// codeBlockResultsEdit builds the edit that writes output into a #+RESULTS:
+// section directly below the src block at the given position, replacing an
+// existing results section if there is one.
+func (s *ServerImpl) codeBlockResultsEdit(uri protocol.DocumentURI, line, column int, output string) (*protocol.WorkspaceEdit, error) {
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	doc, ok := s.state.OpenDocs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not found")
+	}
+	content, ok := s.state.RawContent[uri]
+	if !ok {
+		return nil, fmt.Errorf("document content not found")
+	}
+
+	pos := protocol.Position{Line: uint32(line), Character: uint32(column)}
+	block, found := findNodeAtPosition[org.Block](doc, pos)
+	if !found {
+		return nil, fmt.Errorf("no src block found at position")
+	}
+
+	// go-org folds an existing #+RESULTS: section into the block, so the
+	// block's end line is the end of its results when it has any
+	lines := strings.Split(content, "\n")
+	endLine := min(block.Pos.EndLine, len(lines)-1)
+	results := formatCodeBlockResults(output)
+
+	var editRange protocol.Range
+	if result, ok := block.Result.(org.Result); ok {
+		// Replace the old results, keeping whatever follows them untouched
+		editRange = protocol.Range{
+			Start: protocol.Position{Line: uint32(result.Pos.StartLine), Character: 0},
+			End:   protocol.Position{Line: uint32(endLine), Character: uint32(len(lines[endLine]))},
+		}
+		results = strings.TrimSuffix(results, "\n")
+	} else if endLine+1 < len(lines) {
+		editRange = protocol.Range{
+			Start: protocol.Position{Line: uint32(endLine + 1), Character: 0},
+			End:   protocol.Position{Line: uint32(endLine + 1), Character: 0},
+		}
+		results = "\n" + results
+	} else {
+		// Block is the last line of a file without a trailing newline
+		endOfBlock := protocol.Position{Line: uint32(endLine), Character: uint32(len(lines[endLine]))}
+		editRange = protocol.Range{Start: endOfBlock, End: endOfBlock}
+		results = "\n\n" + strings.TrimSuffix(results, "\n")
+	}
+
+	return &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			uri: {{Range: editRange, NewText: results}},
+		},
+	}, nil
+}
+
+// formatCodeBlockResults renders output as a #+RESULTS: section using
+// org's fixed-width ": " line prefix.
+func formatCodeBlockResults(output string) string {
+	var builder strings.Builder
+	builder.WriteString("#+RESULTS:\n")
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			builder.WriteString(":\n")
+			continue
+		}
+		builder.WriteString(": ")
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
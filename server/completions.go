@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/alexispurslane/go-org/org"
@@ -51,11 +53,34 @@ func (s *ServerImpl) Completion(ctx context.Context, params *protocol.Completion
 	case ContextTypeTag:
 		items = completeTags(s.state, doc, params.Position, completionCtx)
 	case ContextTypeFile:
-		items = completeFiles(s.state, completionCtx)
+		items = completeFiles(s.state, uri, completionCtx)
+	case ContextTypePriority:
+		items = completePriorities(completionCtx, params.Position)
+	case ContextTypeTodo:
+		items = completeTodoKeywords(doc, completionCtx, params.Position)
+	case ContextTypeInclude:
+		items = completeIncludeFiles(s.state, uri, completionCtx)
+	case ContextTypeDenote:
+		items = completeDenote(s.state, completionCtx)
+	case ContextTypeRoam:
+		items = completeRoamRefs(s.state, completionCtx)
+	case ContextTypeInternalLink:
+		items = completeInternalLinks(s.state, doc, uri, completionCtx, params.Position)
+		items = append(items, completeLinkAbbreviations(doc, completionCtx, params.Position)...)
 	case ContextTypeBlock:
-		items = completeBlockTypes(completionCtx, params.Position)
+		items = completeBlockTypes(s.state, completionCtx, params.Position)
 	case ContextTypeExport:
 		items = completeExportTypes(completionCtx, params.Position)
+	case ContextTypeCitation:
+		items = completeCitations(s.state, uri, completionCtx)
+	case ContextTypeEntity:
+		items = completeEntities(completionCtx)
+	case ContextTypePropertyValue:
+		items = completePropertyValues(s.state, uri, completionCtx)
+	case ContextTypeDrawer:
+		items = completePropertyDrawer(s.state, completionCtx, params.Position)
+	case ContextTypeTimestamp:
+		items = completeTimestamps(completionCtx, params.Position)
 	default:
 		return nil, nil
 	}
@@ -66,17 +91,74 @@ func (s *ServerImpl) Completion(ctx context.Context, params *protocol.Completion
 	}, nil
 }
 
+// CompletionResolve implements completionItem/resolve.
+//
+// ID link items carry their UUID in Data; resolving one fills in the heading
+// preview followed by the file it lives in and how many links point to it.
+// Previews need a file read and backlinks a walk over every indexed file, so
+// both are built here rather than for every item in the completion list.
+func (s *ServerImpl) CompletionResolve(ctx context.Context, params *protocol.CompletionItem) (result *protocol.CompletionItem, err error) {
+	if s.state == nil {
+		return params, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	uuid, ok := params.Data.(string)
+	if !ok || s.state.Scanner == nil || s.state.Scanner.ProcessedFiles == nil {
+		return params, nil
+	}
+
+	value, found := s.state.Scanner.ProcessedFiles.UuidIndex.Load(orgscanner.UUID(uuid))
+	if !found {
+		return params, nil
+	}
+	location := value.(orgscanner.HeaderLocation)
+
+	backlinks, _ := findIDReferences(s.state, uuid, false)
+	params.Documentation = protocol.MarkupContent{
+		Kind: "markdown",
+		Value: extractContextLinesForCompletion(s.state, location) +
+			"\n\n`" + location.FilePath + "` · " + formatBacklinkCount(len(backlinks)),
+	}
+
+	slog.Debug("Completion item resolved", "uuid", uuid, "backlinks", len(backlinks))
+	return params, nil
+}
+
 func detectCompletionContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
 	// First check if we're in a tag context (on headline line)
 	headline, found := findNodeAtPosition[org.Headline](doc, pos)
 	if found {
 		// Cursor must be on the headline's first line (where the * is)
 		if headline.Pos.StartLine == int(pos.Line) {
+			// The TODO keyword goes right after the stars
+			todoCtx := detectTodoContext(state, doc, uri, pos)
+			if todoCtx.Type != ContextTypeNone {
+				return todoCtx
+			}
+			// A priority cookie goes right after the TODO keyword
+			priorityCtx := detectPriorityContext(state, uri, pos, headline)
+			if priorityCtx.Type != ContextTypeNone {
+				return priorityCtx
+			}
 			// Now check if we're AFTER the headline title text (not at beginning)
-			return detectTagContext(doc, pos, headline)
+			return detectTagContext(state, uri, pos, headline)
 		}
 	}
 
+	// Check if we're opening a property drawer below a headline
+	drawerCtx := detectPropertyDrawerContext(state, uri, pos)
+	if drawerCtx.Type != ContextTypeNone {
+		return drawerCtx
+	}
+
+	// Check if we're completing a property value inside a drawer
+	propertyCtx := detectPropertyValueContext(state, uri, pos)
+	if propertyCtx.Type != ContextTypeNone {
+		return propertyCtx
+	}
+
 	// Check if we're in an export block completion context (must be before block context)
 	exportCtx := detectExportBlockContext(state, doc, uri, pos)
 	if exportCtx.Type != ContextTypeNone {
@@ -89,33 +171,68 @@ func detectCompletionContext(state *State, doc *org.Document, uri protocol.Docum
 		return blockCtx
 	}
 
+	// Check if we're in a citation key completion context
+	citationCtx := detectCitationContext(state, uri, pos)
+	if citationCtx.Type != ContextTypeNone {
+		return citationCtx
+	}
+
+	// Check if we're in an entity completion context
+	entityCtx := detectEntityContext(state, doc, uri, pos)
+	if entityCtx.Type != ContextTypeNone {
+		return entityCtx
+	}
+
 	// Check if we're in a file link completion context
 	fileCtx := detectFileContext(state, doc, uri, pos)
 	if fileCtx.Type != ContextTypeNone {
 		return fileCtx
 	}
 
-	// Check if we're in an ID link completion context by examining text before cursor
-	return detectIDContext(state, doc, uri, pos)
-}
+	// Check if we're completing the path of an #+INCLUDE: directive
+	includeCtx := detectIncludeContext(state, uri, pos)
+	if includeCtx.Type != ContextTypeNone {
+		return includeCtx
+	}
 
-// detectPrefixContext is a generic helper that checks if cursor is after a specific prefix
-func detectPrefixContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position, prefix string, ctxType CompletionContextType, checkClosingBrackets bool) CompletionContext {
-	ctx := CompletionContext{Type: ContextTypeNone}
+	// Check if we're in a Denote link completion context
+	denoteCtx := detectDenoteContext(state, doc, uri, pos)
+	if denoteCtx.Type != ContextTypeNone {
+		return denoteCtx
+	}
 
-	// Get raw content to check text before cursor
-	content, found := state.RawContent[uri]
-	if !found {
-		return ctx
+	// Check if we're in an org-roam ref completion context
+	roamCtx := detectRoamContext(state, doc, uri, pos)
+	if roamCtx.Type != ContextTypeNone {
+		return roamCtx
 	}
 
-	lines := strings.Split(content, "\n")
-	if int(pos.Line) >= len(lines) {
-		return ctx
+	// Check if we're in an ID link completion context by examining text before cursor
+	idCtx := detectIDContext(state, doc, uri, pos)
+	if idCtx.Type != ContextTypeNone {
+		return idCtx
 	}
 
-	line := lines[pos.Line]
-	if int(pos.Character) > len(line) {
+	// Check if we're opening a timestamp with "<" or "["
+	timestampCtx := detectTimestampContext(state, doc, uri, pos)
+	if timestampCtx.Type != ContextTypeNone {
+		return timestampCtx
+	}
+
+	// Otherwise a bare "[[" may be the start of a link to a heading or target
+	return detectInternalLinkContext(state, doc, uri, pos)
+}
+
+// detectPrefixContext is a generic helper that checks if cursor is after a
+// specific prefix. Link prefixes are matched exactly, since org link types
+// are case-sensitive, while keyword prefixes like "#+begin_" match in any
+// case, as org accepts them.
+func detectPrefixContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position, prefix string, ctxType CompletionContextType, checkClosingBrackets bool) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	// Get the cursor's line to check text before cursor
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
 		return ctx
 	}
 
@@ -123,17 +240,28 @@ func detectPrefixContext(state *State, doc *org.Document, uri protocol.DocumentU
 
 	// Find prefix
 	idx := strings.LastIndex(textBeforeCursor, prefix)
+	if !checkClosingBrackets {
+		idx = lastIndexFold(textBeforeCursor, prefix)
+	}
 	if idx == -1 {
 		return ctx
 	}
 
-	ctx.Type = ctxType
-	// Extract filter text after prefix, with bounds check
-	filterStart := idx + len(prefix)
-	if filterStart <= len(textBeforeCursor) {
-		ctx.FilterPrefix = strings.TrimSpace(textBeforeCursor[filterStart:])
+	// Trigger characters like "[" and "#" fire anywhere on a line, so make
+	// sure the cursor is still inside the prefixed token: a link ends at "]"
+	// and a keyword ends at whitespace
+	typed := textBeforeCursor[idx+len(prefix):]
+	if checkClosingBrackets && strings.Contains(typed, "]") {
+		return ctx
+	}
+	if !checkClosingBrackets && strings.ContainsAny(typed, " \t") {
+		return ctx
 	}
 
+	ctx.Type = ctxType
+	ctx.FilterPrefix = strings.TrimSpace(typed)
+	ctx.TypedPrefix = textBeforeCursor[idx : idx+len(prefix)]
+
 	// Check if closing brackets already exist after cursor (for links)
 	if checkClosingBrackets {
 		if int(pos.Character) < len(line) {
@@ -147,6 +275,26 @@ func detectPrefixContext(state *State, doc *org.Document, uri protocol.DocumentU
 	return ctx
 }
 
+// lastIndexFold is strings.LastIndex with ASCII case folding, for matching
+// an ASCII prefix without shifting byte offsets the way lowercasing would
+func lastIndexFold(s, substr string) int {
+	for i := len(s) - len(substr); i >= 0; i-- {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// keywordCase returns word in upper case when the keyword prefix the user
+// typed is upper case, and as-is otherwise
+func keywordCase(typedPrefix, word string) string {
+	if typedPrefix != strings.ToLower(typedPrefix) && typedPrefix == strings.ToUpper(typedPrefix) {
+		return strings.ToUpper(word)
+	}
+	return word
+}
+
 // detectBlockContext checks if cursor is in a block type completion context (after "#+begin_")
 func detectBlockContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
 	return detectPrefixContext(state, doc, uri, pos, "#+begin_", ContextTypeBlock, false)
@@ -171,7 +319,7 @@ func detectIDContext(state *State, doc *org.Document, uri protocol.DocumentURI,
 }
 
 // detectTagContext checks if cursor is in a valid tag position (after headline text)
-func detectTagContext(doc *org.Document, pos protocol.Position, headline *org.Headline) CompletionContext {
+func detectTagContext(state *State, uri protocol.DocumentURI, pos protocol.Position, headline *org.Headline) CompletionContext {
 	// Tags appear at the end of the headline line, after the title
 	// Check if position is after the headline title ends
 	// In org, Headline.Pos.EndLine is calculated based on content
@@ -186,11 +334,36 @@ func detectTagContext(doc *org.Document, pos protocol.Position, headline *org.He
 		return CompletionContext{Type: ContextTypeNone}
 	}
 
+	// go-org only parses tags once the line ends in a closing colon, so
+	// while one is being typed the ones before it come from the line itself
+	existing := append([]string{}, headline.Tags...)
+	if line, ok := documentLine(state, uri, int(pos.Line)); ok {
+		existing = append(existing, typedTags(line[:min(cursorCol, len(line))])...)
+	}
+
 	return CompletionContext{
 		Type:                ContextTypeTag,
 		FilterPrefix:        "",
 		NeedsClosingBracket: false,
+		ExistingTags:        existing,
+	}
+}
+
+// typedTags returns the complete tags in the tag group that ends before, as
+// "work" and "home" in "* Title :work:home:pa"
+func typedTags(before string) []string {
+	group := before[strings.LastIndexAny(before, " \t")+1:]
+	if !strings.HasPrefix(group, ":") {
+		return nil
 	}
+	parts := strings.Split(group, ":")
+	var tags []string
+	for _, tag := range parts[1 : len(parts)-1] {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
 }
 
 func completeIDs(state *State, ctx CompletionContext) []protocol.CompletionItem {
@@ -211,49 +384,55 @@ func completeIDs(state *State, ctx CompletionContext) []protocol.CompletionItem
 			title = "Untitled"
 		}
 
-		// Filter by title if user has typed something after the prefix
-		if ctx.FilterPrefix != "" {
-			titleLower := strings.ToLower(title)
-			if !strings.Contains(titleLower, ctx.FilterPrefix) {
-				return true // Skip this item, continue iteration
-			}
+		// Filter by title or UUID if user has typed something after the prefix
+		if ctx.FilterPrefix != "" &&
+			!strings.Contains(strings.ToLower(title), ctx.FilterPrefix) &&
+			!strings.Contains(strings.ToLower(uuid), ctx.FilterPrefix) {
+			return true // Skip this item, continue iteration
 		}
 
-		// Generate hover preview for this header as documentation
-		preview := extractContextLinesForCompletion(state, location)
-
 		// Build insert text: UUID + closing brackets if needed
 		insertText := uuid
 		if ctx.NeedsClosingBracket {
 			insertText = uuid + "]]"
 		}
 
-		// Create completion item with title as label, UUID as insert text
+		// Create completion item with title as label, UUID as insert text.
+		// Items stay lightweight: the UUID is kept in Data so CompletionResolve
+		// can read the file and build the preview for just the item the
+		// editor asks about. Sorting on the title, then the UUID, keeps
+		// headings with the same title in a stable order.
 		item := protocol.CompletionItem{
 			Label:      title, // User sees heading title
 			Kind:       protocol.CompletionItemKindReference,
-			Detail:     "ID Link",  // Type indicator
-			InsertText: insertText, // Full UUID inserted (+ closing brackets)
-			Documentation: protocol.MarkupContent{
-				Kind:  "markdown",
-				Value: preview,
-			},
+			Detail:     "ID Link", // Type indicator
+			SortText:   strings.ToLower(title) + " " + uuid,
+			FilterText: title + " " + uuid, // Typing either the title or the UUID matches
+			InsertText: insertText,         // Full UUID inserted (+ closing brackets)
+			Data:       uuid,
 		}
 
 		items = append(items, item)
 		return true // continue iteration
 	})
 
+	// The index is a sync.Map, so put its arbitrary order into the same
+	// order editors will show
+	sort.Slice(items, func(i, j int) bool { return items[i].SortText < items[j].SortText })
+
 	return items
 }
 
-// extractContextLinesForCompletion generates hover preview for completion items
+// drawerBeginRegexp matches the opening line of any drawer (:PROPERTIES:,
+// :LOGBOOK:, or a custom :NAME:)
+var drawerBeginRegexp = regexp.MustCompile(`^\s*:\S+:\s*$`)
+
+// extractContextLinesForCompletion generates hover preview for resolved completion items
 // Excludes header and properties list, since the former is already included in
-// the completion item's name, and the latter is useless, so starts 4 lines
-// *after*
+// the completion item's name, and the latter is useless. Shows the configured
+// number of context lines.
 func extractContextLinesForCompletion(state *State, loc orgscanner.HeaderLocation) string {
-	absPath := filepath.Join(state.OrgScanRoot, loc.FilePath)
-	absPath = filepath.Clean(absPath)
+	absPath := indexPathToAbs(state, loc.FilePath)
 
 	lines, err := readFileLines(absPath)
 	if err != nil {
@@ -265,28 +444,31 @@ func extractContextLinesForCompletion(state *State, loc orgscanner.HeaderLocatio
 	context.WriteString(loc.Title)
 	context.WriteString("**\n\n```org\n")
 
-	// Show header line and content below it
+	// Show the content below the header line
 	startLine := loc.Position.StartLine + 1 // Exclude title
-	numLines := 4
+	numLines := state.Config.ContextLineCount()
 	readLines := 0
-	inProperties := false
+	inDrawer := false
 
 	for _, line := range lines[startLine:] {
 		if readLines >= numLines {
 			break
 		}
 
-		if strings.Contains(line, ":PROPERTIES:") {
-			inProperties = true
-		} else if strings.Contains(line, ":END:") {
-			inProperties = false
+		if strings.EqualFold(strings.TrimSpace(line), ":END:") {
+			inDrawer = false
 			continue
+		} else if drawerBeginRegexp.MatchString(line) {
+			inDrawer = true
 		}
 
-		if inProperties {
+		if inDrawer {
 			continue
 		}
 
+		if readLines > 0 {
+			context.WriteString("\n")
+		}
 		context.WriteString(line)
 		readLines += 1
 	}
@@ -302,6 +484,10 @@ func completeTags(state *State, doc *org.Document, pos protocol.Position, ctx Co
 
 	var items []protocol.CompletionItem
 	seenTags := make(map[string]bool)
+	// Tags the headline already has aren't offered again
+	for _, tag := range ctx.ExistingTags {
+		seenTags[tag] = true
+	}
 
 	// Collect all unique tags from TagMap
 	for tag := range state.Scanner.ProcessedFiles.TagMap {
@@ -323,14 +509,16 @@ func completeTags(state *State, doc *org.Document, pos protocol.Position, ctx Co
 }
 
 // Helper to get string pointer
-func completeFiles(state *State, ctx CompletionContext) []protocol.CompletionItem {
+// completeFiles offers every indexed file, with paths relative to the
+// workspace root that owns the document being edited
+func completeFiles(state *State, uri protocol.DocumentURI, ctx CompletionContext) []protocol.CompletionItem {
 	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
 		return nil
 	}
 
-	var items []protocol.CompletionItem
-	filterLower := strings.ToLower(ctx.FilterPrefix)
+	root := ownerRoot(state, uriToPath(string(uri)))
 
+	var paths []string
 	// Walk through all processed files using sync.Map.Range
 	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
 		fileInfo, ok := value.(*orgscanner.FileInfo)
@@ -338,44 +526,64 @@ func completeFiles(state *State, ctx CompletionContext) []protocol.CompletionIte
 			return true // continue iteration
 		}
 
-		// Filter by partial path prefix (case-insensitive)
-		filePathLower := strings.ToLower(fileInfo.Path)
-		if filterLower != "" && !strings.Contains(filePathLower, filterLower) {
-			return true // continue iteration
-		}
-
-		// Create completion item
-		item := protocol.CompletionItem{
-			Label:  fileInfo.Path,
-			Kind:   protocol.CompletionItemKindFile,
-			Detail: "File",
-		}
-
-		// Insert text is just the path, then add closing bracket if needed
-		insertText := fileInfo.Path
-		if ctx.NeedsClosingBracket {
-			insertText = insertText + "]]"
+		path, err := filepath.Rel(root, indexPathToAbs(state, fileInfo.Path))
+		if err != nil {
+			path = fileInfo.Path
 		}
-		item.InsertText = insertText
-
-		items = append(items, item)
+		paths = append(paths, path)
 		return true // continue iteration
 	})
 
+	closing := ""
+	if ctx.NeedsClosingBracket {
+		closing = "]]"
+	}
+	items := fileCompletionItems(paths, ctx.FilterPrefix, "", closing)
+
 	slog.Debug("File completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
 	return items
 }
 
-// completeBlockTypes returns completion items for block types (#+begin_)
-func completeBlockTypes(ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+// fileCompletionItems builds file completion items for the paths containing
+// filter, wrapping each inserted path in opening and closing text
+func fileCompletionItems(paths []string, filter, opening, closing string) []protocol.CompletionItem {
+	var items []protocol.CompletionItem
+	filterLower := strings.ToLower(filter)
+
+	for _, path := range paths {
+		// Filter by partial path prefix (case-insensitive)
+		if filterLower != "" && !strings.Contains(strings.ToLower(path), filterLower) {
+			continue
+		}
+
+		items = append(items, protocol.CompletionItem{
+			Label:      path,
+			Kind:       protocol.CompletionItemKindFile,
+			Detail:     "File",
+			InsertText: opening + path + closing,
+		})
+	}
+	return items
+}
+
+// completeBlockTypes returns completion items for block types (#+begin_).
+// For clients with snippet support, src blocks come scaffolded with a
+// language placeholder and :results output, with tab stops for the language
+// and then the body.
+func completeBlockTypes(state *State, ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
 	blockTypes := []string{"quote", "src", "verse"}
 
 	var items []protocol.CompletionItem
 	filterLower := strings.ToLower(ctx.FilterPrefix)
 
 	// Calculate the start of "#+begin_" prefix for TextEdit range
-	// "#+begin_" is 8 characters, plus whatever filter prefix was typed
+	// "#+begin_" is 8 characters, plus whatever filter prefix was typed.
+	// The prefix keeps the case it was typed in.
 	prefixLen := 8 + len(ctx.FilterPrefix)
+	begin := "#+begin_"
+	if ctx.TypedPrefix != "" {
+		begin = ctx.TypedPrefix
+	}
 	startChar := max(int(pos.Character)-prefixLen, 0)
 
 	for _, blockType := range blockTypes {
@@ -384,7 +592,7 @@ func completeBlockTypes(ctx CompletionContext, pos protocol.Position) []protocol
 			continue
 		}
 
-		fullLabel := "#+begin_" + blockType
+		fullLabel := begin + keywordCase(begin, blockType)
 		item := protocol.CompletionItem{
 			Label:  fullLabel,
 			Kind:   protocol.CompletionItemKindKeyword,
@@ -392,7 +600,7 @@ func completeBlockTypes(ctx CompletionContext, pos protocol.Position) []protocol
 		}
 
 		// Use TextEdit to replace the entire "#+begin_XXX" prefix
-		insertText := fullLabel + "\n\n#+end_" + blockType
+		insertText := fullLabel + "\n\n" + keywordCase(begin, "#+end_"+blockType)
 		item.TextEdit = &protocol.TextEdit{
 			Range: protocol.Range{
 				Start: protocol.Position{
@@ -406,6 +614,10 @@ func completeBlockTypes(ctx CompletionContext, pos protocol.Position) []protocol
 			},
 			NewText: insertText,
 		}
+		if blockType == "src" && state.Snippets {
+			item.InsertTextFormat = protocol.InsertTextFormatSnippet
+			item.TextEdit.NewText = fullLabel + " ${1:language} :results output\n$0\n" + keywordCase(begin, "#+end_src")
+		}
 
 		items = append(items, item)
 	}
@@ -422,8 +634,13 @@ func completeExportTypes(ctx CompletionContext, pos protocol.Position) []protoco
 	filterLower := strings.ToLower(ctx.FilterPrefix)
 
 	// Calculate the start of "#+begin_export_" prefix for TextEdit range
-	// "#+begin_export_" is 15 characters, plus whatever filter prefix was typed
+	// "#+begin_export_" is 15 characters, plus whatever filter prefix was typed.
+	// The prefix keeps the case it was typed in.
 	prefixLen := 15 + len(ctx.FilterPrefix)
+	begin := "#+begin_export_"
+	if ctx.TypedPrefix != "" {
+		begin = ctx.TypedPrefix
+	}
 	startChar := max(int(pos.Character)-prefixLen, 0)
 
 	for _, exportType := range exportTypes {
@@ -432,7 +649,7 @@ func completeExportTypes(ctx CompletionContext, pos protocol.Position) []protoco
 			continue
 		}
 
-		fullLabel := "#+begin_export_" + exportType
+		fullLabel := begin + keywordCase(begin, exportType)
 		item := protocol.CompletionItem{
 			Label:  fullLabel,
 			Kind:   protocol.CompletionItemKindKeyword,
@@ -440,7 +657,7 @@ func completeExportTypes(ctx CompletionContext, pos protocol.Position) []protoco
 		}
 
 		// Use TextEdit to replace the entire "#+begin_export_XXX" prefix
-		insertText := fullLabel + "\n\n#+end_export"
+		insertText := fullLabel + "\n\n" + keywordCase(begin, "#+end_export")
 		item.TextEdit = &protocol.TextEdit{
 			Range: protocol.Range{
 				Start: protocol.Position{
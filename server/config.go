@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCodeExecutionTimeout = 10 * time.Second
+	defaultMaxCodeOutputBytes   = 64 * 1024
+	defaultTagColumn            = 77
+	defaultJournalDateLayout    = "2006-01-02"
+	defaultHoverContextLines    = 3
+)
+
+// Config holds user-tunable server settings, read from the client's
+// initializationOptions. Zero values fall back to the defaults.
+type Config struct {
+	// CodeExecutionTimeoutSeconds bounds how long a src block may run
+	CodeExecutionTimeoutSeconds float64 `json:"codeExecutionTimeoutSeconds"`
+	// MaxCodeOutputBytes caps how much src block output is captured
+	MaxCodeOutputBytes int `json:"maxCodeOutputBytes"`
+	// AllowedCodeLanguages lists the src block languages that may be
+	// executed. Empty (the default) disables code execution entirely, since
+	// opening an untrusted org file must never be enough to run its code.
+	AllowedCodeLanguages []string `json:"allowedCodeLanguages"`
+	// TangleOutsideWorkspace lets org.tangle write :tangle targets, and
+	// org.archiveSubtree its #+ARCHIVE: file, outside the workspace roots,
+	// such as ~/.bashrc. False (the default) refuses any such write whose
+	// path, after following symlinks, lands outside every root.
+	TangleOutsideWorkspace bool `json:"tangleOutsideWorkspace"`
+	// BibliographyFiles lists .bib files used for citations in every
+	// document, in addition to any #+BIBLIOGRAPHY: keywords. Relative paths
+	// are resolved against the workspace root.
+	BibliographyFiles []string `json:"bibliographyFiles"`
+	// FillColumn hard-wraps paragraphs at this column when formatting.
+	// Zero (the default) leaves line breaks as written.
+	FillColumn int `json:"fillColumn"`
+	// TagColumn is the column headline tags start at when formatting.
+	// Zero (the default) uses column 77.
+	TagColumn int `json:"tagColumn"`
+	// IndentSrcBlocks indents src and example blocks to their heading's
+	// content column (level + 1) when formatting, keeping the code's
+	// relative indentation. False (the default) leaves blocks verbatim.
+	IndentSrcBlocks bool `json:"indentSrcBlocks"`
+	// ValidateWorkspace publishes link diagnostics for every indexed file
+	// once the server is initialized and again whenever the index refreshes,
+	// not just for open documents. False (the default) keeps the problems
+	// panel limited to open files.
+	ValidateWorkspace bool `json:"validateWorkspace"`
+	// TodoKeywords is the TODO keyword sequence, in #+TODO: syntax (e.g.
+	// "TODO NEXT | DONE CANCELLED"), for documents that don't define their
+	// own. Empty (the default) uses "TODO | DONE".
+	TodoKeywords string `json:"todoKeywords"`
+	// RequireHeadingIDs reports every heading without an :ID: property as
+	// a hint, for knowledge bases where each heading should be linkable.
+	// False (the default) leaves headings without IDs alone.
+	RequireHeadingIDs bool `json:"requireHeadingIDs"`
+	// JournalDateFormat names journal files, as a Go time layout (e.g.
+	// "2006-01-02" or "20060102"). Empty (the default) uses "2006-01-02".
+	JournalDateFormat string `json:"journalDateFormat"`
+	// HoverContextLines is how many lines of the target heading link
+	// hovers and completion previews show. Zero (the default) shows 3.
+	HoverContextLines int `json:"hoverContextLines"`
+	// SubtreeStatsHover shows a headline's subtree word count, heading
+	// count, and reading time when hovering its headline line. False (the
+	// default) leaves headline hovers empty.
+	SubtreeStatsHover bool `json:"subtreeStatsHover"`
+	// LogFile writes the server's logs to this path instead of stderr,
+	// which editors often swallow. ORG_LSP_LOG_FILE overrides it. Empty
+	// (the default) logs to stderr.
+	LogFile string `json:"logFile"`
+	// LogFileMaxBytes is how large the log file grows before it is moved
+	// to LogFile.1 and a new one started. Zero (the default) uses 10 MiB.
+	LogFileMaxBytes int64 `json:"logFileMaxBytes"`
+}
+
+// AllowsCodeLanguage reports whether src blocks in lang may be executed
+func (c Config) AllowsCodeLanguage(lang string) bool {
+	return slices.ContainsFunc(c.AllowedCodeLanguages, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSpace(allowed), lang)
+	})
+}
+
+// CodeExecutionTimeout returns the configured src block timeout
+func (c Config) CodeExecutionTimeout() time.Duration {
+	if c.CodeExecutionTimeoutSeconds <= 0 {
+		return defaultCodeExecutionTimeout
+	}
+	return time.Duration(c.CodeExecutionTimeoutSeconds * float64(time.Second))
+}
+
+// CodeOutputLimit returns the configured src block output cap in bytes
+func (c Config) CodeOutputLimit() int {
+	if c.MaxCodeOutputBytes <= 0 {
+		return defaultMaxCodeOutputBytes
+	}
+	return c.MaxCodeOutputBytes
+}
+
+// TagAlignColumn returns the configured headline tag column
+func (c Config) TagAlignColumn() int {
+	if c.TagColumn <= 0 {
+		return defaultTagColumn
+	}
+	return c.TagColumn
+}
+
+// LogFileLimit returns the configured log file size before rotation
+func (c Config) LogFileLimit() int64 {
+	if c.LogFileMaxBytes <= 0 {
+		return defaultLogFileSize
+	}
+	return c.LogFileMaxBytes
+}
+
+// ContextLineCount returns the configured number of preview lines
+func (c Config) ContextLineCount() int {
+	if c.HoverContextLines <= 0 {
+		return defaultHoverContextLines
+	}
+	return c.HoverContextLines
+}
+
+// JournalDateLayout returns the configured journal file date layout
+func (c Config) JournalDateLayout() string {
+	if strings.TrimSpace(c.JournalDateFormat) == "" {
+		return defaultJournalDateLayout
+	}
+	return c.JournalDateFormat
+}
+
+// parseConfig decodes initializationOptions into a Config. The options arrive
+// as a generic JSON value, so they are round-tripped through encoding/json.
+// Malformed options are logged and ignored.
+func parseConfig(options any) Config {
+	var config Config
+	if options == nil {
+		return config
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		slog.Warn("Failed to encode initialization options", "error", err)
+		return config
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		slog.Warn("Failed to decode initialization options", "error", err)
+		return Config{}
+	}
+
+	slog.Info("Loaded configuration from initialization options", "config", config)
+	return config
+}
@@ -6,6 +6,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/alexispurslane/go-org/org"
@@ -34,15 +37,30 @@ func (s *ServerImpl) Definition(ctx context.Context, params *protocol.Definition
 		return nil, nil
 	}
 
+	// #+INCLUDE: lines aren't links, so check the raw line first
+	if location, found := includeDefinition(s.state, uri, params.Position); found {
+		return []protocol.Location{location}, nil
+	}
+
 	// Find link at cursor position using generic helper
 	linkNode, foundLink := findNodeAtPosition[org.RegularLink](doc, params.Position)
 	if !foundLink {
+		// A UUID pasted as plain text still names a heading
+		if location, found := bareUUIDDefinition(s.state, uri, params.Position); found {
+			return []protocol.Location{location}, nil
+		}
 		slog.Debug("No link node found at position", "line", params.Position.Line, "char", params.Position.Character)
 		return nil, nil
 	}
 
 	slog.Debug("Found link node", "protocol", linkNode.Protocol, "url", linkNode.URL)
 
+	// A #+LINK: abbreviation resolves like the link it expands to
+	if expanded, ok := expandLinkAbbreviation(doc, *linkNode); ok {
+		slog.Debug("Expanded link abbreviation", "url", expanded.URL)
+		linkNode = &expanded
+	}
+
 	var filePath string
 	var pos org.Position
 
@@ -53,6 +71,27 @@ func (s *ServerImpl) Definition(ctx context.Context, params *protocol.Definition
 	case "id":
 		slog.Debug("Resolving ID link", "uuid", linkNode.URL)
 		filePath, pos, err = resolveIDLink(s.state, uri, linkNode.URL)
+	case "denote":
+		slog.Debug("Resolving denote link", "url", linkNode.URL)
+		filePath, pos, err = resolveDenoteLink(s.state, linkNode.URL)
+	case "roam":
+		slog.Debug("Resolving roam link", "url", linkNode.URL)
+		filePath, pos, err = resolveRoamLink(s.state, linkNode.URL)
+	case "attachment":
+		slog.Debug("Resolving attachment link", "url", linkNode.URL)
+		filePath, pos, err = resolveAttachmentLink(doc, uri, *linkNode)
+	case "":
+		// [[(name)]] points at a (ref:name) label in one of this file's src blocks
+		if !strings.HasPrefix(linkNode.URL, "(") || !strings.HasSuffix(linkNode.URL, ")") {
+			slog.Debug("Unsupported internal link", "url", linkNode.URL)
+			return nil, nil
+		}
+		location, err := resolveCodeRefLink(s.state, uri, doc, linkNode.URL)
+		if err != nil {
+			slog.Debug("Coderef resolution failed", "error", err)
+			return nil, nil
+		}
+		return []protocol.Location{location}, nil
 	default:
 		slog.Debug("Unknown link protocol", "protocol", linkNode.Protocol)
 		return nil, nil
@@ -81,6 +120,17 @@ func (s *ServerImpl) Hover(ctx context.Context, params *protocol.HoverParams) (r
 	s.state.Mu.RLock()
 	defer s.state.Mu.RUnlock()
 
+	// Hovers are written in markdown; convert whichever one is returned
+	// for clients that only render plain text
+	defer func() {
+		if result != nil && s.state.PlainHover {
+			result.Contents = protocol.MarkupContent{
+				Kind:  protocol.PlainText,
+				Value: markdownToPlaintext(result.Contents.Value),
+			}
+		}
+	}()
+
 	uri := params.TextDocument.URI
 	doc, found := s.state.OpenDocs[uri]
 	if !found {
@@ -91,6 +141,18 @@ func (s *ServerImpl) Hover(ctx context.Context, params *protocol.HoverParams) (r
 	// Find link at cursor position
 	linkNode, foundLink := findNodeAtPosition[org.RegularLink](doc, params.Position)
 	if !foundLink {
+		if hover, found := macroHover(doc, params.Position); found {
+			return hover, nil
+		}
+		// go-org doesn't model org-cite citations, so check the raw line
+		if hover, found := citationHover(s.state, uri, params.Position); found {
+			return hover, nil
+		}
+		if s.state.Config.SubtreeStatsHover {
+			if hover, found := subtreeStatsHover(doc, params.Position); found {
+				return hover, nil
+			}
+		}
 		return nil, nil
 	}
 
@@ -99,13 +161,39 @@ func (s *ServerImpl) Hover(ctx context.Context, params *protocol.HoverParams) (r
 	var targetPos org.Position
 	var resolveErr error
 
+	// A #+LINK: abbreviation shows what it expands to, and previews its
+	// target like any other link when that's a file or heading
+	abbreviation := ""
+	if expanded, ok := expandLinkAbbreviation(doc, *linkNode); ok {
+		abbreviation = fmt.Sprintf("**%s Link**\n\nExpands to: `%s`", strings.ToUpper(linkNode.Protocol), expanded.URL)
+		linkNode = &expanded
+	}
+
 	switch linkNode.Protocol {
 	case "file":
 		filePath, targetPos, resolveErr = resolveFileLink(uri, linkNode.URL)
 	case "id":
 		filePath, targetPos, resolveErr = resolveIDLink(s.state, uri, linkNode.URL)
+	case "man", "info", "shell":
+		content, _ := externalLinkHover(*linkNode)
+		if abbreviation != "" {
+			content = abbreviation + "\n\n" + content
+		}
+		hoverRange := toProtocolRange(linkNode.Pos)
+		return &protocol.Hover{
+			Contents: protocol.MarkupContent{Kind: "markdown", Value: content},
+			Range:    &hoverRange,
+		}, nil
 	default:
-		return nil, nil
+		resolveErr = fmt.Errorf("unsupported link type %q", linkNode.Protocol)
+	}
+
+	if resolveErr != nil && abbreviation != "" {
+		hoverRange := toProtocolRange(linkNode.Pos)
+		return &protocol.Hover{
+			Contents: protocol.MarkupContent{Kind: "markdown", Value: abbreviation},
+			Range:    &hoverRange,
+		}, nil
 	}
 
 	if resolveErr != nil {
@@ -116,12 +204,24 @@ func (s *ServerImpl) Hover(ctx context.Context, params *protocol.HoverParams) (r
 
 	// Build hover content
 	content := fmt.Sprintf("**%s Link**\n\nTarget: `%s`", strings.ToUpper(linkNode.Protocol), filepath.Base(filePath))
+	if abbreviation != "" {
+		content = abbreviation + fmt.Sprintf("\n\nTarget: `%s`", filepath.Base(filePath))
+	}
 
-	// Extract context lines from target document
-	contextLines := extractContextLines(filePath, targetPos)
-	slog.Info("Context extraction result", "hasContent", contextLines != "", "length", len(contextLines))
-	if contextLines != "" {
-		content += fmt.Sprintf("\n\n```org\n%s\n```", contextLines)
+	// File links get a title/first heading preview; otherwise, or if the
+	// target has neither, extract context lines from the target document
+	preview := ""
+	if linkNode.Protocol == "file" {
+		preview = fileLinkPreview(s.state, filePath)
+	}
+	if preview != "" {
+		content += "\n\n" + preview
+	} else {
+		contextLines := extractContextLines(filePath, targetPos, s.state.Config.ContextLineCount())
+		slog.Info("Context extraction result", "hasContent", contextLines != "", "length", len(contextLines))
+		if contextLines != "" {
+			content += fmt.Sprintf("\n\n```org\n%s\n```", contextLines)
+		}
 	}
 
 	// Calculate hover range from link node
@@ -152,41 +252,86 @@ func (s *ServerImpl) References(ctx context.Context, params *protocol.ReferenceP
 	}
 
 	// First check if cursor is on an id: link (Enhanced References feature)
-	link, foundLink := findNodeAtPosition[org.RegularLink](doc, params.Position)
-	if foundLink {
-		// Check if this is an id: link
-		if linkUUID, ok := strings.CutPrefix(link.URL, "id:"); ok && linkUUID != "" {
-			slog.Debug("Found id: link at cursor, finding references", "uuid", linkUUID)
-			locations, err := findIDReferences(s.state, linkUUID)
-			if err != nil {
-				return nil, err
-			}
-			return locations, nil
+	uuid := ""
+	if link, foundLink := findNodeAtPosition[org.RegularLink](doc, params.Position); foundLink && strings.HasPrefix(link.URL, "id:") {
+		uuid = strings.TrimPrefix(link.URL, "id:")
+		slog.Debug("Found id: link at cursor, finding references", "uuid", uuid)
+	} else if headline, foundHeadline := findNodeAtPosition[org.Headline](doc, params.Position); foundHeadline {
+		// A tag's references are the headlines carrying it
+		if tag := findTagAtPosition(headline, params.Position); tag != "" {
+			slog.Debug("Found tag at cursor, finding headlines", "tag", tag)
+			return findTagReferences(s.state, tag), nil
 		}
+		// Fall back to the ID property of the headline under the cursor
+		uuid = getPropertyValue(*headline, "ID")
 	}
-
-	// Fall back to headline detection
-	headline, foundHeadline := findNodeAtPosition[org.Headline](doc, params.Position)
-	if !foundHeadline {
+	if uuid == "" {
 		return nil, nil
 	}
 
-	// Extract UUID from headline properties
-	for _, prop := range headline.Properties.Properties {
-		if prop[0] == "ID" && prop[1] != "" {
-			uuid := prop[1]
-			locations, err := findIDReferences(s.state, uuid)
-			if err != nil {
-				return nil, err
+	return findIDReferences(s.state, uuid, params.Context.IncludeDeclaration)
+}
+
+// findTagReferences returns the headline line of every indexed heading
+// carrying tag, ordered by file and line
+func findTagReferences(state *State, tag string) []protocol.Location {
+	locations := []protocol.Location{}
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return locations
+	}
+
+	for path, headlines := range state.Scanner.ProcessedFiles.TagMap[tag] {
+		absPath := indexPathToAbs(state, path)
+		lines := fileLines(state, absPath)
+		for _, pos := range headlines {
+			if pos.StartLine < len(lines) {
+				pos.EndColumn = len(strings.TrimRight(lines[pos.StartLine], "\r"))
 			}
-			return locations, nil
+			if loc, err := toProtocolLocation(absPath, pos); err == nil {
+				locations = append(locations, loc)
+			}
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].URI != locations[j].URI {
+			return locations[i].URI < locations[j].URI
 		}
+		return locations[i].Range.Start.Line < locations[j].Range.Start.Line
+	})
+	return locations
+}
+
+// idDeclarationLocation returns the headline line of the heading whose :ID:
+// property is uuid
+func idDeclarationLocation(state *State, uuid string) (protocol.Location, bool) {
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return protocol.Location{}, false
+	}
+	locInterface, found := state.Scanner.ProcessedFiles.UuidIndex.Load(orgscanner.UUID(uuid))
+	if !found {
+		return protocol.Location{}, false
+	}
+	location, ok := locInterface.(orgscanner.HeaderLocation)
+	if !ok {
+		return protocol.Location{}, false
 	}
 
-	return nil, nil
+	absPath := indexPathToAbs(state, location.FilePath)
+	line := location.Position.StartLine
+	pos := org.Position{StartLine: line, EndLine: line}
+	if lines := fileLines(state, absPath); line < len(lines) {
+		pos.EndColumn = len(strings.TrimRight(lines[line], "\r"))
+	}
+	loc, err := toProtocolLocation(absPath, pos)
+	if err != nil {
+		return protocol.Location{}, false
+	}
+	return loc, true
 }
 
-// resolveFileLink resolves a file: link to an absolute path and returns the target position
+// resolveFileLink resolves a file: link to an absolute path and returns the
+// target position: the start of the file, or the target of a ::search option
 func resolveFileLink(currentURI protocol.DocumentURI, linkURL string) (string, org.Position, error) {
 	slog.Debug("Resolving file link", "currentURI", currentURI, "linkURL", linkURL)
 
@@ -196,6 +341,9 @@ func resolveFileLink(currentURI protocol.DocumentURI, linkURL string) (string, o
 	// Remove the org-mode file: prefix
 	linkURL = strings.TrimPrefix(linkURL, "file:")
 
+	// Split off a ::search option (line number, *heading, #custom-id, or text)
+	linkURL, search, _ := strings.Cut(linkURL, "::")
+
 	// Handle tilde expansion (~ -> home directory)
 	if strings.HasPrefix(linkURL, "~/") {
 		if homeDir, err := os.UserHomeDir(); err == nil {
@@ -226,10 +374,46 @@ func resolveFileLink(currentURI protocol.DocumentURI, linkURL string) (string, o
 		EndLine:     0,
 		EndColumn:   0,
 	}
+	if search != "" {
+		pos = fileSearchPosition(linkURL, search)
+	}
 
 	return linkURL, pos, nil
 }
 
+// fileSearchPosition finds the line a file link's ::search option points at
+// in filePath: a 1-based line number (clamped to the file's length), a
+// *heading title or #custom-id, or else the first line containing the text.
+// Searches that match nothing fall back to the start of the file.
+func fileSearchPosition(filePath, search string) org.Position {
+	lines, err := readFileLines(filePath)
+	if err != nil {
+		return org.Position{}
+	}
+
+	if n, err := strconv.Atoi(search); err == nil {
+		line := min(max(n-1, 0), len(lines)-1)
+		return org.Position{StartLine: line, EndLine: line}
+	}
+
+	if strings.HasPrefix(search, "*") || strings.HasPrefix(search, "#") {
+		// Only the file's own #+TODO: is known here, not the workspace's
+		doc := orgscanner.ParseDocument(strings.Join(lines, "\n"), filePath, "")
+		if headline, found := findIncludeTarget(doc, search); found {
+			return org.Position{StartLine: headline.Pos.StartLine, EndLine: headline.Pos.StartLine}
+		}
+		return org.Position{}
+	}
+
+	search = strings.ToLower(search)
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), search) {
+			return org.Position{StartLine: i, EndLine: i}
+		}
+	}
+	return org.Position{}
+}
+
 // resolveIDLink resolves an id: link via UUID index and returns the target position
 func resolveIDLink(state *State, currentURI protocol.DocumentURI, uuid string) (string, org.Position, error) {
 	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
@@ -262,11 +446,121 @@ func resolveIDLink(state *State, currentURI protocol.DocumentURI, uuid string) (
 
 	slog.Debug("Resolved ID link path", "relativePath", location.FilePath, "absPath", absPath, "orgScanRoot", state.OrgScanRoot)
 
-	return absPath, location.Position, nil
+	return absPath, headlineTitlePosition(fileLines(state, absPath), location.Position.StartLine, location.Title), nil
+}
+
+// headlineTitlePosition returns the range of a headline's title on its line,
+// past the stars and any TODO keyword or priority, so jumping to a heading
+// lands on its title. Without a match it covers the text after the stars,
+// and without the line at all just its start.
+func headlineTitlePosition(lines []string, line int, title string) org.Position {
+	pos := org.Position{StartLine: line, EndLine: line}
+	if line < 0 || line >= len(lines) {
+		return pos
+	}
+
+	text := strings.TrimRight(lines[line], " \t\r")
+	afterStars := len(text) - len(strings.TrimLeft(strings.TrimLeft(text, "*"), " \t"))
+	pos.StartColumn, pos.EndColumn = afterStars, len(text)
+	if title = strings.TrimSpace(title); title != "" {
+		if idx := strings.Index(text[afterStars:], title); idx != -1 {
+			pos.StartColumn = afterStars + idx
+			pos.EndColumn = pos.StartColumn + len(title)
+		}
+	}
+	return pos
 }
 
-// extractContextLines extracts ±3 lines of context around the target position
-func extractContextLines(filePath string, targetPos org.Position) string {
+// bareUUIDRegexp matches a UUID written outside of link syntax
+var bareUUIDRegexp = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// bareUUIDDefinition resolves the UUID under the cursor on the raw line,
+// for ids that aren't wrapped in [[id:...]]
+func bareUUIDDefinition(state *State, uri protocol.DocumentURI, pos protocol.Position) (protocol.Location, bool) {
+	lines := strings.Split(state.RawContent[uri], "\n")
+	if int(pos.Line) >= len(lines) {
+		return protocol.Location{}, false
+	}
+
+	for _, match := range bareUUIDRegexp.FindAllStringIndex(lines[pos.Line], -1) {
+		if int(pos.Character) < match[0] || int(pos.Character) > match[1] {
+			continue
+		}
+		uuid := lines[pos.Line][match[0]:match[1]]
+		filePath, targetPos, err := resolveIDLink(state, uri, "id:"+uuid)
+		if err != nil {
+			slog.Debug("Bare UUID resolution failed", "uuid", uuid, "error", err)
+			return protocol.Location{}, false
+		}
+		location, err := toProtocolLocation(filePath, targetPos)
+		if err != nil {
+			return protocol.Location{}, false
+		}
+		return location, true
+	}
+	return protocol.Location{}, false
+}
+
+// attachIDDir is org-attach's default directory for ID-based attachments,
+// relative to the org file
+const attachIDDir = "data"
+
+// resolveAttachmentLink resolves an attachment: link against the attachment
+// directory of the headline containing it. A :DIR: property wins; otherwise
+// the directory is derived from the headline's :ID: using org-attach's
+// data/ab/cdef... layout.
+func resolveAttachmentLink(doc *org.Document, currentURI protocol.DocumentURI, link org.RegularLink) (string, org.Position, error) {
+	name := strings.TrimPrefix(link.URL, "attachment:")
+	if name == "" {
+		return "", org.Position{}, fmt.Errorf("empty attachment link")
+	}
+
+	linkPos := protocol.Position{Line: uint32(link.Pos.StartLine), Character: uint32(link.Pos.StartColumn)}
+	headline, found := findNodeAtPosition[org.Headline](doc, linkPos)
+	if !found {
+		return "", org.Position{}, fmt.Errorf("attachment link outside any heading")
+	}
+
+	attachDir, err := attachmentDir(*headline, uriToPath(string(currentURI)))
+	if err != nil {
+		return "", org.Position{}, err
+	}
+
+	if _, err := os.Stat(attachDir); err != nil {
+		return "", org.Position{}, fmt.Errorf("attachment directory not found: %w", err)
+	}
+
+	target := filepath.Join(attachDir, name)
+	slog.Debug("Resolved attachment link path", "attachDir", attachDir, "resolvedPath", target)
+	return target, org.Position{}, nil
+}
+
+// attachmentDir computes a headline's attachment directory
+func attachmentDir(headline org.Headline, currentPath string) (string, error) {
+	baseDir := filepath.Dir(currentPath)
+
+	if dir := strings.TrimSpace(getPropertyValue(headline, "DIR")); dir != "" {
+		if strings.HasPrefix(dir, "~/") {
+			if homeDir, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(homeDir, dir[2:])
+			}
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		return filepath.Clean(dir), nil
+	}
+
+	id := strings.TrimSpace(getPropertyValue(headline, "ID"))
+	if len(id) < 3 {
+		return "", fmt.Errorf("heading has no :DIR: or :ID: for attachments")
+	}
+	return filepath.Join(baseDir, attachIDDir, id[:2], id[2:]), nil
+}
+
+// extractContextLines extracts count lines of context starting at the
+// target position
+func extractContextLines(filePath string, targetPos org.Position, count int) string {
 	slog.Debug("Extracting context lines", "filePath", filePath, "targetPos", targetPos)
 
 	lines, err := readFileLines(filePath)
@@ -275,13 +569,55 @@ func extractContextLines(filePath string, targetPos org.Position) string {
 		return ""
 	}
 
-	// Calculate line range (±3 lines, 1-based to 0-based)
-	startLine := max(0, targetPos.StartLine)          // -3 lines, convert to 0-based
-	endLine := min(len(lines), targetPos.StartLine+2) // 3 lines, inclusive
+	startLine := max(0, targetPos.StartLine)
+	endLine := min(len(lines), startLine+count)
 
 	return joinLines(lines, startLine, endLine)
 }
 
+// fileLinkPreview renders the #+TITLE and first headline of the org file at
+// filePath, using the index's cached document when the file is indexed.
+// Returns "" if the file has neither.
+func fileLinkPreview(state *State, filePath string) string {
+	var doc *org.Document
+	if state.Scanner != nil && state.Scanner.ProcessedFiles != nil {
+		if relPath, err := filepath.Rel(state.OrgScanRoot, filePath); err == nil {
+			if value, ok := state.Scanner.ProcessedFiles.Files.Load(relPath); ok {
+				if fileInfo, ok := value.(*orgscanner.FileInfo); ok {
+					doc = state.Scanner.Document(fileInfo)
+				}
+			}
+		}
+	}
+	if doc == nil {
+		if !strings.HasSuffix(filePath, ".org") {
+			return ""
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return ""
+		}
+		doc = parseOrgDocument(string(data), filePath, state.Config)
+	}
+
+	var preview strings.Builder
+	if title := doc.Get("TITLE"); title != "" {
+		preview.WriteString("**" + title + "**")
+	}
+	for _, node := range doc.Nodes {
+		headline, ok := node.(org.Headline)
+		if !ok {
+			continue
+		}
+		if preview.Len() > 0 {
+			preview.WriteString("\n\n")
+		}
+		fmt.Fprintf(&preview, "```org\n%s %s\n```", strings.Repeat("*", headline.Lvl), strings.TrimSpace(org.String(headline.Title...)))
+		break
+	}
+	return preview.String()
+}
+
 // readFileLines reads a file and returns its lines
 func readFileLines(filePath string) ([]string, error) {
 	content, err := os.ReadFile(filePath)
@@ -307,53 +643,118 @@ func joinLines(lines []string, start, end int) string {
 	return context.String()
 }
 
-func findIDReferences(state *State, targetUUID string) ([]protocol.Location, error) {
+// findIDReferences returns the location of every id: link to targetUUID,
+// preceded by the declaring headline when includeDeclaration is set
+func findIDReferences(state *State, targetUUID string, includeDeclaration bool) ([]protocol.Location, error) {
 	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
 		return nil, nil
 	}
 
 	var locations []protocol.Location
+	decl, hasDecl := idDeclarationLocation(state, targetUUID)
+	if includeDeclaration && hasDecl {
+		locations = append(locations, decl)
+	}
 
 	// Walk through all processed files using sync.Map.Range
 	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
 		fileInfo, ok := value.(*orgscanner.FileInfo)
-		if !ok || fileInfo.ParsedOrg == nil {
+		if !ok {
 			return true // continue iteration
 		}
-
-		// Search for links in this file
-		var walkNodes func(node org.Node)
-		walkNodes = func(node org.Node) {
-			if link, ok := node.(org.RegularLink); ok {
-				// Check if this is an id: link
-				if linkUUID, ok0 := strings.CutPrefix(link.URL, "id:"); ok0 {
-					if linkUUID == targetUUID {
-						// Convert link position to absolute file path
-						absPath := filepath.Join(state.OrgScanRoot, fileInfo.Path)
-						absPath = filepath.Clean(absPath)
-
-						loc, err := toProtocolLocation(absPath, link.Pos)
-						if err != nil {
-							slog.Debug("Failed to convert link to protocol location", "error", err)
-							return
-						}
-						locations = append(locations, loc)
-					}
-				}
+		// Search the links indexed for this file
+		var linkPositions []org.Position
+		for _, link := range fileInfo.Links {
+			if linkUUID, ok0 := strings.CutPrefix(link.URL, "id:"); ok0 && linkUUID == targetUUID {
+				linkPositions = append(linkPositions, link.Pos)
 			}
-
-			// Walk children
-			node.Range(func(n org.Node) bool {
-				walkNodes(n)
-				return true
-			})
+		}
+		if len(linkPositions) == 0 {
+			return true // continue iteration
 		}
 
-		for _, node := range fileInfo.ParsedOrg.Nodes {
-			walkNodes(node)
+		// Convert link positions to absolute file path, snapping each one to
+		// the exact [[...]] syntax in the raw text since go-org's node
+		// positions drift inside list items and markup-heavy descriptions.
+		// Each occurrence is claimed by one link, so links go-org gives the
+		// same position, like those in one table row, stay distinct.
+		absPath := indexPathToAbs(state, fileInfo.Path)
+		lines := fileLines(state, absPath)
+		claimed := make(map[org.Position]bool, len(linkPositions))
+		for _, pos := range linkPositions {
+			pos = linkSyntaxPosition(lines, pos, "id:"+targetUUID, claimed)
+			loc, err := toProtocolLocation(absPath, pos)
+			if err != nil {
+				slog.Debug("Failed to convert link to protocol location", "error", err)
+				continue
+			}
+			if includeDeclaration && hasDecl && loc == decl {
+				continue // already listed as the declaration
+			}
+			locations = append(locations, loc)
 		}
 		return true // continue iteration
 	})
 
 	return locations, nil
 }
+
+// fileLines returns the lines of absPath, preferring the open buffer over
+// the file on disk. Returns nil if the file cannot be read.
+func fileLines(state *State, absPath string) []string {
+	if content, ok := state.RawContent[protocol.DocumentURI(pathToURI(absPath))]; ok {
+		return strings.Split(content, "\n")
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// linkSyntaxPosition narrows pos to the [[url]] or [[url][description]]
+// occurrence on its start line closest to where go-org placed the link,
+// skipping occurrences already in claimed and adding the one it picks.
+// Returns pos unchanged if there is no such occurrence.
+func linkSyntaxPosition(lines []string, pos org.Position, url string, claimed map[org.Position]bool) org.Position {
+	if pos.StartLine < 0 || pos.StartLine >= len(lines) {
+		return pos
+	}
+	line := lines[pos.StartLine]
+	best, found := pos, false
+	for offset := 0; offset < len(line); {
+		idx := strings.Index(line[offset:], "[["+url+"]")
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		offset = start + 2
+		closing := strings.Index(line[start:], "]]")
+		if closing < 0 {
+			break
+		}
+		occurrence := org.Position{
+			StartLine:   pos.StartLine,
+			StartColumn: start,
+			EndLine:     pos.StartLine,
+			EndColumn:   start + closing + 2,
+		}
+		if claimed[occurrence] {
+			continue
+		}
+		if !found || absInt(start-pos.StartColumn) < absInt(best.StartColumn-pos.StartColumn) {
+			best, found = occurrence, true
+		}
+	}
+	if found {
+		claimed[best] = true
+	}
+	return best
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// detectDenoteContext checks if cursor is in a Denote link completion context (after "[[denote:")
+func detectDenoteContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := detectPrefixContext(state, doc, uri, pos, "[[denote:", ContextTypeDenote, true)
+	ctx.FilterPrefix = strings.ToLower(ctx.FilterPrefix)
+	return ctx
+}
+
+// completeDenote returns completion items for notes with a Denote
+// identifier, matching the typed text against identifier or title
+func completeDenote(state *State, ctx CompletionContext) []protocol.CompletionItem {
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return nil
+	}
+
+	var notes []*orgscanner.FileInfo
+	state.Scanner.ProcessedFiles.Files.Range(func(_, value any) bool {
+		fileInfo, ok := value.(*orgscanner.FileInfo)
+		if !ok || fileInfo.DenoteID == "" {
+			return true
+		}
+		if ctx.FilterPrefix != "" &&
+			!strings.Contains(strings.ToLower(fileInfo.DenoteID), ctx.FilterPrefix) &&
+			!strings.Contains(strings.ToLower(fileInfo.Title), ctx.FilterPrefix) {
+			return true
+		}
+		notes = append(notes, fileInfo)
+		return true
+	})
+
+	// Newest notes first, as Denote identifiers are creation timestamps
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].DenoteID > notes[j].DenoteID
+	})
+
+	items := make([]protocol.CompletionItem, 0, len(notes))
+	for _, note := range notes {
+		insertText := note.DenoteID
+		if ctx.NeedsClosingBracket {
+			insertText += "]]"
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:      note.DenoteID,
+			Kind:       protocol.CompletionItemKindReference,
+			Detail:     note.Title,
+			FilterText: note.DenoteID + " " + note.Title,
+			InsertText: insertText,
+		})
+	}
+
+	slog.Debug("Denote completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
+
+// resolveDenoteLink resolves a denote: link to the scanned file whose name
+// carries the identifier
+func resolveDenoteLink(state *State, linkURL string) (string, org.Position, error) {
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return "", org.Position{}, fmt.Errorf("scanner not initialized")
+	}
+
+	id := strings.TrimPrefix(linkURL, "denote:")
+	if i := strings.Index(id, "::"); i != -1 {
+		id = id[:i]
+	}
+
+	var filePath string
+	state.Scanner.ProcessedFiles.Files.Range(func(_, value any) bool {
+		if fileInfo, ok := value.(*orgscanner.FileInfo); ok && fileInfo.DenoteID == id {
+			filePath = indexPathToAbs(state, fileInfo.Path)
+			return false
+		}
+		return true
+	})
+	if filePath == "" {
+		return "", org.Position{}, fmt.Errorf("denote identifier not found: %s", id)
+	}
+
+	return filePath, org.Position{}, nil
+}
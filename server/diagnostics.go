@@ -6,7 +6,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/alexispurslane/go-org/org"
 	"github.com/alexispurslane/org-lsp/orgscanner"
@@ -37,28 +41,208 @@ func PublishDiagnosticsForDocument(state *State, uri protocol.DocumentURI, doc *
 }
 
 func validateDocument(state *State, uri protocol.DocumentURI, doc *org.Document) []protocol.Diagnostic {
+	diagnostics := validateLinks(state, uri, orgscanner.Links(doc))
+
+	// go-org silently treats malformed timestamps as text, so check the raw lines
+	diagnostics = append(diagnostics, validateTimestamps(state.RawContent[uri])...)
+
+	if state.Config.RequireHeadingIDs {
+		diagnostics = append(diagnostics, validateHeadingIDs(doc, state.RawContent[uri])...)
+	}
+
+	return diagnostics
+}
+
+// missingIDDiagnosticCode identifies the hint on headings without an :ID:,
+// so the code action fixing it can be matched back to it
+const missingIDDiagnosticCode = "missing-id"
+
+// validateHeadingIDs returns a hint for every heading without an :ID:
+// property, covering its headline line
+func validateHeadingIDs(doc *org.Document, content string) []protocol.Diagnostic {
 	var diagnostics []protocol.Diagnostic
 
-	var walkNodes func(node org.Node)
-	walkNodes = func(node org.Node) {
-		if link, ok := node.(org.RegularLink); ok {
-			if d := validateLink(state, uri, link); d != nil {
-				diagnostics = append(diagnostics, *d)
-			}
+	lines := strings.Split(content, "\n")
+	for _, headline := range collectHeadlines(doc) {
+		if hasIDProperty(headline) {
+			continue
+		}
+		line := headline.Pos.StartLine
+		end := 0
+		if line < len(lines) {
+			end = len(strings.TrimRight(lines[line], " \t\r"))
 		}
-		node.Range(func(n org.Node) bool {
-			walkNodes(n)
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(line), Character: 0},
+				End:   protocol.Position{Line: uint32(line), Character: uint32(end)},
+			},
+			Severity: protocol.DiagnosticSeverityHint,
+			Code:     missingIDDiagnosticCode,
+			Message:  "Heading has no :ID: property",
+			Source:   "org-lsp",
+		})
+	}
+
+	return diagnostics
+}
+
+// validateLinks returns a diagnostic for every broken link among links
+func validateLinks(state *State, uri protocol.DocumentURI, links []org.RegularLink) []protocol.Diagnostic {
+	var diagnostics []protocol.Diagnostic
+	for _, link := range links {
+		if d := validateLink(state, uri, link); d != nil {
+			diagnostics = append(diagnostics, *d)
+		}
+	}
+	return diagnostics
+}
+
+// workspaceDiagnostics remembers which closed files validateWorkspace last
+// published problems for, so a later run can clear the ones now clean
+type workspaceDiagnostics struct {
+	mu   sync.Mutex // Serializes runs so results are published in order
+	uris map[protocol.DocumentURI]bool
+}
+
+// validateWorkspace publishes link diagnostics for every indexed file that
+// isn't open, so broken links show up before the file is visited. Open
+// documents are skipped since they already get diagnostics as they change.
+// It runs again whenever the index refreshes; closed files that had problems
+// last time and have none now get an empty list to clear them.
+func validateWorkspace(state *State) {
+	if state == nil || state.Client == nil {
+		slog.Debug("Skipping workspace diagnostics - client not available")
+		return
+	}
+
+	state.WorkspaceDiagnostics.mu.Lock()
+	defer state.WorkspaceDiagnostics.mu.Unlock()
+
+	state.Mu.RLock()
+	published := make(map[protocol.DocumentURI][]protocol.Diagnostic)
+	if state.Scanner != nil && state.Scanner.ProcessedFiles != nil {
+		state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
+			fileInfo, ok := value.(*orgscanner.FileInfo)
+			if !ok {
+				return true // continue iteration
+			}
+			uri := protocol.DocumentURI(pathToURI(indexPathToAbs(state, fileInfo.Path)))
+			if _, open := state.OpenDocs[uri]; open {
+				return true
+			}
+			if diagnostics := validateLinks(state, uri, fileInfo.Links); len(diagnostics) > 0 {
+				published[uri] = diagnostics
+			}
 			return true
 		})
 	}
 
-	for _, node := range doc.Nodes {
-		walkNodes(node)
+	// Files that had problems last run but none now, fixed or deleted while
+	// closed, are cleared; open ones are left to their own diagnostics
+	cleared := 0
+	for uri := range state.WorkspaceDiagnostics.uris {
+		if _, still := published[uri]; still {
+			continue
+		}
+		if _, open := state.OpenDocs[uri]; open {
+			continue
+		}
+		published[uri] = []protocol.Diagnostic{}
+		cleared++
+	}
+	state.Mu.RUnlock()
+
+	state.WorkspaceDiagnostics.uris = make(map[protocol.DocumentURI]bool)
+	ctx := context.Background()
+	for uri, diagnostics := range published {
+		if len(diagnostics) > 0 {
+			state.WorkspaceDiagnostics.uris[uri] = true
+		}
+		params := protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}
+		if err := state.Client.PublishDiagnostics(ctx, &params); err != nil {
+			slog.Error("Failed to publish diagnostics", "uri", uri, "error", err)
+		}
+	}
+	slog.Info("Published workspace diagnostics", "files", len(published)-cleared, "cleared", cleared)
+}
+
+// timestampLikeRegexp matches bracketed text starting with a date, which org
+// would read as an active <...> or inactive [...] timestamp
+var timestampLikeRegexp = regexp.MustCompile(`[<\[](\d{4}-\d{1,2}-\d{1,2})([^<>\[\]\n]*)[>\]]`)
+
+// timestampTimeRegexp matches a time or time range field of a timestamp
+var timestampTimeRegexp = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?:-(\d{1,2}):(\d{2}))?$`)
+
+// validateTimestamps warns about timestamp-looking text outside src and
+// example blocks whose date or time is not valid
+func validateTimestamps(content string) []protocol.Diagnostic {
+	var diagnostics []protocol.Diagnostic
+
+	inCode := false
+	for lineNum, line := range strings.Split(content, "\n") {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(lower, "#+begin_src"), strings.HasPrefix(lower, "#+begin_example"):
+			inCode = true
+			continue
+		case strings.HasPrefix(lower, "#+end_src"), strings.HasPrefix(lower, "#+end_example"):
+			inCode = false
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		for _, loc := range timestampLikeRegexp.FindAllStringSubmatchIndex(line, -1) {
+			text := line[loc[0]:loc[1]]
+			problem := timestampProblem(text, line[loc[2]:loc[3]], line[loc[4]:loc[5]])
+			if problem == "" {
+				continue
+			}
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(lineNum), Character: uint32(loc[0])},
+					End:   protocol.Position{Line: uint32(lineNum), Character: uint32(loc[1])},
+				},
+				Severity: protocol.DiagnosticSeverityWarning,
+				Message:  fmt.Sprintf("Malformed timestamp %s: %s", text, problem),
+				Source:   "org-lsp",
+			})
+		}
 	}
 
 	return diagnostics
 }
 
+// timestampProblem describes what is wrong with a timestamp, or returns ""
+// if it is valid
+func timestampProblem(text, date, rest string) string {
+	if (text[0] == '<') != (text[len(text)-1] == '>') {
+		return "mismatched brackets"
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Sprintf("invalid date %q", date)
+	}
+	for _, field := range strings.Fields(rest) {
+		match := timestampTimeRegexp.FindStringSubmatch(field)
+		if match == nil {
+			continue
+		}
+		for i := 1; i < len(match); i += 2 {
+			if match[i] == "" {
+				continue
+			}
+			hour, _ := strconv.Atoi(match[i])
+			minute, _ := strconv.Atoi(match[i+1])
+			if hour > 24 || minute > 59 {
+				return fmt.Sprintf("invalid time %q", field)
+			}
+		}
+	}
+	return ""
+}
+
 func validateLink(state *State, uri protocol.DocumentURI, link org.RegularLink) *protocol.Diagnostic {
 	switch link.Protocol {
 	case "file":
@@ -71,7 +255,7 @@ func validateLink(state *State, uri protocol.DocumentURI, link org.RegularLink)
 
 func validateFileLink(currentURI protocol.DocumentURI, link org.RegularLink) *protocol.Diagnostic {
 	currentPath := uriToPath(string(currentURI))
-	linkPath := strings.TrimPrefix(link.URL, "file:")
+	linkPath, _, _ := strings.Cut(strings.TrimPrefix(link.URL, "file:"), "::")
 
 	if strings.HasPrefix(linkPath, "~") {
 		if homeDir, err := os.UserHomeDir(); err == nil {
@@ -32,11 +32,13 @@ func (s *ServerImpl) DocumentLink(ctx context.Context, params *protocol.Document
 			// Resolve the link to get the target file URI
 			target := resolveLinkTarget(s.state, uri, link)
 
-			links = append(links, protocol.DocumentLink{
-				Range:   toProtocolRange(link.Pos),
-				Target:  target,
-				Tooltip: buildLinkTooltip(link),
-			})
+			if target != "" {
+				links = append(links, protocol.DocumentLink{
+					Range:   toProtocolRange(link.Pos),
+					Target:  target,
+					Tooltip: buildLinkTooltip(link),
+				})
+			}
 		}
 
 		// Walk children recursively
@@ -63,6 +65,11 @@ func (s *ServerImpl) DocumentLinkResolve(ctx context.Context, params *protocol.D
 
 // resolveLinkTarget resolves a RegularLink to a file:// URI for LSP clients.
 func resolveLinkTarget(state *State, currentURI protocol.DocumentURI, link org.RegularLink) protocol.DocumentURI {
+	// #+LINK: abbreviations open what they expand to
+	if expanded, ok := expandLinkAbbreviation(state.OpenDocs[currentURI], link); ok {
+		link = expanded
+	}
+
 	switch link.Protocol {
 	case "file":
 		// Use existing resolveFileLink from definitions.go
@@ -84,10 +91,22 @@ func resolveLinkTarget(state *State, currentURI protocol.DocumentURI, link org.R
 		// Convert absolute path to file:// URI
 		return protocol.DocumentURI(pathToURI(filePath))
 
+	case "attachment":
+		filePath, _, err := resolveAttachmentLink(state.OpenDocs[currentURI], currentURI, link)
+		if err != nil {
+			// Leave unresolvable attachments pointing at their raw URL
+			return protocol.DocumentURI(link.URL)
+		}
+		return protocol.DocumentURI(pathToURI(filePath))
+
 	case "http", "https":
 		// Return web URLs as-is
 		return protocol.DocumentURI(link.URL)
 
+	case "shell":
+		// Never make shell links clickable, so a stray click can't run one
+		return ""
+
 	default:
 		// For other protocols or no protocol, return as-is
 		if link.Protocol != "" {
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// orgEntities maps org entity names (written \name) to the glyph they render
+// as. This is a commonly used subset of org-entities.
+var orgEntities = map[string]string{
+	// Greek letters
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ɸ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+
+	// Arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"leftrightarrow": "↔", "uparrow": "↑", "downarrow": "↓",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔",
+	"mapsto": "↦", "hookleftarrow": "↵",
+
+	// Math and logic
+	"times": "×", "div": "÷", "pm": "±", "mp": "∓", "cdot": "⋅",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "cong": "≅", "propto": "∝",
+	"infin": "∞", "infty": "∞", "partial": "∂", "nabla": "∇", "sum": "∑",
+	"prod": "∏", "int": "∫", "sqrt": "√", "forall": "∀", "exists": "∃",
+	"empty": "∅", "emptyset": "∅", "in": "∈", "notin": "∉", "ni": "∋",
+	"sub": "⊂", "subset": "⊂", "sup": "⊃", "supset": "⊃", "sube": "⊆",
+	"subseteq": "⊆", "supe": "⊇", "supseteq": "⊇", "cap": "∩", "cup": "∪",
+	"and": "∧", "wedge": "∧", "or": "∨", "vee": "∨", "neg": "¬", "lnot": "¬",
+	"therefore": "∴", "because": "∵", "perp": "⊥", "angle": "∠",
+	"deg": "°", "prime": "′", "Prime": "″",
+
+	// Punctuation and typography
+	"nbsp": " ", "ndash": "–", "mdash": "—", "hellip": "…", "dots": "…",
+	"laquo": "«", "raquo": "»", "lsquo": "‘", "rsquo": "’", "ldquo": "“",
+	"rdquo": "”", "dagger": "†", "Dagger": "‡", "bull": "•", "bullet": "•",
+	"middot": "·", "para": "¶", "sect": "§", "shy": "­",
+	"iexcl": "¡", "iquest": "¿",
+
+	// Symbols
+	"copy": "©", "reg": "®", "trade": "™", "euro": "€", "pound": "£",
+	"yen": "¥", "cent": "¢", "star": "⋆", "checkmark": "✓", "smiley": "☺",
+	"frac12": "½", "frac14": "¼", "frac34": "¾", "sup2": "²", "sup3": "³",
+	"micro": "µ", "ell": "ℓ", "hbar": "ℏ", "aleph": "ℵ",
+}
+
+// entityPrefixRegexp matches a backslash followed by a partial entity name
+// at the end of the text before the cursor
+var entityPrefixRegexp = regexp.MustCompile(`\\([A-Za-z]*)$`)
+
+// detectEntityContext checks if cursor is on an entity name (after "\").
+// Backslashes inside src and example blocks are code, not entities.
+func detectEntityContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	if block, found := findNodeAtPosition[org.Block](doc, pos); found {
+		if name := strings.ToLower(block.Name); name == "src" || name == "example" {
+			return ctx
+		}
+	}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+
+	match := entityPrefixRegexp.FindStringSubmatch(line[:pos.Character])
+	if match == nil {
+		return ctx
+	}
+
+	ctx.Type = ContextTypeEntity
+	ctx.FilterPrefix = match[1]
+	return ctx
+}
+
+// completeEntities returns completion items for org entities matching the
+// typed prefix. Matching is case-sensitive, since \Delta and \delta differ.
+func completeEntities(ctx CompletionContext) []protocol.CompletionItem {
+	var names []string
+	for name := range orgEntities {
+		if strings.HasPrefix(name, ctx.FilterPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]protocol.CompletionItem, 0, len(names))
+	for _, name := range names {
+		items = append(items, protocol.CompletionItem{
+			Label:      "\\" + name,
+			Kind:       protocol.CompletionItemKindText,
+			Detail:     orgEntities[name],
+			FilterText: name,
+			InsertText: name,
+		})
+	}
+
+	slog.Debug("Entity completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
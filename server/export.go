@@ -0,0 +1,341 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// markdownEmphasis maps org emphasis kinds to their Markdown delimiters.
+// Kinds missing here (underline) have no Markdown equivalent and are
+// exported as plain text.
+var markdownEmphasis = map[string][2]string{
+	"*":   {"**", "**"},
+	"/":   {"*", "*"},
+	"+":   {"~~", "~~"},
+	"~":   {"`", "`"},
+	"=":   {"`", "`"},
+	"_{}": {"<sub>", "</sub>"},
+	"^{}": {"<sup>", "</sup>"},
+}
+
+// exportMarkdownCommand renders the document given by [uri] to Markdown and
+// returns the text. The document is read from disk if it isn't open.
+func (s *ServerImpl) exportMarkdownCommand(ctx context.Context, args []any) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("expected [uri] argument, got %d", len(args))
+	}
+	uri, ok := args[0].(string)
+	if !ok || uri == "" {
+		return nil, fmt.Errorf("invalid uri argument: %v", args[0])
+	}
+
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	doc, _, err := documentForURI(s.state, protocol.DocumentURI(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	exporter := markdownExporter{state: s.state, uri: protocol.DocumentURI(uri)}
+	markdown := exporter.blocks(doc.Nodes, "\n\n") + "\n"
+	slog.Debug("Exported document to Markdown", "uri", uri, "bytes", len(markdown))
+	return markdown, nil
+}
+
+// markdownExporter renders an org document's AST as Markdown. Links are
+// resolved relative to the document at uri.
+type markdownExporter struct {
+	state *State
+	uri   protocol.DocumentURI
+}
+
+// blocks renders block-level nodes, joining the non-empty results with sep
+func (e markdownExporter) blocks(nodes []org.Node, sep string) string {
+	var parts []string
+	for _, node := range nodes {
+		if text := e.block(node); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// block renders a single block-level node. Drawers, keywords, and comments
+// are metadata and produce nothing.
+func (e markdownExporter) block(node org.Node) string {
+	switch n := node.(type) {
+	case org.Headline:
+		var heading strings.Builder
+		heading.WriteString(strings.Repeat("#", min(n.Lvl, 6)))
+		heading.WriteString(" ")
+		if n.Status != "" {
+			heading.WriteString(n.Status + " ")
+		}
+		heading.WriteString(strings.TrimSpace(e.inline(n.Title)))
+		if body := e.blocks(n.Children, "\n\n"); body != "" {
+			return heading.String() + "\n\n" + body
+		}
+		return heading.String()
+	case org.Paragraph:
+		return strings.TrimSpace(e.inline(n.Children))
+	case org.List:
+		return e.list(n)
+	case org.Block:
+		return e.codeBlock(n)
+	case org.Example:
+		return "```\n" + strings.TrimRight(org.String(n.Children...), "\n") + "\n```"
+	case org.LatexBlock:
+		return "$$\n" + strings.TrimSpace(org.String(n.Content...)) + "\n$$"
+	case org.Table:
+		return e.table(n)
+	case org.HorizontalRule:
+		return "---"
+	case org.FootnoteDefinition:
+		return "[^" + n.Name + "]: " + e.blocks(n.Children, " ")
+	case org.NodeWithMeta:
+		return e.block(n.Node)
+	case org.NodeWithName:
+		return e.block(n.Node)
+	case org.Drawer, org.PropertyDrawer, org.Keyword, org.Comment, org.Include:
+		return ""
+	default:
+		return strings.TrimSpace(e.inline([]org.Node{node}))
+	}
+}
+
+// codeBlock renders src and example blocks as fenced code, quotes as
+// blockquotes, and the contents of any other block as-is
+func (e markdownExporter) codeBlock(block org.Block) string {
+	switch strings.ToUpper(block.Name) {
+	case "SRC":
+		lang := blockLanguage(block)
+		if lang == "unknown" {
+			lang = ""
+		}
+		return "```" + lang + "\n" + strings.TrimRight(org.String(block.Children...), "\n") + "\n```"
+	case "EXAMPLE", "VERSE":
+		return "```\n" + strings.TrimRight(org.String(block.Children...), "\n") + "\n```"
+	case "QUOTE":
+		lines := strings.Split(e.blocks(block.Children, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return e.blocks(block.Children, "\n\n")
+	}
+}
+
+// list renders a list with "-" or numbered bullets, indenting each item's
+// continuation lines and nested lists under its bullet
+func (e markdownExporter) list(list org.List) string {
+	var items []string
+	number := 1
+	for _, node := range list.Items {
+		var bullet, body string
+		switch item := node.(type) {
+		case org.ListItem:
+			bullet = "- "
+			if list.Kind == org.OrderedList {
+				if value, err := strconv.Atoi(item.Value); err == nil {
+					number = value
+				}
+				bullet = strconv.Itoa(number) + ". "
+				number++
+			}
+			body = e.blocks(item.Children, "\n")
+			switch item.Status {
+			case " ":
+				body = "[ ] " + body
+			case "X", "-":
+				body = "[x] " + body
+			}
+		case org.DescriptiveListItem:
+			bullet = "- "
+			body = "**" + strings.TrimSpace(e.inline(item.Term)) + "**: " + e.blocks(item.Details, "\n")
+		default:
+			continue
+		}
+
+		lines := strings.Split(body, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(bullet)) + lines[i]
+			}
+		}
+		items = append(items, bullet+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a pipe table, treating the first row as the header since
+// Markdown tables require one
+func (e markdownExporter) table(table org.Table) string {
+	var rows [][]string
+	for _, row := range table.Rows {
+		if row.IsSpecial || len(row.Columns) == 0 {
+			continue
+		}
+		cells := make([]string, len(row.Columns))
+		for i, column := range row.Columns {
+			cells[i] = strings.ReplaceAll(strings.TrimSpace(e.inline(column.Children)), "|", `\|`)
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", len(cells)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline renders inline nodes. Nodes without a Markdown counterpart
+// (timestamps, macros, LaTeX fragments) are kept in their org syntax.
+func (e markdownExporter) inline(nodes []org.Node) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case org.Text:
+			builder.WriteString(n.Content)
+		case org.LineBreak:
+			builder.WriteString("\n")
+		case org.ExplicitLineBreak:
+			builder.WriteString("  \n")
+		case org.Emphasis:
+			content := e.inline(n.Content)
+			if delims, ok := markdownEmphasis[n.Kind]; ok {
+				content = delims[0] + content + delims[1]
+			}
+			builder.WriteString(content)
+		case org.InlineBlock:
+			builder.WriteString("`" + org.String(n.Children...) + "`")
+		case org.RegularLink:
+			builder.WriteString(e.link(n))
+		case org.StatisticToken:
+			builder.WriteString("[" + n.Content + "]")
+		case org.FootnoteLink:
+			builder.WriteString("[^" + n.Name + "]")
+		case org.Paragraph:
+			builder.WriteString(e.inline(n.Children))
+		default:
+			builder.WriteString(org.String(node))
+		}
+	}
+	return builder.String()
+}
+
+// link renders a link, pointing id: and file: links at the exported .md
+// files relative to this document and *Heading links at heading anchors
+func (e markdownExporter) link(link org.RegularLink) string {
+	target := link.URL
+	text := strings.TrimSpace(e.inline(link.Description))
+
+	switch link.Protocol {
+	case "id":
+		if path, title, ok := e.idLinkTarget(strings.TrimPrefix(link.URL, "id:")); ok {
+			target = path + "#" + markdownSlug(title)
+			if text == "" {
+				text = title
+			}
+		}
+	case "file":
+		path, search, _ := strings.Cut(strings.TrimPrefix(link.URL, "file:"), "::")
+		if absPath, _, err := resolveFileLink(e.uri, path); err == nil {
+			target = e.relativeMarkdownPath(absPath)
+		}
+		if heading, ok := strings.CutPrefix(search, "*"); ok {
+			target += "#" + markdownSlug(heading)
+		}
+		if text == "" && isImagePath(path) {
+			return "![](" + target + ")"
+		}
+	case "":
+		if heading, ok := strings.CutPrefix(link.URL, "*"); ok {
+			target = "#" + markdownSlug(heading)
+			if text == "" {
+				text = heading
+			}
+		}
+	}
+
+	if text == "" {
+		text = link.URL
+	}
+	return "[" + text + "](" + target + ")"
+}
+
+// idLinkTarget returns the Markdown path and heading title of the indexed
+// heading with uuid; the path is empty for headings in this document
+func (e markdownExporter) idLinkTarget(uuid string) (string, string, bool) {
+	if e.state.Scanner == nil || e.state.Scanner.ProcessedFiles == nil {
+		return "", "", false
+	}
+	value, found := e.state.Scanner.ProcessedFiles.UuidIndex.Load(orgscanner.UUID(uuid))
+	if !found {
+		return "", "", false
+	}
+	location, ok := value.(orgscanner.HeaderLocation)
+	if !ok {
+		return "", "", false
+	}
+
+	absPath := indexPathToAbs(e.state, location.FilePath)
+	if absPath == filepath.Clean(uriToPath(string(e.uri))) {
+		return "", location.Title, true
+	}
+	return e.relativeMarkdownPath(absPath), location.Title, true
+}
+
+// relativeMarkdownPath returns absPath relative to this document, with an
+// .org extension replaced by .md
+func (e markdownExporter) relativeMarkdownPath(absPath string) string {
+	path, err := filepath.Rel(filepath.Dir(uriToPath(string(e.uri))), absPath)
+	if err != nil {
+		path = absPath
+	}
+	if strings.EqualFold(filepath.Ext(path), ".org") {
+		path = strings.TrimSuffix(path, filepath.Ext(path)) + ".md"
+	}
+	return filepath.ToSlash(path)
+}
+
+// isImagePath reports whether path has a common image extension
+func isImagePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp":
+		return true
+	}
+	return false
+}
+
+// markdownSlug turns a heading title into the anchor most Markdown renderers
+// generate for it: lowercase, spaces to hyphens, punctuation dropped
+func markdownSlug(title string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			builder.WriteRune(r)
+		case unicode.IsSpace(r):
+			builder.WriteRune('-')
+		}
+	}
+	return builder.String()
+}
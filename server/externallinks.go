@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+)
+
+// manSectionPattern matches a man page name with its section, as in printf(3)
+var manSectionPattern = regexp.MustCompile(`^(.+)\(([0-9a-zA-Z]+)\)$`)
+
+// externalLinkHover describes a man:, info:, or shell: link, which org-lsp
+// can't open itself, with the command that would. Shell links are only ever
+// shown, never run.
+func externalLinkHover(link org.RegularLink) (string, bool) {
+	target := strings.TrimPrefix(link.URL, link.Protocol+":")
+	switch link.Protocol {
+	case "man":
+		// org-man links may carry a search string after ::
+		page, _, _ := strings.Cut(target, "::")
+		command := "man " + page
+		if m := manSectionPattern.FindStringSubmatch(page); m != nil {
+			command = fmt.Sprintf("man %s %s", m[2], m[1])
+		}
+		return fmt.Sprintf("**MAN Link**\n\nManual page: `%s`\n\nOpen with `%s`", page, command), true
+	case "info":
+		// info:emacs#Top names the Top node of the emacs manual
+		manual, node, hasNode := strings.Cut(target, "#")
+		content := fmt.Sprintf("**INFO Link**\n\nInfo manual: `%s`", manual)
+		command := "info " + manual
+		if hasNode && node != "" {
+			content += fmt.Sprintf("\n\nNode: `%s`", node)
+			command = fmt.Sprintf("info '(%s)%s'", manual, node)
+		}
+		return content + fmt.Sprintf("\n\nOpen with `%s`", command), true
+	case "shell":
+		return fmt.Sprintf("**SHELL Link**\n\nRuns:\n\n```sh\n%s\n```\n\norg-lsp never runs shell links; follow it from your editor if you trust it.", target), true
+	}
+	return "", false
+}
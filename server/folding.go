@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"strings"
 
 	"github.com/alexispurslane/go-org/org"
 	"go.lsp.dev/protocol"
@@ -10,7 +11,8 @@ import (
 // FoldingRanges implements textDocument/foldingRange.
 //
 // Returns foldable regions for headings, blocks, and drawers in the document.
-// Headings use Comment kind, blocks and drawers use Region kind.
+// Headings, :LOGBOOK: and custom drawers use Region kind; property drawers
+// use Comment kind and blocks use Imports kind.
 func (s *ServerImpl) FoldingRanges(ctx context.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
 	if s.state == nil {
 		return nil, nil
@@ -24,20 +26,22 @@ func (s *ServerImpl) FoldingRanges(ctx context.Context, params *protocol.Folding
 		return nil, nil
 	}
 
-	return findFoldingRanges(doc), nil
+	lines := strings.Split(s.state.RawContent[params.TextDocument.URI], "\n")
+	return findFoldingRanges(doc, lines), nil
 }
 
 // findFoldingRanges extracts all collapsible regions from an org document.
 //
-// Uses go-org's Position() method which returns StartLine/EndLine covering
-// the full extent of each node. For headings, EndLine extends through the
-// entire section. For blocks and drawers, EndLine is the closing delimiter.
-func findFoldingRanges(doc *org.Document) []protocol.FoldingRange {
-	return collectSectionFoldingRanges(doc.Outline.Children)
+// Headings fold through their whole subtree, found from lines, up to its
+// last non-blank line. For blocks and property drawers go-org's Position()
+// EndLine is the closing delimiter. go-org doesn't record EndLine for named
+// drawers, so lines is used to find their :END: instead.
+func findFoldingRanges(doc *org.Document, lines []string) []protocol.FoldingRange {
+	return collectSectionFoldingRanges(doc.Outline.Children, lines)
 }
 
 // collectSectionFoldingRanges recursively collects folding ranges from sections.
-func collectSectionFoldingRanges(sections []*org.Section) []protocol.FoldingRange {
+func collectSectionFoldingRanges(sections []*org.Section, lines []string) []protocol.FoldingRange {
 	var ranges []protocol.FoldingRange
 
 	for _, section := range sections {
@@ -47,11 +51,12 @@ func collectSectionFoldingRanges(sections []*org.Section) []protocol.FoldingRang
 
 		// Add heading fold
 		pos := section.Headline.Position()
-		if pos.EndLine > pos.StartLine {
+		endLine := headingFoldEndLine(lines, pos.StartLine, section.Headline.Lvl)
+		if endLine > pos.StartLine {
 			kind := protocol.RegionFoldingRange
 			ranges = append(ranges, protocol.FoldingRange{
 				StartLine: uint32(pos.StartLine), // Skip heading line itself
-				EndLine:   uint32(pos.EndLine),
+				EndLine:   uint32(endLine),
 				Kind:      kind,
 			})
 		}
@@ -66,7 +71,8 @@ func collectSectionFoldingRanges(sections []*org.Section) []protocol.FoldingRang
 			})
 		}
 
-		// Walk children of this headline for blocks and regular drawers
+		// Walk children of this headline for blocks and named drawers
+		// (:LOGBOOK:, :NOTES:, ...)
 		section.Headline.Range(func(node org.Node) bool {
 			switch n := node.(type) {
 			case org.Block:
@@ -78,18 +84,47 @@ func collectSectionFoldingRanges(sections []*org.Section) []protocol.FoldingRang
 				})
 			case org.Drawer:
 				pos := n.Position()
+				endLine := drawerEndLine(lines, pos.StartLine)
+				if endLine <= pos.StartLine {
+					return true
+				}
 				ranges = append(ranges, protocol.FoldingRange{
 					StartLine: uint32(pos.StartLine),
-					EndLine:   uint32(pos.EndLine),
-					Kind:      protocol.CommentFoldingRange,
+					EndLine:   uint32(endLine),
+					Kind:      protocol.RegionFoldingRange,
 				})
 			}
 			return true
 		})
 
 		// Recurse into subsections and append their ranges
-		ranges = append(ranges, collectSectionFoldingRanges(section.Children)...)
+		ranges = append(ranges, collectSectionFoldingRanges(section.Children, lines)...)
 	}
 
 	return ranges
 }
+
+// headingFoldEndLine returns the last non-blank line of the subtree of the
+// headline at startLine, which runs to the next headline of the same or a
+// higher level or the end of the file
+func headingFoldEndLine(lines []string, startLine, level int) int {
+	end := subtreeEndLine(lines, startLine, level) - 1
+	for end > startLine && strings.TrimSpace(lines[end]) == "" {
+		end--
+	}
+	return end
+}
+
+// drawerEndLine returns the line of the :END: closing the drawer opened at
+// startLine, or startLine if the drawer is unterminated
+func drawerEndLine(lines []string, startLine int) int {
+	for i := startLine + 1; i < len(lines); i++ {
+		if isHeadlineLine(lines[i]) {
+			break
+		}
+		if strings.EqualFold(strings.TrimSpace(lines[i]), ":END:") {
+			return i
+		}
+	}
+	return startLine
+}
@@ -6,9 +6,12 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log/slog"
+	"math"
 	"reflect"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/alexispurslane/go-org/org"
 	protocol "go.lsp.dev/protocol"
@@ -35,10 +38,10 @@ func (s *ServerImpl) Formatting(ctx context.Context, params *protocol.DocumentFo
 	}
 
 	// Parse the document
-	doc := org.New().Parse(strings.NewReader(content), string(uri))
+	doc := parseOrgDocument(content, string(uri), s.state.Config)
 
 	// Format the AST recursively
-	formattedNodes := formatNodes(doc.Nodes)
+	formattedNodes := formatNodes(doc.Nodes, s.state.Config)
 
 	// Serialize the formatted AST back to string
 	output := org.String(formattedNodes...)
@@ -46,18 +49,20 @@ func (s *ServerImpl) Formatting(ctx context.Context, params *protocol.DocumentFo
 	// Post-process to fix planning directive indentation
 	// The go-org serializer applies default indentation, so we need to override it
 	output = fixPlanningDirectiveIndentation(output)
+	output = alignHeadlineTags(output, s.state.Config.TagAlignColumn())
+	if s.state.Config.IndentSrcBlocks {
+		output = indentCodeBlocks(output)
+	}
 
-	// Return a single text edit that replaces the entire document
-	edit := protocol.TextEdit{
-		Range: protocol.Range{
-			Start: protocol.Position{Line: 0, Character: 0},
-			End:   getEndPosition(content),
-		},
-		NewText: output,
+	// Only send the lines that changed, so cursors and folds elsewhere in
+	// the buffer stay put
+	edits := lineDiffEdits(content, output)
+	for i := range edits {
+		edits[i].NewText = clientText(s.state, uri, edits[i].NewText)
 	}
 
-	slog.Info("Document formatted", "uri", uri)
-	return []protocol.TextEdit{edit}, nil
+	slog.Info("Document formatted", "uri", uri, "edits", len(edits))
+	return edits, nil
 }
 
 // WillSaveWaitUntil handles textDocument/willSaveWaitUntil requests for format-on-save
@@ -86,72 +91,30 @@ func (s *ServerImpl) RangeFormatting(ctx context.Context, params *protocol.Docum
 	}
 
 	// Parse and format the entire document to get proper context
-	doc := org.New().Parse(strings.NewReader(content), string(uri))
-	formattedNodes := formatNodes(doc.Nodes)
+	doc := parseOrgDocument(content, string(uri), s.state.Config)
+	formattedNodes := formatNodes(doc.Nodes, s.state.Config)
 	fullFormatted := org.String(formattedNodes...)
+	fullFormatted = alignHeadlineTags(fullFormatted, s.state.Config.TagAlignColumn())
+	if s.state.Config.IndentSrcBlocks {
+		fullFormatted = indentCodeBlocks(fullFormatted)
+	}
 
-	// Split original and formatted into lines
-	originalLines := strings.Split(content, "\n")
-	formattedLines := strings.Split(fullFormatted, "\n")
-
-	// Calculate line range bounds
+	// A selection ending at the start of a line doesn't include that line
 	startLine := int(params.Range.Start.Line)
 	endLine := int(params.Range.End.Line)
-
-	// Clamp to valid line range
-	if startLine < 0 {
-		startLine = 0
-	}
-	if endLine >= len(originalLines) {
-		endLine = len(originalLines) - 1
-	}
-	if startLine > endLine {
-		return nil, nil
-	}
-
-	// Ensure we have enough formatted lines
-	if endLine >= len(formattedLines) {
-		endLine = len(formattedLines) - 1
-	}
-	if startLine > endLine {
-		return nil, nil
-	}
-
-	// Extract the formatted content for the specified range
-	rangeFormattedLines := formattedLines[startLine : endLine+1]
-	rangeFormatted := strings.Join(rangeFormattedLines, "\n")
-
-	// Ensure trailing newline matches original if present
-	if strings.HasSuffix(content, "\n") && !strings.HasSuffix(rangeFormatted, "\n") && len(rangeFormatted) > 0 {
-		rangeFormatted += "\n"
+	if params.Range.End.Character == 0 && endLine > startLine {
+		endLine--
 	}
 
-	// Calculate character positions
-	startChar := int(params.Range.Start.Character)
-	endChar := int(params.Range.End.Character)
-
-	// Clamp end character for original content
-	if endLine < len(originalLines) && endChar > len(originalLines[endLine]) {
-		endChar = len(originalLines[endLine])
+	// Only send the changes to lines inside the range, and only those
+	// lines that actually changed
+	edits := lineRangeDiffEdits(content, fullFormatted, startLine, endLine)
+	for i := range edits {
+		edits[i].NewText = clientText(s.state, uri, edits[i].NewText)
 	}
 
-	// Create the edit for the range
-	edit := protocol.TextEdit{
-		Range: protocol.Range{
-			Start: protocol.Position{
-				Line:      uint32(startLine),
-				Character: uint32(startChar),
-			},
-			End: protocol.Position{
-				Line:      uint32(endLine),
-				Character: uint32(endChar),
-			},
-		},
-		NewText: rangeFormatted,
-	}
-
-	slog.Info("Range formatted", "uri", uri, "lines", fmt.Sprintf("%d-%d", startLine, endLine))
-	return []protocol.TextEdit{edit}, nil
+	slog.Info("Range formatted", "uri", uri, "lines", fmt.Sprintf("%d-%d", startLine, endLine), "edits", len(edits))
+	return edits, nil
 }
 
 // needsSpaceBefore checks if a node is an inline element that typically needs
@@ -172,7 +135,7 @@ func needsSpaceBefore(n org.Node) bool {
 // - Consolidating keywords at document level
 // - Inserting blank lines before headings
 // - Preserving trailing spaces before inline elements
-func formatNodes(nodes []org.Node) []org.Node {
+func formatNodes(nodes []org.Node, cfg Config) []org.Node {
 	if len(nodes) == 0 {
 		return nodes
 	}
@@ -184,7 +147,7 @@ func formatNodes(nodes []org.Node) []org.Node {
 	nonKeywords := make([]org.Node, 0, len(nodes))
 	for _, n := range nodes {
 		if isKeyword(n) {
-			keywords = append(keywords, formatNode(n))
+			keywords = append(keywords, formatNode(n, cfg))
 		} else {
 			nonKeywords = append(nonKeywords, n)
 		}
@@ -203,13 +166,19 @@ func formatNodes(nodes []org.Node) []org.Node {
 			continue
 		}
 
+		// Skip empty property drawers, e.g. one left behind after a planning
+		// line, which go-org doesn't attach to the headline
+		if pd, ok := n.(org.PropertyDrawer); ok && len(pd.Properties) == 0 {
+			continue
+		}
+
 		// Ensure blank line before headings (except at document start)
 		if isHeadline(n) && i > 0 {
 			result = append(result, org.Text{Content: "\n"})
 		}
 
 		// Format the individual node (which recursively formats its children)
-		formatted := formatNode(n)
+		formatted := formatNode(n, cfg)
 
 		result = append(result, formatted)
 	}
@@ -219,7 +188,7 @@ func formatNodes(nodes []org.Node) []org.Node {
 
 // formatNode processes a single node and recursively formats its children.
 // Uses reflection to find and format Children fields on any node type.
-func formatNode(n org.Node) org.Node {
+func formatNode(n org.Node, cfg Config) org.Node {
 	if n == nil {
 		return nil
 	}
@@ -230,7 +199,7 @@ func formatNode(n org.Node) org.Node {
 	case org.Headline:
 		formatted = formatHeadline(node)
 	case org.Paragraph:
-		formatted = formatParagraph(node)
+		formatted = formatParagraph(node, cfg.FillColumn)
 	case org.Table:
 		formatted = formatTable(node)
 	case org.List:
@@ -246,12 +215,12 @@ func formatNode(n org.Node) org.Node {
 	}
 
 	// Then, use reflection to recursively format any Children fields
-	return formatChildren(formatted)
+	return formatChildren(formatted, cfg)
 }
 
 // formatChildren uses reflection to find []org.Node Children fields
 // and recursively format them. Returns the node with formatted children.
-func formatChildren(n org.Node) org.Node {
+func formatChildren(n org.Node, cfg Config) org.Node {
 	if n == nil {
 		return nil
 	}
@@ -281,7 +250,7 @@ func formatChildren(n org.Node) org.Node {
 		return n
 	}
 
-	formattedChildren := formatNodes(children)
+	formattedChildren := formatNodes(children, cfg)
 
 	// Create a new node with the formatted children
 	newNode := reflect.New(v.Type()).Elem()
@@ -307,10 +276,11 @@ func formatHeadline(h org.Headline) org.Node {
 		}
 	}
 
-	// Align tags to consistent column (default: column 77, or max line length + 1)
+	// Clean up tag names; they are aligned after serialization, see alignHeadlineTags
 	h.Tags = normalizeTags(h.Tags)
 
-	// Format property drawer if present and ensure blank line after
+	// Format property drawer if present and ensure blank line after. An
+	// empty drawer never gets here: ensureHeadlineUUID has filled it already
 	hasPropertyDrawer := h.Properties != nil
 	if hasPropertyDrawer {
 		formatted := formatPropertyDrawer(*h.Properties)
@@ -334,15 +304,25 @@ func ensureHeadlineUUID(h org.Headline) org.Headline {
 		return h
 	}
 
-	newID := generateUUID()
+	return setHeadlineProperty(h, "ID", generateUUID())
+}
 
+// setHeadlineProperty appends a property to a heading's drawer, creating
+// the drawer if the heading doesn't have one yet
+func setHeadlineProperty(h org.Headline, key, value string) org.Headline {
 	if h.Properties == nil {
 		h.Properties = &org.PropertyDrawer{
 			Properties: [][]string{},
 		}
+	} else {
+		// Copy so the parsed document's drawer isn't mutated
+		h.Properties = &org.PropertyDrawer{
+			Properties: append([][]string{}, h.Properties.Properties...),
+			Pos:        h.Properties.Pos,
+		}
 	}
 
-	h.Properties.Properties = append(h.Properties.Properties, []string{"ID", newID})
+	h.Properties.Properties = append(h.Properties.Properties, []string{key, value})
 	return h
 }
 
@@ -359,8 +339,9 @@ func hasIDProperty(h org.Headline) bool {
 }
 
 // formatText removes trailing whitespace from each line and collapses
-// more than 2 consecutive blank lines to exactly 2.
-func formatParagraph(p org.Paragraph) org.Node {
+// more than 2 consecutive blank lines to exactly 2. When fillColumn is
+// positive, the paragraph is also re-wrapped to fit within it.
+func formatParagraph(p org.Paragraph, fillColumn int) org.Node {
 	// Match: [Non-Space][Marker][Non-Space]
 	stuckEmphasis := regexp.MustCompile(`([^\s])([*/=_\+])([^\s])`)
 	for i, current := range p.Children {
@@ -385,10 +366,117 @@ func formatParagraph(p org.Paragraph) org.Node {
 			textNode.Content = stuckEmphasis.ReplaceAllString(textNode.Content, "$1 $2$3")
 			p.Children[i] = textNode
 		}
+		if emphasis, isEmphasis := current.(org.Emphasis); isEmphasis {
+			p.Children[i] = normalizeEmphasis(emphasis)
+		}
+	}
+
+	if fillColumn > 0 {
+		p.Children = fillParagraph(p.Children, fillColumn)
 	}
 	return p
 }
 
+// emphasisSpacingRegexp matches a run of spaces or tabs inside emphasis
+var emphasisSpacingRegexp = regexp.MustCompile(`[ \t]+`)
+
+// normalizeEmphasis collapses stray runs of spaces inside a parsed bold,
+// italic, underline or strike-through span to one, turning "*bold   text*"
+// into "*bold text*", and does the same for emphasis nested in it. Org only
+// parses emphasis whose markers hug the text, so "/ italic /" is plain text
+// and never gets here. Code and verbatim spans are kept exactly as written.
+func normalizeEmphasis(e org.Emphasis) org.Emphasis {
+	if e.Kind == "~" || e.Kind == "=" {
+		return e
+	}
+
+	content := make([]org.Node, len(e.Content))
+	for i, n := range e.Content {
+		switch n := n.(type) {
+		case org.Text:
+			n.Content = emphasisSpacingRegexp.ReplaceAllString(n.Content, " ")
+			content[i] = n
+		case org.Emphasis:
+			content[i] = normalizeEmphasis(n)
+		default:
+			content[i] = n
+		}
+	}
+	e.Content = content
+	return e
+}
+
+// fillParagraph hard-wraps paragraph children so lines stay within
+// fillColumn where possible. Soft line breaks and spaces in text are
+// re-flowed; links, emphasis, and other inline nodes are never split, and a
+// single word longer than fillColumn gets a line to itself. Blank lines and
+// explicit line breaks are kept as they are.
+func fillParagraph(children []org.Node, fillColumn int) []org.Node {
+	var result []org.Node
+	var word []org.Node // pieces of the word being built, not yet placed
+	wordWidth := 0
+	column := 0
+
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		if column > 0 && column+1+wordWidth > fillColumn {
+			result = append(result, org.Text{Content: "\n"})
+			column = 0
+		} else if column > 0 {
+			result = append(result, org.Text{Content: " "})
+			column++
+		}
+		result = append(result, word...)
+		column += wordWidth
+		word, wordWidth = nil, 0
+	}
+
+	for _, child := range children {
+		switch n := child.(type) {
+		case org.Text:
+			// Split on whitespace; each break ends the current word
+			content := n.Content
+			for content != "" {
+				end := strings.IndexFunc(content, unicode.IsSpace)
+				if end == -1 {
+					end = len(content)
+				}
+				if end > 0 {
+					word = append(word, org.Text{Content: content[:end], IsRaw: n.IsRaw})
+					wordWidth += utf8.RuneCountInString(content[:end])
+				}
+				rest := strings.TrimLeftFunc(content[end:], unicode.IsSpace)
+				if len(rest) < len(content[end:]) {
+					flushWord()
+				}
+				content = rest
+			}
+		case org.LineBreak:
+			if n.Count == 1 && column+wordWidth > 0 {
+				// Soft break inside the paragraph, re-flowed like a space
+				flushWord()
+				continue
+			}
+			flushWord()
+			result = append(result, n)
+			column = 0
+		case org.ExplicitLineBreak:
+			flushWord()
+			result = append(result, n)
+			column = 0
+		default:
+			// Inline nodes are atomic and glue to adjacent text
+			word = append(word, n)
+			wordWidth += utf8.RuneCountInString(org.String(n))
+		}
+	}
+	flushWord()
+
+	return result
+}
+
 // formatTable aligns column widths
 func formatTable(t org.Table) org.Node {
 	if len(t.Rows) == 0 {
@@ -573,22 +661,134 @@ func fixPlanningDirectiveIndentation(content string) string {
 	return strings.Join(lines, "\n")
 }
 
-// getHeadingLevel extracts the heading level from a line
-// Returns 0 if the line is not a heading
+// alignHeadlineTags post-processes the serialized content so every headline's
+// tag block starts at column, or one space after the title if it is too long
+func alignHeadlineTags(content string, column int) string {
+	lines := strings.Split(content, "\n")
+	block := ""
+	for i, line := range lines {
+		// Block content only looks like a headline; skip it whole
+		if m := blockDelimiterRegexp.FindStringSubmatch(line); m != nil {
+			name := strings.ToLower(m[2])
+			if block == "" && strings.EqualFold(m[1], "begin") {
+				block = name
+			} else if block == name && strings.EqualFold(m[1], "end") {
+				block = ""
+			}
+			continue
+		}
+		if block != "" || !isHeadlineLine(line) {
+			continue
+		}
+		loc := headlineTagsRegexp.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		title, tags := line[:loc[0]], line[loc[2]:loc[3]]
+		padding := max(column-utf8.RuneCountInString(title), 1)
+		lines[i] = title + strings.Repeat(" ", padding) + tags
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeBlockBeginRegexp and codeBlockEndRegexp match the fences of src and
+// example blocks
+var (
+	codeBlockBeginRegexp = regexp.MustCompile(`(?i)^\s*#\+begin_(src|example)\b`)
+	codeBlockEndRegexp   = regexp.MustCompile(`(?i)^\s*#\+end_(src|example)\b`)
+)
+
+// indentCodeBlocks post-processes the serialized content so src and example
+// block fences sit at their heading's content column (level + 1 spaces) and
+// the code is shifted to match, keeping its internal relative indentation
+func indentCodeBlocks(content string) string {
+	lines := strings.Split(content, "\n")
+	indent := ""
+
+	for i := 0; i < len(lines); i++ {
+		if isHeadlineLine(lines[i]) {
+			indent = strings.Repeat(" ", getHeadingLevel(lines[i])+1)
+			continue
+		}
+		if !codeBlockBeginRegexp.MatchString(lines[i]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && !codeBlockEndRegexp.MatchString(lines[end]) {
+			end++
+		}
+		if end == len(lines) {
+			break // unterminated block, leave it alone
+		}
+
+		body := lines[i+1 : end]
+		common := commonIndentation(body)
+		for j, line := range body {
+			if strings.TrimSpace(line) != "" {
+				body[j] = indent + strings.TrimPrefix(line, common)
+			}
+		}
+		lines[i] = indent + strings.TrimLeft(lines[i], " \t")
+		lines[end] = indent + strings.TrimLeft(lines[end], " \t")
+		i = end
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// commonIndentation returns the leading whitespace shared by every non-blank line
+func commonIndentation(lines []string) string {
+	common, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			common, found = lead, true
+			continue
+		}
+		for !strings.HasPrefix(lead, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	return common
+}
+
+// getHeadingLevel returns the number of stars of a headline line, or 0 if
+// line isn't one
 func getHeadingLevel(line string) int {
-	line = strings.TrimSpace(line)
-	if !strings.HasPrefix(line, "*") {
+	if !isHeadlineLine(line) {
 		return 0
 	}
-	level := 0
-	for _, ch := range line {
-		if ch == '*' {
-			level++
-		} else if ch != ' ' {
+	return len(line) - len(strings.TrimLeft(line, "*"))
+}
+
+// planningLineRegexp matches a planning line, which org only recognizes
+// directly below its headline
+var planningLineRegexp = regexp.MustCompile(`^\s*(SCHEDULED|DEADLINE|CLOSED):`)
+
+// headlineMetaLines locates the planning line and property drawer directly
+// below the headline at start. Each index is -1 when that part is missing;
+// drawerEnd is the :END: line.
+func headlineMetaLines(lines []string, start int) (planning, drawerStart, drawerEnd int) {
+	planning, drawerStart, drawerEnd = -1, -1, -1
+	i := start + 1
+	if i < len(lines) && planningLineRegexp.MatchString(lines[i]) {
+		planning = i
+		i++
+	}
+	if i >= len(lines) || !strings.EqualFold(strings.TrimSpace(lines[i]), ":PROPERTIES:") {
+		return
+	}
+	for j := i + 1; j < len(lines) && !isHeadlineLine(lines[j]); j++ {
+		if strings.EqualFold(strings.TrimSpace(lines[j]), ":END:") {
+			drawerStart, drawerEnd = i, j
 			break
 		}
 	}
-	return level
+	return
 }
 
 // formatPlanningDirectives ensures planning directives (DEADLINE, SCHEDULED, CLOCK, CLOSED)
@@ -612,3 +812,117 @@ func getEndPosition(content string) protocol.Position {
 		Character: uint32(lastLineLength),
 	}
 }
+
+// maxLineDiffCells bounds the lines(original) * lines(formatted) table
+// lineDiffEdits builds; larger changes are sent as a single edit
+const maxLineDiffCells = 1 << 22
+
+// lineDiffEdits returns the edits turning original into formatted, one per
+// run of changed lines. Edits are in document order and never overlap, as
+// LSP requires, since every range refers to the original text.
+func lineDiffEdits(original, formatted string) []protocol.TextEdit {
+	return lineRangeDiffEdits(original, formatted, 0, math.MaxInt)
+}
+
+// lineRangeDiffEdits is lineDiffEdits limited to the changes touching lines
+// first through last of original: hunks replacing any of those lines, or
+// inserting lines before one of them
+func lineRangeDiffEdits(original, formatted string, first, last int) []protocol.TextEdit {
+	if original == formatted {
+		return []protocol.TextEdit{}
+	}
+	a := strings.SplitAfter(original, "\n")
+	b := strings.SplitAfter(formatted, "\n")
+
+	// Lines shared at either end never need diffing
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	position := func(line int) protocol.Position {
+		if line >= len(a) {
+			return getEndPosition(original)
+		}
+		return protocol.Position{Line: uint32(line)}
+	}
+
+	edits := []protocol.TextEdit{}
+	for _, hunk := range diffLines(midA, midB) {
+		start, end := prefix+hunk.aStart, prefix+hunk.aEnd
+		if start > last || (end <= first && (start != end || start < first)) {
+			continue
+		}
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: position(start),
+				End:   position(end),
+			},
+			NewText: strings.Join(midB[hunk.bStart:hunk.bEnd], ""),
+		})
+	}
+	return edits
+}
+
+// lineHunk replaces lines [aStart, aEnd) of one text with lines
+// [bStart, bEnd) of another
+type lineHunk struct {
+	aStart, aEnd, bStart, bEnd int
+}
+
+// diffLines returns the hunks turning a into b, in order, using their
+// longest common subsequence of lines
+func diffLines(a, b []string) []lineHunk {
+	if len(a)*len(b) > maxLineDiffCells {
+		return []lineHunk{{0, len(a), 0, len(b)}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []lineHunk
+	var current *lineHunk
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			i++
+			j++
+			continue
+		}
+		if current == nil {
+			current = &lineHunk{aStart: i, aEnd: i, bStart: j, bEnd: j}
+		}
+		if j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+			i++
+			current.aEnd = i
+		} else {
+			j++
+			current.bEnd = j
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
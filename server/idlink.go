@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// IDLink is the result of the org.copyIdLink command
+type IDLink struct {
+	Link string                  `json:"link"`           // Ready to paste, e.g. [[id:UUID][Title]]
+	Edit *protocol.WorkspaceEdit `json:"edit,omitempty"` // Adds the :ID:, when the heading had none
+}
+
+// copyIDLinkCommand returns an id link to the heading at [uri, line, column],
+// first giving the heading an :ID: property through workspace/applyEdit if
+// it doesn't have one yet.
+func (s *ServerImpl) copyIDLinkCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, column, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.state.Mu.RLock()
+	result, err := idLinkForHeadline(s.state, uri, line, column)
+	s.state.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Edit != nil {
+		if err := s.applyWorkspaceEdit(ctx, "Org: Add ID to this heading", *result.Edit); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// idLinkForHeadline builds the id link to the heading at line and column of
+// the open document uri, along with the edit adding its ID when needed
+func idLinkForHeadline(state *State, uri protocol.DocumentURI, line, column int) (IDLink, error) {
+	doc, ok := state.OpenDocs[uri]
+	if !ok {
+		return IDLink{}, fmt.Errorf("document not open: %s", uri)
+	}
+	headline, found := findNodeAtPosition[org.Headline](doc, protocol.Position{Line: uint32(line), Character: uint32(column)})
+	if !found {
+		return IDLink{}, fmt.Errorf("no heading found at line %d", line)
+	}
+
+	var result IDLink
+	updated := ensureHeadlineUUID(*headline)
+	if !hasIDProperty(*headline) {
+		result.Edit = &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				uri: {propertyDrawerEdit(*headline, updated, state.RawContent[uri])},
+			},
+		}
+	}
+
+	title := strings.TrimSpace(org.String(headline.Title...))
+	result.Link = fmt.Sprintf("[[id:%s][%s]]", getPropertyValue(updated, "ID"), title)
+	return result, nil
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// includeLineRegexp matches an #+INCLUDE: line, capturing the quoted or bare
+// path and the remaining arguments
+var includeLineRegexp = regexp.MustCompile(`(?i)^\s*#\+include:\s+(?:"([^"]+)"|(\S+))(.*)$`)
+
+// includeLinesRegexp matches the :lines "START-END" argument of an include
+var includeLinesRegexp = regexp.MustCompile(`:lines\s+"(\d*)-(\d*)"`)
+
+// includeDefinition resolves the #+INCLUDE: directive on the cursor's line
+// to the included file, honouring ::*heading / ::#custom-id search options
+// and the :lines argument
+func includeDefinition(state *State, uri protocol.DocumentURI, pos protocol.Position) (protocol.Location, bool) {
+	lines := strings.Split(state.RawContent[uri], "\n")
+	if int(pos.Line) >= len(lines) {
+		return protocol.Location{}, false
+	}
+
+	match := includeLineRegexp.FindStringSubmatch(lines[pos.Line])
+	if match == nil {
+		return protocol.Location{}, false
+	}
+	path, args := match[1]+match[2], match[3]
+
+	search := ""
+	if i := strings.Index(path, "::"); i != -1 {
+		path, search = path[:i], path[i+2:]
+	}
+
+	filePath, targetPos, err := resolveFileLink(uri, path)
+	if err != nil {
+		slog.Debug("Include resolution failed", "path", path, "error", err)
+		return protocol.Location{}, false
+	}
+
+	if search != "" {
+		targetURI := protocol.DocumentURI(pathToURI(filePath))
+		if doc, _, err := documentForURI(state, targetURI); err == nil {
+			if headline, found := findIncludeTarget(doc, search); found {
+				targetPos = org.Position{StartLine: headline.Pos.StartLine, EndLine: headline.Pos.StartLine}
+			}
+		}
+	} else if m := includeLinesRegexp.FindStringSubmatch(args); m != nil && m[1] != "" {
+		// :lines is 1-based
+		if start, err := strconv.Atoi(m[1]); err == nil && start > 0 {
+			targetPos = org.Position{StartLine: start - 1, EndLine: start - 1}
+		}
+	}
+
+	location, err := toProtocolLocation(filePath, targetPos)
+	if err != nil {
+		return protocol.Location{}, false
+	}
+	return location, true
+}
+
+// findIncludeTarget finds the heading named by an include search option:
+// "*Title" matches a heading title and "#id" its CUSTOM_ID
+func findIncludeTarget(doc *org.Document, search string) (org.Headline, bool) {
+	for _, headline := range collectHeadlines(doc) {
+		switch {
+		case strings.HasPrefix(search, "*"):
+			if strings.TrimSpace(org.String(headline.Title...)) == strings.TrimSpace(search[1:]) {
+				return headline, true
+			}
+		case strings.HasPrefix(search, "#"):
+			if getPropertyValue(headline, "CUSTOM_ID") == search[1:] {
+				return headline, true
+			}
+		}
+	}
+	return org.Headline{}, false
+}
+
+// includePathPrefixRegexp matches an #+INCLUDE: line up to the cursor while
+// the path is being typed, capturing the opening quote and typed path
+var includePathPrefixRegexp = regexp.MustCompile(`(?i)^\s*#\+include:\s+("?)([^"\s]*)$`)
+
+// detectIncludeContext checks if cursor is on the path of an #+INCLUDE: directive
+func detectIncludeContext(state *State, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+
+	match := includePathPrefixRegexp.FindStringSubmatch(line[:pos.Character])
+	if match == nil {
+		return ctx
+	}
+
+	ctx.Type = ContextTypeInclude
+	ctx.FilterPrefix = match[2]
+	ctx.NeedsOpeningQuote = match[1] == ""
+	ctx.NeedsClosingQuote = !strings.HasPrefix(line[pos.Character:], `"`)
+	return ctx
+}
+
+// completeIncludeFiles returns completion items for every file under the
+// current workspace root, org or not, relative to the current file as
+// #+INCLUDE: resolves them, with the path quoted
+func completeIncludeFiles(state *State, uri protocol.DocumentURI, ctx CompletionContext) []protocol.CompletionItem {
+	currentPath := uriToPath(string(uri))
+	currentDir := filepath.Dir(currentPath)
+
+	var paths []string
+	for _, path := range state.IncludeFiles.list(ownerRoot(state, currentPath)) {
+		if path == currentPath {
+			continue
+		}
+		if rel, err := filepath.Rel(currentDir, path); err == nil {
+			paths = append(paths, rel)
+		}
+	}
+
+	opening, closing := "", ""
+	if ctx.NeedsOpeningQuote {
+		opening = `"`
+	}
+	if ctx.NeedsClosingQuote {
+		closing = `"`
+	}
+	items := fileCompletionItems(paths, ctx.FilterPrefix, opening, closing)
+
+	slog.Debug("Include completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
+
+// includeFileCache holds the files under each workspace root offered by
+// #+INCLUDE: completion, so the tree is walked once instead of per request.
+// It is dropped whenever the client reports a file created or deleted.
+type includeFileCache struct {
+	mu    sync.Mutex
+	files map[string][]string // Absolute file paths per root
+}
+
+// list returns the files under root, walking it if it isn't cached yet
+func (c *includeFileCache) list(root string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if files, ok := c.files[root]; ok {
+		return files
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		slog.Debug("Failed to list include candidates", "error", err)
+	}
+
+	if c.files == nil {
+		c.files = make(map[string][]string)
+	}
+	c.files[root] = files
+	return files
+}
+
+// invalidate forgets every cached walk
+func (c *includeFileCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = nil
+}
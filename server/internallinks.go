@@ -0,0 +1,70 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// linkTargetRegexp matches <<target>> and <<<radio target>>> definitions
+var linkTargetRegexp = regexp.MustCompile(`<<<?([^<>\n]+?)>>>?`)
+
+// detectInternalLinkContext checks if cursor is right after "[[" with no
+// link type typed yet, where a heading or target in this buffer can go
+func detectInternalLinkContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := detectPrefixContext(state, doc, uri, pos, "[[", ContextTypeInternalLink, true)
+	if ctx.Type == ContextTypeNone {
+		return ctx
+	}
+	// Anything with a colon is a typed link (id:, file:, https:, ...)
+	if strings.ContainsAny(ctx.FilterPrefix, ":[") {
+		return CompletionContext{Type: ContextTypeNone}
+	}
+	return ctx
+}
+
+// completeInternalLinks returns the current buffer's headlines as *Heading
+// items and its <<targets>> as plain items, each replacing whatever was
+// typed after "[["
+func completeInternalLinks(state *State, doc *org.Document, uri protocol.DocumentURI, ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	filterLower := strings.ToLower(strings.TrimPrefix(ctx.FilterPrefix, "*"))
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: uint32(max(int(pos.Character)-len(ctx.FilterPrefix), 0))},
+		End:   pos,
+	}
+	closing := ""
+	if ctx.NeedsClosingBracket {
+		closing = "]]"
+	}
+
+	var items []protocol.CompletionItem
+	seen := make(map[string]bool)
+	add := func(target, detail string, kind protocol.CompletionItemKind) {
+		if seen[target] || !strings.Contains(strings.ToLower(target), filterLower) {
+			return
+		}
+		seen[target] = true
+		items = append(items, protocol.CompletionItem{
+			Label:      target,
+			Kind:       kind,
+			Detail:     detail,
+			FilterText: target,
+			TextEdit: &protocol.TextEdit{
+				Range:   editRange,
+				NewText: target + closing,
+			},
+		})
+	}
+
+	for _, headline := range collectHeadlines(doc) {
+		if title := strings.TrimSpace(org.String(headline.Title...)); title != "" {
+			add("*"+title, "Heading", protocol.CompletionItemKindReference)
+		}
+	}
+	for _, match := range linkTargetRegexp.FindAllStringSubmatch(state.RawContent[uri], -1) {
+		add(strings.TrimSpace(match[1]), "Target", protocol.CompletionItemKindReference)
+	}
+	return items
+}
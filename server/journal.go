@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// insertJournalLinkCommand inserts a link to today's journal file at
+// [uri, line, column], creating the file next to the document if it doesn't
+// exist yet. The journal file is named after today's date in the configured
+// format, and the link's description is the date itself.
+func (s *ServerImpl) insertJournalLinkCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, column, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.state.Mu.RLock()
+	date := time.Now().Format(s.state.Config.JournalDateLayout())
+	s.state.Mu.RUnlock()
+
+	path := filepath.Join(filepath.Dir(uriToPath(string(uri))), date+".org")
+	if err := createJournalFile(path, date); err != nil {
+		return nil, err
+	}
+
+	at := protocol.Position{Line: uint32(line), Character: uint32(column)}
+	edit := &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			uri: {{
+				Range:   protocol.Range{Start: at, End: at},
+				NewText: fmt.Sprintf("[[file:%s.org][%s]]", date, date),
+			}},
+		},
+	}
+
+	if err := s.applyWorkspaceEdit(ctx, "Org: Insert journal link", *edit); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Inserted journal link", "uri", uri, "journal", path)
+	return edit, nil
+}
+
+// createJournalFile writes a new journal file titled with its date, leaving
+// an existing one untouched
+func createJournalFile(path, date string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create journal file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "#+TITLE: %s\n", date); err != nil {
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+	return nil
+}
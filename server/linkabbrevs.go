@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// expandLinkAbbreviation expands a link whose type is an abbreviation
+// defined by a #+LINK: keyword, as in "#+LINK: gh https://github.com/%s",
+// into the link it stands for. The text after the abbreviation replaces %s
+// in the template, or %h URL-encoded, or is appended if the template has
+// neither. The result keeps the original link's position and description.
+func expandLinkAbbreviation(doc *org.Document, link org.RegularLink) (org.RegularLink, bool) {
+	if doc == nil || len(doc.Links) == 0 {
+		return link, false
+	}
+
+	var expanded string
+	if template, ok := doc.Links[link.Protocol]; ok && link.Protocol != "" {
+		tag := strings.TrimPrefix(link.URL, link.Protocol+":")
+		if strings.Contains(template, "%s") || strings.Contains(template, "%h") {
+			// One pass, so a tag containing "%h" isn't substituted again
+			expanded = strings.NewReplacer("%s", tag, "%h", url.QueryEscape(tag)).Replace(template)
+		} else {
+			expanded = template + tag
+		}
+	} else if template, ok := doc.Links[link.URL]; ok {
+		// [[gh]] on its own uses the template with nothing filled in
+		expanded = strings.NewReplacer("%s", "", "%h", "").Replace(template)
+	} else {
+		return link, false
+	}
+
+	link.URL = expanded
+	link.Protocol = ""
+	if scheme, _, found := strings.Cut(expanded, ":"); found && !strings.Contains(scheme, "/") {
+		link.Protocol = scheme
+	} else if strings.HasPrefix(expanded, "/") || strings.HasPrefix(expanded, "./") ||
+		strings.HasPrefix(expanded, "../") || strings.HasPrefix(expanded, "~/") {
+		// Templates expanding to bare paths are file links
+		link.Protocol = "file"
+		link.URL = "file:" + expanded
+	}
+	return link, true
+}
+
+// completeLinkAbbreviations returns the document's #+LINK: abbreviations as
+// link types, each replacing whatever was typed after "[[" with "abbrev:"
+func completeLinkAbbreviations(doc *org.Document, ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: uint32(max(int(pos.Character)-len(ctx.FilterPrefix), 0))},
+		End:   pos,
+	}
+
+	names := make([]string, 0, len(doc.Links))
+	for name := range doc.Links {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(ctx.FilterPrefix)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]protocol.CompletionItem, 0, len(names))
+	for _, name := range names {
+		items = append(items, protocol.CompletionItem{
+			Label:      name + ":",
+			Kind:       protocol.CompletionItemKindModule,
+			Detail:     "Link abbreviation for " + doc.Links[name],
+			FilterText: name,
+			TextEdit:   &protocol.TextEdit{Range: editRange, NewText: name + ":"},
+		})
+	}
+	return items
+}
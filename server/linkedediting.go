@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// blockDelimiterRegexp matches a #+begin_ or #+end_ line, capturing the
+// delimiter kind and the block type word
+var blockDelimiterRegexp = regexp.MustCompile(`(?i)^\s*#\+(begin|end)_(\S+)`)
+
+// blockTypeWordPattern is the shape of a block type word while it is edited
+const blockTypeWordPattern = `[A-Za-z0-9_-]+`
+
+// LinkedEditingRange keeps the block type of a #+begin_ line and its matching
+// #+end_ line in sync while either is edited
+func (s *ServerImpl) LinkedEditingRange(ctx context.Context, params *protocol.LinkedEditingRangeParams) (result *protocol.LinkedEditingRanges, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	lines := strings.Split(s.state.RawContent[params.TextDocument.URI], "\n")
+	line, char := int(params.Position.Line), int(params.Position.Character)
+	if line >= len(lines) {
+		return nil, nil
+	}
+
+	cursorWord, found := blockTypeWordRange(lines, line)
+	if !found || char < int(cursorWord.Start.Character) || char > int(cursorWord.End.Character) {
+		return nil, nil
+	}
+
+	matchLine, found := matchingBlockDelimiter(lines, line)
+	if !found {
+		return nil, nil
+	}
+	matchWord, _ := blockTypeWordRange(lines, matchLine)
+
+	// Linked ranges must hold identical text, so #+BEGIN_SRC / #+end_src
+	// pairs are left alone
+	if rangeText(lines, cursorWord) != rangeText(lines, matchWord) {
+		return nil, nil
+	}
+
+	return &protocol.LinkedEditingRanges{
+		Ranges:      []protocol.Range{cursorWord, matchWord},
+		WordPattern: blockTypeWordPattern,
+	}, nil
+}
+
+// blockTypeWordRange returns the range of the block type word on a
+// #+begin_/#+end_ line
+func blockTypeWordRange(lines []string, line int) (protocol.Range, bool) {
+	loc := blockDelimiterRegexp.FindStringSubmatchIndex(lines[line])
+	if loc == nil {
+		return protocol.Range{}, false
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(line), Character: uint32(loc[4])},
+		End:   protocol.Position{Line: uint32(line), Character: uint32(loc[5])},
+	}, true
+}
+
+// matchingBlockDelimiter finds the #+end_ line closing the #+begin_ at line,
+// or the #+begin_ opening the #+end_ at line, pairing nested delimiters of
+// the same block type
+func matchingBlockDelimiter(lines []string, line int) (int, bool) {
+	m := blockDelimiterRegexp.FindStringSubmatch(lines[line])
+	if m == nil {
+		return 0, false
+	}
+	kind, blockType := strings.ToLower(m[1]), strings.ToLower(m[2])
+
+	step, opener := 1, "begin"
+	if kind == "end" {
+		step, opener = -1, "end"
+	}
+
+	depth := 0
+	for i := line + step; i >= 0 && i < len(lines); i += step {
+		other := blockDelimiterRegexp.FindStringSubmatch(lines[i])
+		if other == nil || strings.ToLower(other[2]) != blockType {
+			continue
+		}
+		if strings.ToLower(other[1]) == opener {
+			depth++
+		} else if depth == 0 {
+			return i, true
+		} else {
+			depth--
+		}
+	}
+	return 0, false
+}
+
+// rangeText returns the text of a single-line range
+func rangeText(lines []string, r protocol.Range) string {
+	return lines[r.Start.Line][r.Start.Character:r.End.Character]
+}
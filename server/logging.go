@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// defaultLogFileSize is how large the log file grows before it is rotated
+const defaultLogFileSize = 10 * 1024 * 1024
+
+// rotatingWriter appends to a log file, moving it aside to path.1 once it
+// would grow past maxSize, so logs kept for debugging never fill the disk.
+// Only one old file is kept.
+type rotatingWriter struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+// newRotatingWriter opens path for appending, creating it if needed
+func newRotatingWriter(path string, maxSize int64) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, maxSize: maxSize}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the log file and picks up its current size
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating first if p would take it past
+// maxSize. A single write larger than maxSize still goes to a fresh file.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current log file to path.1, replacing any older one, and
+// starts a new, empty log file
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return w.open()
+}
+
+// Close closes the log file
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+)
+
+func TestRotatingLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "org-lsp.log")
+	const maxSize = 1024
+
+	writer, err := newRotatingWriter(path, maxSize)
+	testza.AssertNoError(t, err)
+	defer writer.Close()
+	logger := slog.New(slog.NewTextHandler(writer, nil))
+
+	logger.Info("first entry", "n", 0)
+	data, err := os.ReadFile(path)
+	testza.AssertNoError(t, err)
+	testza.AssertContains(t, string(data), "first entry")
+	testza.AssertNoFileExists(t, path+".1", "Expected no rotation below the size limit")
+
+	for i := range 50 {
+		logger.Info("filler entry to pass the size limit", "n", i)
+	}
+	logger.Info("last entry")
+
+	rotated, err := os.ReadFile(path + ".1")
+	testza.AssertNoError(t, err, "Expected the old log moved aside")
+	testza.AssertLessOrEqual(t, len(rotated), maxSize)
+
+	current, err := os.ReadFile(path)
+	testza.AssertNoError(t, err)
+	testza.AssertLessOrEqual(t, len(current), maxSize)
+	testza.AssertTrue(t, strings.HasSuffix(strings.TrimSpace(string(current)), "msg=\"last entry\""))
+	testza.AssertNotContains(t, string(current), "first entry")
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// macroHover describes the {{{macro}}} at pos, showing its #+MACRO:
+// definition and the expansion with arguments substituted
+func macroHover(doc *org.Document, pos protocol.Position) (*protocol.Hover, bool) {
+	macro, found := findNodeAtPosition[org.Macro](doc, pos)
+	if !found {
+		return nil, false
+	}
+
+	content := fmt.Sprintf("**Macro** `%s`\n\nUndefined: no `#+MACRO: %s` definition in this document", macro.Name, macro.Name)
+	if definition, ok := doc.Macros[macro.Name]; ok {
+		content = fmt.Sprintf("**Macro** `%s`\n\nDefinition: `%s`\n\n```org\n%s\n```",
+			macro.Name, definition, expandMacro(definition, macro.Parameters))
+	}
+
+	hoverRange := toProtocolRange(macro.Pos)
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  "markdown",
+			Value: content,
+		},
+		Range: &hoverRange,
+	}, true
+}
+
+// expandMacro substitutes $1..$n in definition with params, replacing the
+// highest placeholders first so $1 doesn't clobber $10
+func expandMacro(definition string, params []string) string {
+	for i := len(params); i >= 1; i-- {
+		definition = strings.ReplaceAll(definition, fmt.Sprintf("$%d", i), params[i-1])
+	}
+	return definition
+}
+
+// openMacroCallRegexp matches an unfinished {{{name( call at the end of the
+// text before the cursor, capturing the name and the arguments typed so far
+var openMacroCallRegexp = regexp.MustCompile(`\{\{\{([A-Za-z][\w-]*)\(([^)]*)$`)
+
+// macroPlaceholderRegexp matches the $N argument placeholders of a definition
+var macroPlaceholderRegexp = regexp.MustCompile(`\$(\d+)`)
+
+func (s *ServerImpl) SignatureHelp(ctx context.Context, params *protocol.SignatureHelpParams) (result *protocol.SignatureHelp, err error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	uri := params.TextDocument.URI
+	doc, found := s.state.OpenDocs[uri]
+	if !found {
+		return nil, nil
+	}
+
+	lines := strings.Split(s.state.RawContent[uri], "\n")
+	line, char := int(params.Position.Line), int(params.Position.Character)
+	if line >= len(lines) || char > len(lines[line]) {
+		return nil, nil
+	}
+
+	match := openMacroCallRegexp.FindStringSubmatch(lines[line][:char])
+	if match == nil {
+		return nil, nil
+	}
+	name, args := match[1], match[2]
+
+	definition, ok := doc.Macros[name]
+	if !ok {
+		return nil, nil
+	}
+
+	// Org escapes literal commas in macro arguments as "\,"
+	activeParameter := strings.Count(args, ",") - strings.Count(args, `\,`)
+
+	signature := macroSignature(name, definition)
+	slog.Debug("Macro signature help", "macro", name, "parameters", len(signature.Parameters), "active", activeParameter)
+	return &protocol.SignatureHelp{
+		Signatures:      []protocol.SignatureInformation{signature},
+		ActiveParameter: uint32(activeParameter),
+	}, nil
+}
+
+// macroSignature describes a macro as name($1, $2, ...), with one parameter
+// per placeholder up to the highest one its definition uses
+func macroSignature(name, definition string) protocol.SignatureInformation {
+	count := 0
+	for _, m := range macroPlaceholderRegexp.FindAllStringSubmatch(definition, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			count = max(count, n)
+		}
+	}
+
+	parameters := make([]protocol.ParameterInformation, count)
+	labels := make([]string, count)
+	for i := range count {
+		labels[i] = fmt.Sprintf("$%d", i+1)
+		parameters[i] = protocol.ParameterInformation{Label: labels[i]}
+	}
+
+	return protocol.SignatureInformation{
+		Label:         fmt.Sprintf("%s(%s)", name, strings.Join(labels, ", ")),
+		Documentation: definition,
+		Parameters:    parameters,
+	}
+}
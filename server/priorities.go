@@ -0,0 +1,91 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// priorityCookies are org's default priorities, highest first
+var priorityCookies = []struct {
+	Cookie, Detail string
+}{
+	{"[#A]", "Highest priority"},
+	{"[#B]", "Default priority"},
+	{"[#C]", "Lowest priority"},
+}
+
+// partialPriorityRegexp matches a priority cookie being typed at the end of
+// the text before the cursor
+var partialPriorityRegexp = regexp.MustCompile(`\[(?:#[A-Za-z]?)?$`)
+
+// detectPriorityContext checks if cursor is where a headline's priority
+// cookie goes: right after its TODO keyword, or on a partial "[#" there
+func detectPriorityContext(state *State, uri protocol.DocumentURI, pos protocol.Position, headline *org.Headline) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+	before := line[:pos.Character]
+
+	// Strip the stars and TODO keyword; whatever remains must be the cookie
+	rest := strings.TrimLeft(before, "*")
+	if rest == before || !strings.HasPrefix(rest, " ") {
+		return ctx
+	}
+	rest = strings.TrimLeft(rest, " ")
+	if headline.Status != "" {
+		after, found := strings.CutPrefix(rest, headline.Status+" ")
+		if !found {
+			return ctx
+		}
+		rest = strings.TrimLeft(after, " ")
+	}
+
+	if rest == "" && headline.Status == "" {
+		return ctx
+	}
+	if rest != "" && partialPriorityRegexp.FindString(rest) != rest {
+		return ctx
+	}
+
+	ctx.Type = ContextTypePriority
+	ctx.FilterPrefix = rest
+	ctx.NeedsClosingBracket = !strings.HasPrefix(line[pos.Character:], "]")
+	return ctx
+}
+
+// completePriorities returns the priority cookies, each replacing the
+// partial cookie typed so far and followed by a space
+func completePriorities(ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	startChar := max(int(pos.Character)-len(ctx.FilterPrefix), 0)
+	endChar := int(pos.Character)
+	if !ctx.NeedsClosingBracket {
+		endChar++ // replace the "]" already after the cursor
+	}
+
+	var items []protocol.CompletionItem
+	for i, priority := range priorityCookies {
+		if !strings.HasPrefix(priority.Cookie, strings.ToUpper(ctx.FilterPrefix)) {
+			continue
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:    priority.Cookie,
+			Kind:     protocol.CompletionItemKindEnumMember,
+			Detail:   priority.Detail,
+			SortText: string(rune('a' + i)),
+			TextEdit: &protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: pos.Line, Character: uint32(startChar)},
+					End:   protocol.Position{Line: pos.Line, Character: uint32(endChar)},
+				},
+				NewText: priority.Cookie + " ",
+			},
+		})
+	}
+	return items
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	protocol "go.lsp.dev/protocol"
+)
+
+// knownPropertyValues maps property keys with a fixed set of values to the
+// values org accepts for them
+var knownPropertyValues = map[string][]string{
+	"ORDERED":        {"t", "nil"},
+	"NOBLOCKING":     {"t", "nil"},
+	"VISIBILITY":     {"folded", "children", "content", "all"},
+	"LOGGING":        {"nil", "time", "note", "lognoterepeat"},
+	"COOKIE_DATA":    {"todo", "checkbox", "recursive"},
+	"EXPORT_OPTIONS": {"toc:nil", "num:nil", "toc:t", "num:t"},
+}
+
+// uniquePropertyKeys are keys whose values must not be reused, so no
+// workspace values are suggested for them
+var uniquePropertyKeys = map[string]bool{
+	"ID":        true,
+	"CUSTOM_ID": true,
+}
+
+// propertyValuePrefixRegexp matches a property line up to the cursor,
+// capturing the key and the partially typed value
+var propertyValuePrefixRegexp = regexp.MustCompile(`^\s*:([^:\s]+):\s+(\S*)$`)
+
+// detectPropertyValueContext checks if cursor is on the value of a property
+// line (after ":KEY: ") inside a :PROPERTIES: drawer
+func detectPropertyValueContext(state *State, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	content, found := state.RawContent[uri]
+	if !found {
+		return ctx
+	}
+
+	lines := strings.Split(content, "\n")
+	if int(pos.Line) >= len(lines) || int(pos.Character) > len(lines[pos.Line]) {
+		return ctx
+	}
+
+	match := propertyValuePrefixRegexp.FindStringSubmatch(lines[pos.Line][:pos.Character])
+	if match == nil || !insidePropertyDrawer(lines, int(pos.Line)) {
+		return ctx
+	}
+
+	ctx.Type = ContextTypePropertyValue
+	ctx.PropertyKey = strings.ToUpper(match[1])
+	ctx.FilterPrefix = match[2]
+	return ctx
+}
+
+// insidePropertyDrawer reports whether line lies between a :PROPERTIES: line
+// and its :END:
+func insidePropertyDrawer(lines []string, line int) bool {
+	for i := line - 1; i >= 0; i-- {
+		trimmed := strings.ToUpper(strings.TrimSpace(lines[i]))
+		switch {
+		case trimmed == ":PROPERTIES:":
+			return true
+		case trimmed == ":END:", isHeadlineLine(lines[i]):
+			return false
+		}
+	}
+	return false
+}
+
+// propertyDrawerPrefixRegexp matches the start of a drawer line typed up
+// to the cursor, e.g. ":PROP"
+var propertyDrawerPrefixRegexp = regexp.MustCompile(`^\s*(:[A-Za-z]*)$`)
+
+// detectPropertyDrawerContext checks if cursor is at the end of a partial
+// ":PROPERTIES:" typed on the line right below a headline
+func detectPropertyDrawerContext(state *State, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	content, found := state.RawContent[uri]
+	if !found {
+		return ctx
+	}
+
+	lines := strings.Split(content, "\n")
+	if pos.Line == 0 || int(pos.Line) >= len(lines) || int(pos.Character) > len(lines[pos.Line]) {
+		return ctx
+	}
+	if !isHeadlineLine(lines[pos.Line-1]) {
+		return ctx
+	}
+
+	match := propertyDrawerPrefixRegexp.FindStringSubmatch(lines[pos.Line][:pos.Character])
+	if match == nil || len(match[1]) < 2 || !strings.HasPrefix(":PROPERTIES:", strings.ToUpper(match[1])) {
+		return ctx
+	}
+
+	ctx.Type = ContextTypeDrawer
+	ctx.FilterPrefix = match[1]
+	return ctx
+}
+
+// completePropertyDrawer offers the whole drawer for a partial
+// ":PROPERTIES:", with its :END: and a fresh :ID:. Clients that support
+// snippets get tab stops on the ID and after the drawer.
+func completePropertyDrawer(state *State, ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: uint32(max(int(pos.Character)-len(ctx.FilterPrefix), 0))},
+		End:   pos,
+	}
+
+	uuid := generateUUID()
+	item := protocol.CompletionItem{
+		Label:      ":PROPERTIES:",
+		Kind:       protocol.CompletionItemKindSnippet,
+		Detail:     "Property drawer with an ID",
+		FilterText: ":PROPERTIES:",
+		TextEdit: &protocol.TextEdit{
+			Range:   editRange,
+			NewText: ":PROPERTIES:\n:ID: " + uuid + "\n:END:",
+		},
+	}
+	if state.Snippets {
+		item.InsertTextFormat = protocol.InsertTextFormatSnippet
+		item.TextEdit.NewText = ":PROPERTIES:\n:ID: ${1:" + uuid + "}\n:END:\n$0"
+	}
+	return []protocol.CompletionItem{item}
+}
+
+// completePropertyValues returns value candidates for the property key at
+// the cursor: the fixed values of known keys, otherwise values already used
+// for that key across the workspace
+func completePropertyValues(state *State, uri protocol.DocumentURI, ctx CompletionContext) []protocol.CompletionItem {
+	values, known := knownPropertyValues[ctx.PropertyKey]
+	detail := "Property value"
+	if !known {
+		if uniquePropertyKeys[ctx.PropertyKey] {
+			return nil
+		}
+		values = workspacePropertyValues(state, uri, ctx.PropertyKey)
+		detail = "Used elsewhere in workspace"
+	}
+
+	var items []protocol.CompletionItem
+	for _, value := range values {
+		if !strings.HasPrefix(strings.ToLower(value), strings.ToLower(ctx.FilterPrefix)) {
+			continue
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:      value,
+			Kind:       protocol.CompletionItemKindValue,
+			Detail:     detail,
+			InsertText: value,
+		})
+	}
+
+	slog.Debug("Property value completion generated", "key", ctx.PropertyKey, "itemCount", len(items))
+	return items
+}
+
+// workspacePropertyValues collects the distinct values used for key in the
+// open document, which may have unsaved edits, and in the scanner's index of
+// every file, sorted
+func workspacePropertyValues(state *State, uri protocol.DocumentURI, key string) []string {
+	seen := make(map[string]bool)
+	if doc, ok := state.OpenDocs[uri]; ok {
+		for _, headline := range collectHeadlines(doc) {
+			if value := getPropertyValue(headline, key); value != "" {
+				seen[value] = true
+			}
+		}
+	}
+	if state.Scanner != nil && state.Scanner.ProcessedFiles != nil {
+		for value := range state.Scanner.ProcessedFiles.PropertyValues[key] {
+			seen[value] = true
+		}
+	}
+
+	values := make([]string, 0, len(seen))
+	for value := range seen {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// refileTopLevel is the parent choice offered for refiling as a top-level
+// heading of the target file
+const refileTopLevel = "(top level)"
+
+// getRefileAction returns the action that starts an interactive refile of
+// the subtree at headline
+func getRefileAction(headline org.Headline, uri protocol.DocumentURI) protocol.CodeAction {
+	title := "Org: Refile subtree"
+	return protocol.CodeAction{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Command: &protocol.Command{
+			Title:     title,
+			Command:   CommandRefile,
+			Arguments: []any{string(uri), headline.Pos.StartLine, headline.Pos.StartColumn},
+		},
+	}
+}
+
+// refileCommand moves the subtree at [uri, line, column] to a target file,
+// optionally under a parent heading: [uri, line, column, targetURI, parent].
+// parent is a heading title or ID; empty refiles as a top-level heading.
+// When the target is not given the user is asked to pick the file and parent
+// with window/showMessageRequest.
+func (s *ServerImpl) refileCommand(ctx context.Context, args []any) (any, error) {
+	uri, line, _, err := decodeLocationArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var targetURI protocol.DocumentURI
+	var parent string
+	if len(args) > 3 {
+		target, ok := args[3].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid target uri argument: %v", args[3])
+		}
+		targetURI = protocol.DocumentURI(target)
+	}
+	if len(args) > 4 {
+		if parent, err = decodeStringArg(args[4], "parent"); err != nil {
+			return nil, err
+		}
+	}
+
+	if targetURI == "" {
+		var chosen bool
+		targetURI, parent, chosen = s.promptRefileTarget(ctx)
+		if !chosen {
+			slog.Debug("Refile cancelled", "uri", uri)
+			return nil, nil
+		}
+	}
+
+	s.state.Mu.RLock()
+	edit, err := refileEdit(s.state, uri, line, targetURI, parent)
+	s.state.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyWorkspaceEdit(ctx, "Org: Refile subtree", *edit); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Refiled subtree", "from", uri, "line", line, "to", targetURI, "parent", parent)
+	return edit, nil
+}
+
+// decodeStringArg decodes an optional string command argument
+func decodeStringArg(v any, name string) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s argument: %v", name, v)
+	}
+	return str, nil
+}
+
+// promptRefileTarget asks the user for the target file, then for the
+// parent heading within it
+func (s *ServerImpl) promptRefileTarget(ctx context.Context) (protocol.DocumentURI, string, bool) {
+	client := s.GetClient()
+	if client == nil {
+		return "", "", false
+	}
+
+	s.state.Mu.RLock()
+	files := workspaceFilePaths(s.state)
+	s.state.Mu.RUnlock()
+	if len(files) == 0 {
+		return "", "", false
+	}
+
+	fileActions := make([]protocol.MessageActionItem, len(files))
+	for i, path := range files {
+		fileActions[i] = protocol.MessageActionItem{Title: path}
+	}
+	fileChoice, err := client.ShowMessageRequest(ctx, &protocol.ShowMessageRequestParams{
+		Type:    protocol.MessageTypeInfo,
+		Message: "Refile subtree to which file?",
+		Actions: fileActions,
+	})
+	if err != nil || fileChoice == nil || fileChoice.Title == "" {
+		return "", "", false
+	}
+	targetURI := protocol.DocumentURI(pathToURI(filepath.Join(s.state.OrgScanRoot, fileChoice.Title)))
+
+	s.state.Mu.RLock()
+	headingActions := []protocol.MessageActionItem{{Title: refileTopLevel}}
+	if targetDoc, _, err := documentForURI(s.state, targetURI); err == nil {
+		for _, headline := range collectHeadlines(targetDoc) {
+			headingActions = append(headingActions, protocol.MessageActionItem{Title: strings.TrimSpace(org.String(headline.Title...))})
+		}
+	}
+	s.state.Mu.RUnlock()
+
+	parentChoice, err := client.ShowMessageRequest(ctx, &protocol.ShowMessageRequestParams{
+		Type:    protocol.MessageTypeInfo,
+		Message: "Refile under which heading?",
+		Actions: headingActions,
+	})
+	if err != nil || parentChoice == nil {
+		return "", "", false
+	}
+	if parentChoice.Title == refileTopLevel {
+		return targetURI, "", true
+	}
+	return targetURI, parentChoice.Title, true
+}
+
+// workspaceFilePaths returns the sorted workspace-relative paths of every
+// indexed org file
+func workspaceFilePaths(state *State) []string {
+	var paths []string
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return paths
+	}
+	state.Scanner.ProcessedFiles.Files.Range(func(_, value any) bool {
+		if fileInfo, ok := value.(*orgscanner.FileInfo); ok {
+			paths = append(paths, fileInfo.Path)
+		}
+		return true
+	})
+	slices.Sort(paths)
+	return paths
+}
+
+// collectHeadlines returns every headline in doc in document order
+func collectHeadlines(doc *org.Document) []org.Headline {
+	var headlines []org.Headline
+	var walkNodes func(node org.Node)
+	walkNodes = func(node org.Node) {
+		if headline, ok := node.(org.Headline); ok {
+			headlines = append(headlines, headline)
+		}
+		node.Range(func(n org.Node) bool {
+			walkNodes(n)
+			return true
+		})
+	}
+	for _, node := range doc.Nodes {
+		walkNodes(node)
+	}
+	return headlines
+}
+
+// subtreeEndLine returns the line after the subtree of the headline at
+// startLine: the next headline of the same or a higher level, or len(lines)
+func subtreeEndLine(lines []string, startLine, level int) int {
+	for i := startLine + 1; i < len(lines); i++ {
+		if lvl := getHeadingLevel(lines[i]); lvl > 0 && lvl <= level {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// refileEdit builds the WorkspaceEdit moving the subtree at line in uri to
+// targetURI, under the heading matching parent (by title or ID) or at the
+// top level when parent is empty. Heading levels are shifted to fit.
+func refileEdit(state *State, uri protocol.DocumentURI, line int, targetURI protocol.DocumentURI, parent string) (*protocol.WorkspaceEdit, error) {
+	if _, ok := state.OpenDocs[uri]; !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+	targetDoc, targetContent, err := documentForURI(state, targetURI)
+	if err != nil {
+		return nil, err
+	}
+	return refileEditTo(state, uri, line, targetURI, targetDoc, targetContent, parent)
+}
+
+// refileEditTo is refileEdit with the target's document and content given,
+// for a target that doesn't exist on disk yet
+func refileEditTo(state *State, uri protocol.DocumentURI, line int, targetURI protocol.DocumentURI, targetDoc *org.Document, targetContent string, parent string) (*protocol.WorkspaceEdit, error) {
+	doc, ok := state.OpenDocs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+	headline, found := findNodeAtPosition[org.Headline](doc, protocol.Position{Line: uint32(line)})
+	if !found {
+		return nil, fmt.Errorf("no heading found at line %d", line)
+	}
+
+	sourceLines := strings.Split(state.RawContent[uri], "\n")
+	start := headline.Pos.StartLine
+	end := subtreeEndLine(sourceLines, start, headline.Lvl)
+
+	targetLines := strings.Split(targetContent, "\n")
+
+	// Find where the subtree goes and what level it becomes
+	newLevel := 1
+	insertLine := len(targetLines)
+	if parent != "" {
+		parentHeadline, found := findRefileParent(targetDoc, parent)
+		if !found {
+			return nil, fmt.Errorf("parent heading not found in target: %s", parent)
+		}
+		if targetURI == uri && parentHeadline.Pos.StartLine >= start && parentHeadline.Pos.StartLine < end {
+			return nil, fmt.Errorf("cannot refile a subtree under itself")
+		}
+		newLevel = parentHeadline.Lvl + 1
+		insertLine = subtreeEndLine(targetLines, parentHeadline.Pos.StartLine, parentHeadline.Lvl)
+	}
+
+	subtree := shiftHeadingLevels(sourceLines[start:end], newLevel-headline.Lvl)
+	text := strings.Join(subtree, "\n")
+	text = strings.TrimRight(text, "\n") + "\n"
+
+	// Delete the subtree from the source, including its trailing newline
+	deleteRange := protocol.Range{
+		Start: protocol.Position{Line: uint32(start), Character: 0},
+		End:   protocol.Position{Line: uint32(end), Character: 0},
+	}
+	if end >= len(sourceLines) {
+		last := len(sourceLines) - 1
+		deleteRange.End = protocol.Position{Line: uint32(last), Character: uint32(len(sourceLines[last]))}
+	}
+
+	// Insert before the line at insertLine, or append at end of file
+	var insertAt protocol.Position
+	if insertLine < len(targetLines) {
+		insertAt = protocol.Position{Line: uint32(insertLine), Character: 0}
+	} else {
+		last := len(targetLines) - 1
+		insertAt = protocol.Position{Line: uint32(last), Character: uint32(len(targetLines[last]))}
+		if targetLines[last] != "" {
+			text = "\n" + text
+		}
+	}
+
+	changes := map[protocol.DocumentURI][]protocol.TextEdit{}
+	changes[uri] = append(changes[uri], protocol.TextEdit{Range: deleteRange})
+	changes[targetURI] = append(changes[targetURI], protocol.TextEdit{
+		Range:   protocol.Range{Start: insertAt, End: insertAt},
+		NewText: text,
+	})
+
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+// findRefileParent finds the heading in doc whose title or ID is parent
+func findRefileParent(doc *org.Document, parent string) (org.Headline, bool) {
+	parent = strings.TrimSpace(strings.TrimPrefix(parent, "id:"))
+	for _, headline := range collectHeadlines(doc) {
+		if strings.TrimSpace(org.String(headline.Title...)) == parent || getPropertyValue(headline, "ID") == parent {
+			return headline, true
+		}
+	}
+	return org.Headline{}, false
+}
+
+// shiftHeadingLevels adds delta stars to every headline line, keeping each
+// heading at level one or deeper
+func shiftHeadingLevels(lines []string, delta int) []string {
+	shifted := make([]string, len(lines))
+	for i, line := range lines {
+		shifted[i] = line
+		if delta == 0 || !isHeadlineLine(line) {
+			continue
+		}
+		level := getHeadingLevel(line)
+		shifted[i] = strings.Repeat("*", max(1, level+delta)) + line[level:]
+	}
+	return shifted
+}
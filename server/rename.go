@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// renameTargetKind identifies what kind of symbol a rename applies to
+type renameTargetKind string
+
+const (
+	renameTargetTag     renameTargetKind = "tag"
+	renameTargetHeading renameTargetKind = "heading"
+	renameTargetID      renameTargetKind = "id"
+	renameTargetCustom  renameTargetKind = "customID"
+)
+
+// renameTarget is a renameable symbol under the cursor
+type renameTarget struct {
+	Kind  renameTargetKind
+	Name  string         // Current tag name, heading title, or ID
+	Range protocol.Range // Range of Name in the current document
+}
+
+// customIDLinkRegexp matches a [[#custom-id]] or [[#custom-id][desc]] link,
+// capturing the custom ID
+var customIDLinkRegexp = regexp.MustCompile(`\[\[#([^\]\s]+)\](?:\[[^\]]*\])?\]`)
+
+// headlineTagsRegexp matches the trailing :tag1:tag2: group of a headline line
+var headlineTagsRegexp = regexp.MustCompile(`\s+(:[\p{L}0-9_@#%:]+:)\s*$`)
+
+// tagSpan is the location of a single tag name on a headline line
+type tagSpan struct {
+	Name       string
+	Start, End int // Byte columns of the name, excluding colons
+}
+
+// findLineTags returns the tags on a raw headline line with their columns
+func findLineTags(line string) []tagSpan {
+	match := headlineTagsRegexp.FindStringSubmatchIndex(line)
+	if match == nil {
+		return nil
+	}
+
+	var spans []tagSpan
+	col := match[2] + 1 // Skip the leading colon
+	for _, name := range strings.Split(strings.Trim(line[match[2]:match[3]], ":"), ":") {
+		if name != "" {
+			spans = append(spans, tagSpan{Name: name, Start: col, End: col + len(name)})
+		}
+		col += len(name) + 1
+	}
+	return spans
+}
+
+// headlineTitleSpan returns the byte columns of the title text on a raw
+// headline line, skipping the stars, TODO keyword, priority cookie, and tags.
+func headlineTitleSpan(headline *org.Headline, line string) (start, end int) {
+	start = headline.Lvl + 1
+	if headline.Status != "" {
+		start += len(headline.Status) + 1
+	}
+	if headline.Priority != "" {
+		start += len("[#"+headline.Priority+"]") + 1
+	}
+	start = min(start, len(line))
+
+	end = len(line)
+	if match := headlineTagsRegexp.FindStringIndex(line); match != nil {
+		end = match[0]
+	}
+	end = max(start, len(strings.TrimRight(line[:end], " \t")))
+	return start, end
+}
+
+// findRenameTarget decides what, if anything, can be renamed at pos. Both
+// PrepareRename and Rename go through here, so the range an editor is told is
+// renameable is always one Rename can act on.
+func findRenameTarget(doc *org.Document, content string, pos protocol.Position) (*renameTarget, bool) {
+	lines := strings.Split(content, "\n")
+	if int(pos.Line) >= len(lines) {
+		return nil, false
+	}
+	line := lines[pos.Line]
+	col := int(pos.Character)
+
+	lineRange := func(start, end int) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: pos.Line, Character: uint32(start)},
+			End:   protocol.Position{Line: pos.Line, Character: uint32(end)},
+		}
+	}
+
+	// Tags and titles on the headline line itself
+	if headline, found := findNodeAtPosition[org.Headline](doc, pos); found && headline.Pos.StartLine == int(pos.Line) {
+		for _, tag := range findLineTags(line) {
+			if col >= tag.Start && col <= tag.End {
+				return &renameTarget{Kind: renameTargetTag, Name: tag.Name, Range: lineRange(tag.Start, tag.End)}, true
+			}
+		}
+
+		start, end := headlineTitleSpan(headline, line)
+		if col >= start && col <= end && start < end {
+			return &renameTarget{Kind: renameTargetHeading, Name: line[start:end], Range: lineRange(start, end)}, true
+		}
+		return nil, false
+	}
+
+	// The :ID: property of a heading
+	if id, start, ok := idPropertyValue(line); ok && col >= start && col <= start+len(id) {
+		return &renameTarget{Kind: renameTargetID, Name: id, Range: lineRange(start, start+len(id))}, true
+	}
+
+	// The :CUSTOM_ID: property of a heading, or a [[#custom-id]] link to one
+	if id, start, ok := propertyLineValue(line, "CUSTOM_ID"); ok && col >= start && col <= start+len(id) {
+		return &renameTarget{Kind: renameTargetCustom, Name: id, Range: lineRange(start, start+len(id))}, true
+	}
+	for _, match := range customIDLinkRegexp.FindAllStringSubmatchIndex(line, -1) {
+		if col >= match[0] && col <= match[1] {
+			return &renameTarget{Kind: renameTargetCustom, Name: line[match[2]:match[3]], Range: lineRange(match[2], match[3])}, true
+		}
+	}
+
+	// An id: link pointing at a heading
+	if link, found := findNodeAtPosition[org.RegularLink](doc, pos); found && link.Protocol == "id" {
+		id := strings.TrimPrefix(link.URL, "id:")
+		for _, start := range idLinkColumns(line, id) {
+			if col >= start-len("[[id:") && col <= start+len(id)+len("]]") {
+				return &renameTarget{Kind: renameTargetID, Name: id, Range: lineRange(start, start+len(id))}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// idPropertyValue extracts the value of an ":ID: value" property line,
+// returning the value and its starting column
+func idPropertyValue(line string) (string, int, bool) {
+	return propertyLineValue(line, "ID")
+}
+
+// propertyLineValue extracts the value of a ":KEY: value" property line,
+// returning the value and its starting column
+func propertyLineValue(line, key string) (string, int, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	prefix := ":" + key + ":"
+	if !strings.HasPrefix(strings.ToUpper(trimmed), prefix) {
+		return "", 0, false
+	}
+	offset := len(line) - len(trimmed) + len(prefix)
+	rest := line[offset:]
+	value := strings.TrimSpace(rest)
+	if value == "" {
+		return "", 0, false
+	}
+	return value, offset + strings.Index(rest, value), true
+}
+
+// idLinkColumns returns the starting column of id in every [[id:id]] or
+// [[id:id][...]] link on line
+func idLinkColumns(line, id string) []int {
+	var columns []int
+	needle := "[[id:" + id
+	offset := 0
+	for {
+		idx := strings.Index(line[offset:], needle)
+		if idx == -1 {
+			break
+		}
+		start := offset + idx + len("[[id:")
+		end := start + len(id)
+		// Only accept whole IDs, not prefixes of longer ones
+		if end < len(line) && line[end] == ']' {
+			columns = append(columns, start)
+		}
+		offset = end
+	}
+	return columns
+}
+
+func (s *ServerImpl) PrepareRename(ctx context.Context, params *protocol.PrepareRenameParams) (result *protocol.Range, err error) {
+	if s.state == nil {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	uri := params.TextDocument.URI
+	doc, ok := s.state.OpenDocs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+
+	target, found := findRenameTarget(doc, s.state.RawContent[uri], params.Position)
+	if !found {
+		slog.Debug("Nothing renameable at position", "uri", uri, "line", params.Position.Line, "char", params.Position.Character)
+		return nil, fmt.Errorf("only tags, headings, and IDs can be renamed")
+	}
+
+	slog.Debug("Prepared rename", "kind", target.Kind, "name", target.Name)
+	return &target.Range, nil
+}
+
+func (s *ServerImpl) Rename(ctx context.Context, params *protocol.RenameParams) (result *protocol.WorkspaceEdit, err error) {
+	if s.state == nil {
+		return nil, fmt.Errorf("server not initialized")
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	uri := params.TextDocument.URI
+	doc, ok := s.state.OpenDocs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+
+	target, found := findRenameTarget(doc, s.state.RawContent[uri], params.Position)
+	if !found {
+		return nil, fmt.Errorf("only tags, headings, and IDs can be renamed")
+	}
+
+	newName := strings.TrimSpace(params.NewName)
+	if newName == "" {
+		return nil, fmt.Errorf("new name must not be empty")
+	}
+
+	var changes map[protocol.DocumentURI][]protocol.TextEdit
+	switch target.Kind {
+	case renameTargetTag:
+		if strings.ContainsAny(newName, ": \t") {
+			return nil, fmt.Errorf("tags cannot contain colons or whitespace")
+		}
+		changes = renameTagEdits(s.state, target.Name, newName)
+	case renameTargetHeading:
+		changes = map[protocol.DocumentURI][]protocol.TextEdit{
+			uri: {{Range: target.Range, NewText: newName}},
+		}
+	case renameTargetID:
+		if strings.ContainsAny(newName, " \t[]") {
+			return nil, fmt.Errorf("IDs cannot contain whitespace or brackets")
+		}
+		changes = renameIDEdits(s.state, target.Name, newName)
+	case renameTargetCustom:
+		if strings.ContainsAny(newName, " \t[]") {
+			return nil, fmt.Errorf("custom IDs cannot contain whitespace or brackets")
+		}
+		changes = map[protocol.DocumentURI][]protocol.TextEdit{
+			uri: renameCustomIDEdits(s.state.RawContent[uri], target.Name, newName),
+		}
+	}
+
+	slog.Info("Rename", "kind", target.Kind, "from", target.Name, "to", newName, "files", len(changes))
+	edit := &protocol.WorkspaceEdit{Changes: changes}
+	clientEdit(s.state, edit)
+	return edit, nil
+}
+
+// renameTagEdits renames a tag on every headline in the workspace
+func renameTagEdits(state *State, oldTag, newTag string) map[protocol.DocumentURI][]protocol.TextEdit {
+	changes := make(map[protocol.DocumentURI][]protocol.TextEdit)
+	for uri, content := range workspaceContents(state) {
+		for lineNum, line := range strings.Split(content, "\n") {
+			if !strings.HasPrefix(line, "*") {
+				continue
+			}
+			for _, tag := range findLineTags(line) {
+				if tag.Name == oldTag {
+					changes[uri] = append(changes[uri], protocol.TextEdit{
+						Range: protocol.Range{
+							Start: protocol.Position{Line: uint32(lineNum), Character: uint32(tag.Start)},
+							End:   protocol.Position{Line: uint32(lineNum), Character: uint32(tag.End)},
+						},
+						NewText: newTag,
+					})
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// renameIDEdits renames an ID property and every id: link pointing at it
+func renameIDEdits(state *State, oldID, newID string) map[protocol.DocumentURI][]protocol.TextEdit {
+	changes := make(map[protocol.DocumentURI][]protocol.TextEdit)
+	for uri, content := range workspaceContents(state) {
+		for lineNum, line := range strings.Split(content, "\n") {
+			columns := idLinkColumns(line, oldID)
+			if id, start, ok := idPropertyValue(line); ok && id == oldID {
+				columns = append(columns, start)
+			}
+			for _, start := range columns {
+				changes[uri] = append(changes[uri], protocol.TextEdit{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(lineNum), Character: uint32(start)},
+						End:   protocol.Position{Line: uint32(lineNum), Character: uint32(start + len(oldID))},
+					},
+					NewText: newID,
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// renameCustomIDEdits renames a :CUSTOM_ID: property and every [[#id]] link
+// pointing at it. Custom IDs are scoped to their file, so only content is
+// searched.
+func renameCustomIDEdits(content, oldID, newID string) []protocol.TextEdit {
+	var edits []protocol.TextEdit
+	for lineNum, line := range strings.Split(content, "\n") {
+		var columns []int
+		if id, start, ok := propertyLineValue(line, "CUSTOM_ID"); ok && id == oldID {
+			columns = append(columns, start)
+		}
+		for _, match := range customIDLinkRegexp.FindAllStringSubmatchIndex(line, -1) {
+			if line[match[2]:match[3]] == oldID {
+				columns = append(columns, match[2])
+			}
+		}
+		for _, start := range columns {
+			edits = append(edits, protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(lineNum), Character: uint32(start)},
+					End:   protocol.Position{Line: uint32(lineNum), Character: uint32(start + len(oldID))},
+				},
+				NewText: newID,
+			})
+		}
+	}
+	return edits
+}
+
+// workspaceContents returns the current text of every org file the server
+// knows about, keyed by URI. Open documents use their in-memory content so
+// unsaved edits are respected; everything else is read from disk.
+func workspaceContents(state *State) map[protocol.DocumentURI]string {
+	contents := make(map[protocol.DocumentURI]string)
+	for uri, content := range state.RawContent {
+		contents[uri] = content
+	}
+
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return contents
+	}
+
+	state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
+		fileInfo, ok := value.(*orgscanner.FileInfo)
+		if !ok {
+			return true
+		}
+		absPath := filepath.Clean(filepath.Join(state.OrgScanRoot, fileInfo.Path))
+		uri := protocol.DocumentURI(pathToURI(absPath))
+		if _, open := contents[uri]; open {
+			return true
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			slog.Debug("Failed to read workspace file", "path", absPath, "error", err)
+			return true
+		}
+		contents[uri] = string(data)
+		return true
+	})
+
+	return contents
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// detectRoamContext checks if cursor is in an org-roam ref completion context (after "[[roam:")
+func detectRoamContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := detectPrefixContext(state, doc, uri, pos, "[[roam:", ContextTypeRoam, true)
+	ctx.FilterPrefix = strings.ToLower(ctx.FilterPrefix)
+	return ctx
+}
+
+// completeRoamRefs returns completion items for every indexed :ROAM_REFS:
+// entry, matching the typed text against the ref or its node's title
+func completeRoamRefs(state *State, ctx CompletionContext) []protocol.CompletionItem {
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return nil
+	}
+
+	var items []protocol.CompletionItem
+	state.Scanner.ProcessedFiles.RoamRefs.Range(func(key, value any) bool {
+		ref, ok := key.(string)
+		location, ok0 := value.(orgscanner.HeaderLocation)
+		if !ok || !ok0 {
+			return true
+		}
+		if ctx.FilterPrefix != "" &&
+			!strings.Contains(strings.ToLower(ref), ctx.FilterPrefix) &&
+			!strings.Contains(strings.ToLower(location.Title), ctx.FilterPrefix) {
+			return true
+		}
+
+		insertText := ref
+		if ctx.NeedsClosingBracket {
+			insertText += "]]"
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:      ref,
+			Kind:       protocol.CompletionItemKindReference,
+			Detail:     location.Title,
+			FilterText: ref + " " + location.Title,
+			InsertText: insertText,
+		})
+		return true
+	})
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+
+	slog.Debug("Roam ref completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
+
+// resolveRoamLink resolves a roam: link to the node whose :ROAM_REFS:
+// contains it, falling back to a node titled like it as org-roam does
+func resolveRoamLink(state *State, linkURL string) (string, org.Position, error) {
+	if state.Scanner == nil || state.Scanner.ProcessedFiles == nil {
+		return "", org.Position{}, fmt.Errorf("scanner not initialized")
+	}
+
+	ref := strings.TrimSpace(strings.TrimPrefix(linkURL, "roam:"))
+	if value, found := state.Scanner.ProcessedFiles.RoamRefs.Load(ref); found {
+		if location, ok := value.(orgscanner.HeaderLocation); ok {
+			return indexPathToAbs(state, location.FilePath), location.Position, nil
+		}
+	}
+
+	var match *orgscanner.HeaderLocation
+	state.Scanner.ProcessedFiles.UuidIndex.Range(func(_, value any) bool {
+		if location, ok := value.(orgscanner.HeaderLocation); ok && strings.EqualFold(location.Title, ref) {
+			match = &location
+			return false
+		}
+		return true
+	})
+	if match == nil {
+		return "", org.Position{}, fmt.Errorf("no roam node for %q", ref)
+	}
+	return indexPathToAbs(state, match.FilePath), match.Position, nil
+}
@@ -3,13 +3,19 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.lsp.dev/jsonrpc2"
 	protocol "go.lsp.dev/protocol"
+	"go.uber.org/zap"
 
 	"github.com/alexispurslane/go-org/org"
 	"github.com/alexispurslane/org-lsp/orgscanner"
@@ -26,11 +32,16 @@ type ServerImpl struct {
 	client   protocol.Client // LSP client for sending notifications
 	clientMu sync.RWMutex    // Protects client field
 	state    *State          // Per-instance server state
+	logFile  *rotatingWriter // Log destination when a log file is configured
+
+	shutdown atomic.Bool   // Set once the client sends shutdown
+	exited   chan struct{} // Closed once the client sends exit
+	exitOnce sync.Once     // Closes exited
 }
 
 // New creates a new ServerImpl instance
 func New() *ServerImpl {
-	return &ServerImpl{}
+	return &ServerImpl{exited: make(chan struct{})}
 }
 
 // SetClient sets the LSP client for sending notifications
@@ -90,19 +101,40 @@ func (s *ServerImpl) Initialize(ctx context.Context, params *protocol.Initialize
 	s.state.OpenDocs = make(map[protocol.DocumentURI]*org.Document)
 	s.state.DocVersions = make(map[protocol.DocumentURI]int32)
 	s.state.RawContent = make(map[protocol.DocumentURI]string)
+	s.state.CRLF = make(map[protocol.DocumentURI]bool)
+	s.state.LineStarts = make(map[protocol.DocumentURI][]int)
+	s.state.Config = parseConfig(params.InitializationOptions)
+	s.configureLogFile(level)
+	if params.Capabilities.Workspace != nil && params.Capabilities.Workspace.DidChangeWatchedFiles != nil {
+		s.state.WatchFiles = params.Capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	}
+	if textDocument := params.Capabilities.TextDocument; textDocument != nil && textDocument.Completion != nil && textDocument.Completion.CompletionItem != nil {
+		s.state.Snippets = textDocument.Completion.CompletionItem.SnippetSupport
+	}
+	if textDocument := params.Capabilities.TextDocument; textDocument != nil && textDocument.Hover != nil && len(textDocument.Hover.ContentFormat) > 0 {
+		s.state.PlainHover = !slices.Contains(textDocument.Hover.ContentFormat, protocol.Markdown)
+	}
 	s.clientMu.RLock()
 	s.state.Client = s.client
 	s.clientMu.RUnlock()
 
-	// Check if RootURI is provided (it's a string in go.lsp.dev/protocol, not a pointer)
-	if params.RootURI != "" {
-		// Convert URI to filesystem path
-		s.state.OrgScanRoot = uriToPath(string(params.RootURI))
+	// Prefer WorkspaceFolders, falling back to the single RootURI (it's a
+	// string in go.lsp.dev/protocol, not a pointer)
+	for _, folder := range params.WorkspaceFolders {
+		s.state.Roots = append(s.state.Roots, uriToPath(folder.URI))
+	}
+	if len(s.state.Roots) == 0 && params.RootURI != "" {
+		s.state.Roots = []string{uriToPath(string(params.RootURI))}
+	}
 
-		// Process org files from root directory
-		slog.Info("Starting org file scan", "root", s.state.OrgScanRoot)
-		s.state.Scanner = orgscanner.NewOrgScanner(s.state.OrgScanRoot)
-		err := s.state.Scanner.Process()
+	if len(s.state.Roots) > 0 {
+		s.state.OrgScanRoot = s.state.Roots[0]
+
+		// Process org files from every root into one index
+		slog.Info("Starting org file scan", "root", s.state.OrgScanRoot, "roots", s.state.Roots)
+		s.state.Scanner = orgscanner.NewOrgScanner(s.state.OrgScanRoot, s.state.Roots[1:]...)
+		s.state.Scanner.TodoKeywords = s.state.Config.TodoKeywords
+		err := s.scanWithProgress(ctx, params.WorkDoneToken)
 		if err != nil {
 			slog.Error("Failed to scan org files", "error", err)
 			return nil, err
@@ -120,7 +152,7 @@ func (s *ServerImpl) Initialize(ctx context.Context, params *protocol.Initialize
 	capabilities := protocol.ServerCapabilities{
 		TextDocumentSync: &protocol.TextDocumentSyncOptions{
 			OpenClose: true,
-			Change:    protocol.TextDocumentSyncKindFull,
+			Change:    protocol.TextDocumentSyncKindIncremental,
 			Save: &protocol.SaveOptions{
 				IncludeText: true,
 			},
@@ -133,13 +165,33 @@ func (s *ServerImpl) Initialize(ctx context.Context, params *protocol.Initialize
 		WorkspaceSymbolProvider:    true,
 		FoldingRangeProvider:       true,
 		CompletionProvider: &protocol.CompletionOptions{
-			TriggerCharacters: []string{":", "_"},
+			TriggerCharacters: []string{":", "_", "@", "\\", "[", "#", "+"},
+			ResolveProvider:   true,
+		},
+		SignatureHelpProvider: &protocol.SignatureHelpOptions{
+			TriggerCharacters:   []string{"("},
+			RetriggerCharacters: []string{","},
 		},
 		CodeActionProvider: true,
+		Workspace: &protocol.ServerCapabilitiesWorkspace{
+			WorkspaceFolders: &protocol.ServerCapabilitiesWorkspaceFolders{
+				Supported:           true,
+				ChangeNotifications: true,
+			},
+		},
 		DocumentLinkProvider: &protocol.DocumentLinkOptions{
 			ResolveProvider: false,
 		},
-		SelectionRangeProvider: true,
+		SelectionRangeProvider:     true,
+		CallHierarchyProvider:      true,
+		ColorProvider:              true,
+		LinkedEditingRangeProvider: true,
+		RenameProvider: &protocol.RenameOptions{
+			PrepareProvider: true,
+		},
+		ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
+			Commands: supportedCommands,
+		},
 	}
 
 	slog.Info("📤 Initialize response",
@@ -151,7 +203,13 @@ func (s *ServerImpl) Initialize(ctx context.Context, params *protocol.Initialize
 		"DocumentFormattingProvider", capabilities.DocumentFormattingProvider != nil,
 		"FoldingRangeProvider", capabilities.FoldingRangeProvider != nil,
 		"CompletionProvider", capabilities.CompletionProvider != nil,
-		"DocumentLinkProvider", capabilities.DocumentLinkProvider != nil)
+		"SignatureHelpProvider", capabilities.SignatureHelpProvider != nil,
+		"DocumentLinkProvider", capabilities.DocumentLinkProvider != nil,
+		"RenameProvider", capabilities.RenameProvider != nil,
+		"CallHierarchyProvider", capabilities.CallHierarchyProvider != nil,
+		"ColorProvider", capabilities.ColorProvider != nil,
+		"LinkedEditingRangeProvider", capabilities.LinkedEditingRangeProvider != nil,
+		"ExecuteCommandProvider", capabilities.ExecuteCommandProvider != nil)
 	return &protocol.InitializeResult{
 		Capabilities: capabilities,
 		ServerInfo: &protocol.ServerInfo{
@@ -161,19 +219,154 @@ func (s *ServerImpl) Initialize(ctx context.Context, params *protocol.Initialize
 	}, nil
 }
 
+// scanWithProgress runs the initial scan, reporting it as work done progress
+// on token if the client sent one with initialize. That token is the only
+// progress the spec allows before initialize has returned.
+func (s *ServerImpl) scanWithProgress(ctx context.Context, token *protocol.ProgressToken) error {
+	if token == nil || s.state.Client == nil {
+		return s.state.Scanner.Process()
+	}
+
+	notify := func(value any) {
+		if err := s.state.Client.Progress(ctx, &protocol.ProgressParams{Token: *token, Value: value}); err != nil {
+			slog.Debug("Failed to send indexing progress", "error", err)
+		}
+	}
+
+	notify(&protocol.WorkDoneProgressBegin{
+		Kind:       protocol.WorkDoneProgressKindBegin,
+		Title:      "Indexing org files",
+		Percentage: 0,
+	})
+	lastPercentage := uint32(0)
+	err := s.state.Scanner.ProcessWithProgress(func(done, total int) {
+		// Only report when the bar would actually move
+		percentage := uint32(done * 100 / total)
+		if percentage == lastPercentage {
+			return
+		}
+		lastPercentage = percentage
+		notify(&protocol.WorkDoneProgressReport{
+			Kind:       protocol.WorkDoneProgressKindReport,
+			Message:    fmt.Sprintf("%d/%d files", done, total),
+			Percentage: percentage,
+		})
+	})
+	notify(&protocol.WorkDoneProgressEnd{
+		Kind:    protocol.WorkDoneProgressKindEnd,
+		Message: fmt.Sprintf("Indexed %d UUIDs", countUUIDs(s.state.Scanner.ProcessedFiles)),
+	})
+	return err
+}
+
+// configureLogFile sends logs to the file named by ORG_LSP_LOG_FILE or the
+// logFile setting, if either is set, keeping stderr when it can't be opened
+func (s *ServerImpl) configureLogFile(level slog.Level) {
+	path := os.Getenv("ORG_LSP_LOG_FILE")
+	if path == "" {
+		path = s.state.Config.LogFile
+	}
+	if path == "" {
+		return
+	}
+
+	writer, err := newRotatingWriter(path, s.state.Config.LogFileLimit())
+	if err != nil {
+		slog.Error("Failed to open log file, logging to stderr", "path", path, "error", err)
+		return
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(writer, &slog.HandlerOptions{Level: level})))
+	if s.logFile != nil {
+		s.logFile.Close()
+	}
+	s.logFile = writer
+	slog.Info("Logging to file", "path", path)
+}
+
+// Exit stops Serve, so the process can exit with ExitCode
 func (s *ServerImpl) Exit(ctx context.Context) (err error) {
+	slog.Info("Exit received", "afterShutdown", s.shutdown.Load())
+	s.exitOnce.Do(func() { close(s.exited) })
 	return nil
 }
 
+// Shutdown records that the client asked the server to shut down, so a
+// following exit is a clean one
 func (s *ServerImpl) Shutdown(ctx context.Context) error {
+	slog.Info("Shutdown received")
+	s.shutdown.Store(true)
 	return nil
 }
 
+// Exited returns a channel that is closed once the client sends exit
+func (s *ServerImpl) Exited() <-chan struct{} {
+	return s.exited
+}
+
+// ExitCode is the status the process should exit with: 0 if the client
+// sent shutdown first, as the spec asks, and 1 otherwise
+func (s *ServerImpl) ExitCode() int {
+	if s.shutdown.Load() {
+		return 0
+	}
+	return 1
+}
+
+// Serve handles LSP messages on stream until the client disconnects or
+// sends exit, closing the connection in the latter case. It doesn't wait
+// for the connection's reader to stop, since a read from stdin can't be
+// interrupted.
+func (s *ServerImpl) Serve(ctx context.Context, stream jsonrpc2.Stream, logger *zap.Logger) error {
+	_, conn, client := protocol.NewServer(ctx, s, stream, logger)
+	s.SetClient(client)
+
+	select {
+	case <-conn.Done():
+		return conn.Err()
+	case <-s.Exited():
+		conn.Close()
+		return nil
+	}
+}
+
 func (s *ServerImpl) Initialized(ctx context.Context, params *protocol.InitializedParams) (err error) {
 	slog.Info("Server initialized")
+
+	// Ask the client to watch org files so the index stays fresh when they
+	// change outside the editor, and to report any file created or deleted
+	// so the #+INCLUDE: completion list can be rebuilt
+	if s.state != nil && s.state.WatchFiles && s.state.Client != nil {
+		err := s.state.Client.RegisterCapability(ctx, &protocol.RegistrationParams{
+			Registrations: []protocol.Registration{{
+				ID:     "org-lsp-watch-org-files",
+				Method: protocol.MethodWorkspaceDidChangeWatchedFiles,
+				RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{
+					Watchers: []protocol.FileSystemWatcher{
+						{GlobPattern: "**/*.org"},
+						{GlobPattern: "**/*", Kind: protocol.WatchKindCreate + protocol.WatchKindDelete},
+					},
+				},
+			}},
+		})
+		if err != nil {
+			slog.Warn("Failed to register file watchers", "error", err)
+		}
+	}
+
+	// The initial scan is done by now, so broken links across the whole
+	// workspace can be reported without waiting for files to be opened
+	s.revalidateWorkspace()
 	return nil
 }
 
+// revalidateWorkspace re-publishes workspace-wide link diagnostics in the
+// background, when enabled. Call it whenever the index has been refreshed.
+func (s *ServerImpl) revalidateWorkspace() {
+	if s.state != nil && s.state.Config.ValidateWorkspace {
+		go validateWorkspace(s.state)
+	}
+}
+
 func (s *ServerImpl) SetTrace(ctx context.Context, params *protocol.SetTraceParams) error {
 	slog.Info("Set trace", "value", params.Value)
 	return nil
@@ -191,14 +384,6 @@ func (s *ServerImpl) SelectionRange(ctx context.Context, params *protocol.Select
 	return []protocol.SelectionRange{}, nil
 }
 
-func (s *ServerImpl) ColorPresentation(ctx context.Context, params *protocol.ColorPresentationParams) (result []protocol.ColorPresentation, err error) {
-	return []protocol.ColorPresentation{}, nil
-}
-
-func (s *ServerImpl) CompletionResolve(ctx context.Context, params *protocol.CompletionItem) (result *protocol.CompletionItem, err error) {
-	return nil, nil
-}
-
 func (s *ServerImpl) Declaration(ctx context.Context, params *protocol.DeclarationParams) (result []protocol.Location /* Declaration | DeclarationLink[] | null */, err error) {
 	return []protocol.Location{}, nil
 }
@@ -213,31 +398,37 @@ func (s *ServerImpl) DidChange(ctx context.Context, params *protocol.DidChangeTe
 	uri := params.TextDocument.URI
 	slog.Info("Changing document", "uri", uri, "version", params.TextDocument.Version)
 
-	// For MVP, we only support full document sync through ContentChanges
-	if len(params.ContentChanges) > 0 {
-		change := params.ContentChanges[0]
-		slog.Debug("Change received", "change", change)
+	if len(params.ContentChanges) == 0 {
+		return nil
+	}
 
-		// Check if this is a full document change (RangeLength == 0 indicates full doc)
-		if change.RangeLength == 0 {
-			// Full document sync
-			text := change.Text
-			slog.Debug("Document change received (full sync)", "uri", uri, "textLen", len(text))
+	// Ranged changes are spliced into the stored buffer; a change without a
+	// range is a full document replacement
+	text, err := applyContentChanges(s.state.RawContent[uri], params.ContentChanges)
+	if err != nil {
+		slog.Error("Failed to apply document changes", "uri", uri, "error", err)
+		return err
+	}
+	slog.Debug("Document changes applied", "uri", uri, "changes", len(params.ContentChanges), "textLen", len(text))
+	// Track the client's line endings from any change that shows them; a
+	// full replacement without line breaks can only be \n
+	for _, change := range params.ContentChanges {
+		if crlf, ok := lineEnding(change.Text); ok || change.Range == nil {
+			s.state.CRLF[uri] = crlf
+		}
+	}
 
-			doc := org.New().Parse(strings.NewReader(text), string(uri))
+	doc := parseOrgDocument(text, string(uri), s.state.Config)
 
-			s.state.OpenDocs[uri] = doc
-			s.state.DocVersions[uri] = params.TextDocument.Version
-			s.state.RawContent[uri] = text
-			slog.Debug("RawContent updated", "uri", uri, "contentLen", len(text))
+	s.state.OpenDocs[uri] = doc
+	s.state.DocVersions[uri] = params.TextDocument.Version
+	s.state.RawContent[uri] = text
+	s.state.LineStarts[uri] = lineStarts(text)
+	slog.Debug("RawContent updated", "uri", uri, "contentLen", len(text))
 
-			// Publish diagnostics for the updated document
-			if s.state.Client != nil {
-				PublishDiagnosticsForDocument(s.state, uri, doc)
-			}
-		} else {
-			slog.Warn("Incremental document changes not supported", "uri", uri)
-		}
+	// Publish diagnostics for the updated document
+	if s.state.Client != nil {
+		PublishDiagnosticsForDocument(s.state, uri, doc)
 	}
 
 	return nil
@@ -247,11 +438,105 @@ func (s *ServerImpl) DidChangeConfiguration(ctx context.Context, params *protoco
 	return nil
 }
 
+// DidChangeWatchedFiles implements workspace/didChangeWatchedFiles.
+//
+// Keeps the index fresh when org files change outside the editor: created and
+// changed files are re-parsed, deleted files have their UUIDs and tags pruned.
+// Any file created or deleted also drops the cached #+INCLUDE: candidates.
 func (s *ServerImpl) DidChangeWatchedFiles(ctx context.Context, params *protocol.DidChangeWatchedFilesParams) (err error) {
+	if s.state == nil {
+		return nil
+	}
+
+	for _, change := range params.Changes {
+		if change.Type != protocol.FileChangeTypeChanged {
+			s.state.IncludeFiles.invalidate()
+		}
+	}
+
+	if s.state.Scanner == nil {
+		return nil
+	}
+
+	for _, change := range params.Changes {
+		absPath := uriToPath(string(change.URI))
+		if !strings.HasSuffix(absPath, ".org") {
+			continue
+		}
+		relPath, err := filepath.Rel(s.state.OrgScanRoot, absPath)
+		if err != nil {
+			continue
+		}
+
+		slog.Debug("Watched file changed", "path", relPath, "type", change.Type)
+		switch change.Type {
+		case protocol.FileChangeTypeCreated, protocol.FileChangeTypeChanged:
+			if err := s.state.Scanner.UpdateFile(relPath); err != nil {
+				slog.Error("Failed to re-index watched file", "path", relPath, "error", err)
+			}
+		case protocol.FileChangeTypeDeleted:
+			s.state.Scanner.RemoveFile(relPath)
+		}
+	}
+	s.revalidateWorkspace()
 	return nil
 }
 
+// DidChangeWorkspaceFolders implements workspace/didChangeWorkspaceFolders.
+//
+// Added folders are scanned into the shared index; removed folders have their
+// files pruned from it. Index paths are relative to the first root, so if it
+// is removed the index is rebuilt on the next remaining root, or dropped when
+// no roots remain.
 func (s *ServerImpl) DidChangeWorkspaceFolders(ctx context.Context, params *protocol.DidChangeWorkspaceFoldersParams) (err error) {
+	if s.state == nil {
+		return nil
+	}
+
+	s.state.Mu.Lock()
+	for _, folder := range params.Event.Removed {
+		root := uriToPath(folder.URI)
+		s.state.Roots = slices.DeleteFunc(s.state.Roots, func(r string) bool { return r == root })
+		if s.state.Scanner != nil {
+			s.state.Scanner.RemoveRoot(root)
+		}
+	}
+	if !slices.Contains(s.state.Roots, s.state.OrgScanRoot) {
+		if len(s.state.Roots) > 0 {
+			s.state.OrgScanRoot = s.state.Roots[0]
+			s.state.Scanner = orgscanner.NewOrgScanner(s.state.OrgScanRoot, s.state.Roots[1:]...)
+			s.state.Scanner.TodoKeywords = s.state.Config.TodoKeywords
+		} else {
+			s.state.OrgScanRoot = ""
+			s.state.Scanner = nil
+		}
+	}
+	for _, folder := range params.Event.Added {
+		root := uriToPath(folder.URI)
+		if slices.Contains(s.state.Roots, root) {
+			continue
+		}
+		s.state.Roots = append(s.state.Roots, root)
+		if s.state.Scanner == nil {
+			s.state.OrgScanRoot = root
+			s.state.Scanner = orgscanner.NewOrgScanner(root)
+			s.state.Scanner.TodoKeywords = s.state.Config.TodoKeywords
+		} else {
+			s.state.Scanner.AddRoot(root)
+		}
+	}
+	scanner := s.state.Scanner
+	s.state.Mu.Unlock()
+
+	if scanner == nil {
+		return nil
+	}
+
+	slog.Info("Re-scanning org files after workspace folder change", "added", len(params.Event.Added), "removed", len(params.Event.Removed))
+	if err := scanner.Process(); err != nil {
+		slog.Error("Failed to re-scan org files", "error", err)
+	}
+	s.revalidateWorkspace()
 	return nil
 }
 
@@ -268,6 +553,8 @@ func (s *ServerImpl) DidClose(ctx context.Context, params *protocol.DidCloseText
 	delete(s.state.OpenDocs, uri)
 	delete(s.state.DocVersions, uri)
 	delete(s.state.RawContent, uri)
+	delete(s.state.CRLF, uri)
+	delete(s.state.LineStarts, uri)
 	return nil
 }
 
@@ -283,13 +570,16 @@ func (s *ServerImpl) DidOpen(ctx context.Context, params *protocol.DidOpenTextDo
 	uri := params.TextDocument.URI
 	slog.Info("Opening document", "uri", uri, "version", params.TextDocument.Version, "textLength", len(params.TextDocument.Text))
 
-	// Parse the document content
-	text := params.TextDocument.Text
-	doc := org.New().Parse(strings.NewReader(text), string(uri))
+	// Parse the document content, kept with \n line endings only so line
+	// splitting and prefix checks don't trip over a trailing \r
+	text := normalizeLineEndings(params.TextDocument.Text)
+	doc := parseOrgDocument(text, string(uri), s.state.Config)
 
 	s.state.OpenDocs[uri] = doc
 	s.state.DocVersions[uri] = params.TextDocument.Version
 	s.state.RawContent[uri] = text
+	s.state.LineStarts[uri] = lineStarts(text)
+	s.state.CRLF[uri] = strings.Contains(params.TextDocument.Text, "\r\n")
 
 	// Publish diagnostics for broken links
 	if s.state.Client != nil {
@@ -312,6 +602,7 @@ func (s *ServerImpl) DidSave(ctx context.Context, params *protocol.DidSaveTextDo
 				return true
 			})
 			slog.Info("Completed org file re-scan", "files_scanned", fileCount, "uuids_indexed", countUUIDs(s.state.Scanner.ProcessedFiles))
+			s.revalidateWorkspace()
 		}
 	}
 
@@ -327,14 +618,6 @@ func (s *ServerImpl) DidSave(ctx context.Context, params *protocol.DidSaveTextDo
 	return nil
 }
 
-func (s *ServerImpl) DocumentColor(ctx context.Context, params *protocol.DocumentColorParams) (result []protocol.ColorInformation, err error) {
-	return []protocol.ColorInformation{}, nil
-}
-
-func (s *ServerImpl) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCommandParams) (result interface{}, err error) {
-	return nil, nil
-}
-
 func (s *ServerImpl) Implementation(ctx context.Context, params *protocol.ImplementationParams) (result []protocol.Location, err error) {
 	return []protocol.Location{}, nil
 }
@@ -343,18 +626,6 @@ func (s *ServerImpl) OnTypeFormatting(ctx context.Context, params *protocol.Docu
 	return []protocol.TextEdit{}, nil
 }
 
-func (s *ServerImpl) PrepareRename(ctx context.Context, params *protocol.PrepareRenameParams) (result *protocol.Range, err error) {
-	return nil, nil
-}
-
-func (s *ServerImpl) Rename(ctx context.Context, params *protocol.RenameParams) (result *protocol.WorkspaceEdit, err error) {
-	return nil, nil
-}
-
-func (s *ServerImpl) SignatureHelp(ctx context.Context, params *protocol.SignatureHelpParams) (result *protocol.SignatureHelp, err error) {
-	return nil, nil
-}
-
 func (s *ServerImpl) TypeDefinition(ctx context.Context, params *protocol.TypeDefinitionParams) (result []protocol.Location, err error) {
 	return []protocol.Location{}, nil
 }
@@ -395,18 +666,6 @@ func (s *ServerImpl) CodeLensRefresh(ctx context.Context) (err error) {
 	return nil
 }
 
-func (s *ServerImpl) PrepareCallHierarchy(ctx context.Context, params *protocol.CallHierarchyPrepareParams) (result []protocol.CallHierarchyItem, err error) {
-	return []protocol.CallHierarchyItem{}, nil
-}
-
-func (s *ServerImpl) IncomingCalls(ctx context.Context, params *protocol.CallHierarchyIncomingCallsParams) (result []protocol.CallHierarchyIncomingCall, err error) {
-	return []protocol.CallHierarchyIncomingCall{}, nil
-}
-
-func (s *ServerImpl) OutgoingCalls(ctx context.Context, params *protocol.CallHierarchyOutgoingCallsParams) (result []protocol.CallHierarchyOutgoingCall, err error) {
-	return []protocol.CallHierarchyOutgoingCall{}, nil
-}
-
 func (s *ServerImpl) SemanticTokensFull(ctx context.Context, params *protocol.SemanticTokensParams) (result *protocol.SemanticTokens, err error) {
 	return nil, nil
 }
@@ -423,18 +682,10 @@ func (s *ServerImpl) SemanticTokensRefresh(ctx context.Context) (err error) {
 	return nil
 }
 
-func (s *ServerImpl) LinkedEditingRange(ctx context.Context, params *protocol.LinkedEditingRangeParams) (result *protocol.LinkedEditingRanges, err error) {
-	return nil, nil
-}
-
 func (s *ServerImpl) Moniker(ctx context.Context, params *protocol.MonikerParams) (result []protocol.Moniker, err error) {
 	return []protocol.Moniker{}, nil
 }
 
-func (s *ServerImpl) Request(ctx context.Context, method string, params interface{}) (result interface{}, err error) {
-	return nil, nil
-}
-
 func (s *ServerImpl) WorkDoneProgressCancel(ctx context.Context, params *protocol.WorkDoneProgressCancelParams) (err error) {
 	return nil
 }
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+func TestRemovingTheFirstWorkspaceFolder(t *testing.T) {
+	work, personal := t.TempDir(), t.TempDir()
+	testza.AssertNoError(t, os.WriteFile(filepath.Join(work, "projects.org"), []byte("* Projects\n"), 0o644))
+	testza.AssertNoError(t, os.WriteFile(filepath.Join(personal, "garden.org"), []byte("* Garden\n"), 0o644))
+
+	scanner := orgscanner.NewOrgScanner(work, personal)
+	testza.AssertNoError(t, scanner.Process())
+	s := New()
+	s.state = &State{
+		OrgScanRoot: work,
+		Roots:       []string{work, personal},
+		Scanner:     scanner,
+		OpenDocs:    map[protocol.DocumentURI]*org.Document{},
+		RawContent:  map[protocol.DocumentURI]string{},
+	}
+
+	remove := func(root string) {
+		testza.AssertNoError(t, s.DidChangeWorkspaceFolders(context.Background(), &protocol.DidChangeWorkspaceFoldersParams{
+			Event: protocol.WorkspaceFoldersChangeEvent{
+				Removed: []protocol.WorkspaceFolder{{URI: pathToURI(root), Name: filepath.Base(root)}},
+			},
+		}))
+	}
+
+	remove(work)
+	testza.AssertEqual(t, personal, s.state.OrgScanRoot)
+	testza.AssertEqual(t, personal, s.state.Scanner.Root)
+	_, ok := s.state.Scanner.ProcessedFiles.Files.Load("garden.org")
+	testza.AssertTrue(t, ok, "Index paths should be relative to the remaining root")
+	_, ok = s.state.Scanner.ProcessedFiles.Files.Load("projects.org")
+	testza.AssertFalse(t, ok, "Files of the removed root should be pruned")
+
+	remove(personal)
+	testza.AssertEqual(t, "", s.state.OrgScanRoot)
+	testza.AssertNil(t, s.state.Scanner)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// MethodStartupVisibility is the custom request clients send to learn how a
+// document's #+STARTUP: keyword wants it folded when opened, since
+// FoldingRange has no way to say which ranges start collapsed. It takes
+// {"textDocument": {"uri": ...}}.
+const MethodStartupVisibility = "org/startupVisibility"
+
+// StartupVisibility is the result of an org/startupVisibility request
+type StartupVisibility struct {
+	// Visibility is overview, content, or showall, or empty when the
+	// document doesn't set one and the client's default applies
+	Visibility string `json:"visibility"`
+}
+
+// startupVisibility answers an org/startupVisibility request, preferring
+// the open buffer, which may have changed since the file was indexed
+func (s *ServerImpl) startupVisibility(params any) (StartupVisibility, error) {
+	uri, err := decodeTextDocumentParam(params)
+	if err != nil {
+		return StartupVisibility{}, err
+	}
+	if s.state == nil {
+		return StartupVisibility{}, nil
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	if doc, ok := s.state.OpenDocs[uri]; ok {
+		return StartupVisibility{Visibility: orgscanner.StartupVisibility(doc)}, nil
+	}
+
+	if s.state.Scanner == nil || s.state.Scanner.ProcessedFiles == nil {
+		return StartupVisibility{}, nil
+	}
+	relPath, err := filepath.Rel(s.state.OrgScanRoot, uriToPath(string(uri)))
+	if err != nil {
+		return StartupVisibility{}, nil
+	}
+	if value, ok := s.state.Scanner.ProcessedFiles.Files.Load(relPath); ok {
+		if fileInfo, ok := value.(*orgscanner.FileInfo); ok {
+			return StartupVisibility{Visibility: fileInfo.Startup}, nil
+		}
+	}
+	return StartupVisibility{}, nil
+}
+
+// decodeTextDocumentParam extracts the document URI from custom request
+// params shaped like {"textDocument": {"uri": ...}}
+func decodeTextDocumentParam(params any) (protocol.DocumentURI, error) {
+	fields, _ := params.(map[string]any)
+	textDocument, _ := fields["textDocument"].(map[string]any)
+	uri, _ := textDocument["uri"].(string)
+	if uri == "" {
+		return "", fmt.Errorf("expected {\"textDocument\": {\"uri\": ...}} params, got %v", params)
+	}
+	return protocol.DocumentURI(uri), nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// readingWordsPerMinute is the reading speed reading time estimates assume
+const readingWordsPerMinute = 200
+
+// subtreeStatsHover describes the size of the subtree whose headline line
+// the cursor is on: its word count, descendant headings, and reading time.
+// Only used when Config.SubtreeStatsHover is set.
+func subtreeStatsHover(doc *org.Document, pos protocol.Position) (*protocol.Hover, bool) {
+	headline, found := findNodeAtPosition[org.Headline](doc, pos)
+	if !found || headline.Pos.StartLine != int(pos.Line) {
+		return nil, false
+	}
+
+	words := countWords(headline.Children)
+	headings := countHeadings(headline.Children)
+
+	readingTime := "under a minute"
+	switch minutes := (words + readingWordsPerMinute/2) / readingWordsPerMinute; {
+	case minutes == 1:
+		readingTime = "about 1 minute"
+	case minutes > 1:
+		readingTime = fmt.Sprintf("about %d minutes", minutes)
+	}
+
+	content := fmt.Sprintf("**Subtree statistics**\n\n- Words: %d\n- Headings: %d\n- Reading time: %s",
+		words, headings, readingTime)
+
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{Kind: protocol.Markdown, Value: content},
+	}, true
+}
+
+// countHeadings returns how many headlines are in nodes, at any depth
+func countHeadings(nodes []org.Node) int {
+	count := 0
+	for _, node := range nodes {
+		if headline, ok := node.(org.Headline); ok {
+			count += 1 + countHeadings(headline.Children)
+		}
+	}
+	return count
+}
+
+// countWords returns how many words of prose are in nodes, at any depth.
+// Code, examples, exports, drawers, and keywords aren't prose, and heading
+// titles aren't counted, only their bodies.
+func countWords(nodes []org.Node) int {
+	count := 0
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case org.Text:
+			count += len(strings.Fields(n.Content))
+		case org.Headline:
+			count += countWords(n.Children)
+		case org.Block:
+			switch strings.ToUpper(n.Name) {
+			case "SRC", "EXAMPLE", "EXPORT":
+			default:
+				count += countWords(n.Children)
+			}
+		case org.PropertyDrawer, org.Drawer, org.Keyword, org.InlineBlock:
+		default:
+			node.Range(func(child org.Node) bool {
+				count += countWords([]org.Node{child})
+				return true
+			})
+		}
+	}
+	return count
+}
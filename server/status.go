@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// MethodStatus is the custom request clients send to observe indexing
+// progress
+const MethodStatus = "org/status"
+
+// ScanStatus is the result of an org/status request
+type ScanStatus struct {
+	LastScanTime time.Time `json:"lastScanTime"`
+	FileCount    int       `json:"fileCount"`
+	UUIDCount    int       `json:"uuidCount"`
+	TagCount     int       `json:"tagCount"`
+}
+
+// Request handles non-standard requests
+func (s *ServerImpl) Request(ctx context.Context, method string, params any) (result any, err error) {
+	switch method {
+	case MethodStatus:
+		return s.scanStatus(), nil
+	case MethodStartupVisibility:
+		return s.startupVisibility(params)
+	default:
+		slog.Debug("Unhandled custom request", "method", method)
+		return nil, nil
+	}
+}
+
+func (s *ServerImpl) scanStatus() ScanStatus {
+	status := ScanStatus{LastScanTime: s.LastScanTime()}
+	if s.state == nil {
+		return status
+	}
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+	if s.state.Scanner == nil || s.state.Scanner.ProcessedFiles == nil {
+		return status
+	}
+
+	processed := s.state.Scanner.ProcessedFiles
+	processed.Files.Range(func(_, _ any) bool {
+		status.FileCount++
+		return true
+	})
+	processed.UuidIndex.Range(func(_, _ any) bool {
+		status.UUIDCount++
+		return true
+	})
+	status.TagCount = len(processed.TagMap)
+	return status
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/alexispurslane/go-org/org"
 	"github.com/alexispurslane/org-lsp/orgscanner"
@@ -28,7 +32,7 @@ func (s *ServerImpl) DocumentSymbol(ctx context.Context, params *protocol.Docume
 	}
 
 	// Convert outline sections to document symbols
-	symbols := sectionsToSymbols(doc.Outline.Children)
+	symbols := sectionsToSymbols(doc.Outline.Children, doneKeywords(doc), nil)
 
 	// Convert []DocumentSymbol to []interface{}
 	result = make([]interface{}, len(symbols))
@@ -40,6 +44,16 @@ func (s *ServerImpl) DocumentSymbol(ctx context.Context, params *protocol.Docume
 	return result, nil
 }
 
+// maxWorkspaceSymbols caps workspace/symbol results so broad queries in large
+// workspaces stay fast
+const maxWorkspaceSymbols = 200
+
+// scoredSymbol pairs a workspace symbol with its fuzzy match score
+type scoredSymbol struct {
+	symbol protocol.SymbolInformation
+	score  int
+}
+
 func (s *ServerImpl) Symbols(ctx context.Context, params *protocol.WorkspaceSymbolParams) (result []protocol.SymbolInformation, err error) {
 	slog.Info("🔍 WORKSPACE/SYMBOL HANDLER CALLED", "query", params.Query, "queryEmpty", params.Query == "")
 
@@ -55,43 +69,50 @@ func (s *ServerImpl) Symbols(ctx context.Context, params *protocol.WorkspaceSymb
 	}
 
 	query := strings.ToLower(params.Query)
-	var symbols []protocol.SymbolInformation
+	// A #tag query matches headings carrying that tag, including inherited ones
+	tagQuery, byTag := strings.CutPrefix(query, "#")
+	var scored []scoredSymbol
 	matchCount := 0
 	skipCount := 0
 
-	// Iterate through all UUID-indexed headers
-	s.state.Scanner.ProcessedFiles.UuidIndex.Range(func(key, value any) bool {
-		uuidKey, ok := key.(orgscanner.UUID)
-		if !ok {
-			slog.Warn("⚠️ UUID key is not an orgscanner.UUID", "key", key, "keyType", fmt.Sprintf("%T", key))
-			return true
-		}
-		uuid := string(uuidKey)
-
-		location, ok := value.(orgscanner.HeaderLocation)
+	// Iterate through every indexed heading, with or without an ID
+	s.state.Scanner.ProcessedFiles.HeadingIndex.Range(func(key, value any) bool {
+		locations, ok := value.([]orgscanner.HeaderLocation)
 		if !ok {
-			slog.Warn("⚠️ Value is not HeaderLocation", "uuid", uuid, "valueType", fmt.Sprintf("%T", value))
-			skipCount++
+			slog.Warn("⚠️ Value is not []HeaderLocation", "path", key, "valueType", fmt.Sprintf("%T", value))
 			return true // Skip invalid entries
 		}
 
-		slog.Debug("Processing entry", "uuid", uuid, "title", location.Title, "filePath", location.FilePath)
+		for _, location := range locations {
+			slog.Debug("Processing entry", "title", location.Title, "filePath", location.FilePath)
 
-		// Substring match on title
-		titleLower := strings.ToLower(location.Title)
-		matches := query == "" || strings.Contains(titleLower, query)
+			// Match on tags for #tag queries, otherwise fuzzy subsequence match on title
+			score, matches := 0, false
+			if byTag {
+				matches = slices.ContainsFunc(location.Tags, func(tag string) bool { return strings.EqualFold(tag, tagQuery) })
+			} else {
+				score, matches = fuzzyScore(query, location.Title)
+			}
 
-		if !matches {
-			slog.Debug("❌ No match", "title", location.Title, "query", query)
-			skipCount++
-		} else {
-			slog.Info("✅ MATCH FOUND", "title", location.Title, "query", query, "uuid", uuid)
-			uri := pathToURI(location.FilePath)
-			slog.Debug("Converted path to URI", "path", location.FilePath, "uri", uri)
+			if !matches {
+				slog.Debug("❌ No match", "title", location.Title, "query", query)
+				skipCount++
+				continue
+			}
+
+			slog.Debug("✅ MATCH FOUND", "title", location.Title, "query", query)
+			uri := pathToURI(indexPathToAbs(s.state, location.FilePath))
+
+			// Group under the parent heading, or the file for top-level ones
+			container := location.Parent
+			if container == "" {
+				container = filepath.Base(location.FilePath)
+			}
 
 			symbol := protocol.SymbolInformation{
-				Name: location.Title,
-				Kind: levelToSymbolKind(location.Level),
+				Name:          location.Title,
+				Kind:          levelToSymbolKind(location.Level, "", nil),
+				ContainerName: container,
 				Location: protocol.Location{
 					URI: protocol.DocumentURI(uri),
 					Range: protocol.Range{
@@ -106,12 +127,55 @@ func (s *ServerImpl) Symbols(ctx context.Context, params *protocol.WorkspaceSymb
 					},
 				},
 			}
-			symbols = append(symbols, symbol)
+			scored = append(scored, scoredSymbol{symbol: symbol, score: score})
 			matchCount++
 		}
 		return true // Continue iteration
 	})
 
+	// Files match on their #+TITLE, so users can jump to a file by name
+	if !byTag {
+		s.state.Scanner.ProcessedFiles.Files.Range(func(key, value any) bool {
+			fileInfo, ok := value.(*orgscanner.FileInfo)
+			if !ok || fileInfo.DocTitle == "" {
+				return true
+			}
+
+			score, matches := fuzzyScore(query, fileInfo.DocTitle)
+			if !matches {
+				skipCount++
+				return true
+			}
+
+			uri := pathToURI(indexPathToAbs(s.state, fileInfo.Path))
+			symbol := protocol.SymbolInformation{
+				Name:          fileInfo.DocTitle,
+				Kind:          protocol.SymbolKindFile,
+				ContainerName: filepath.Base(fileInfo.Path),
+				Location:      protocol.Location{URI: protocol.DocumentURI(uri)},
+			}
+			scored = append(scored, scoredSymbol{symbol: symbol, score: score})
+			matchCount++
+			return true
+		})
+	}
+
+	// Best matches first, ties broken by name for a stable order
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].symbol.Name < scored[j].symbol.Name
+	})
+	if len(scored) > maxWorkspaceSymbols {
+		scored = scored[:maxWorkspaceSymbols]
+	}
+
+	symbols := make([]protocol.SymbolInformation, len(scored))
+	for i, match := range scored {
+		symbols[i] = match.symbol
+	}
+
 	slog.Info("🏁 WORKSPACE/SYMBOL COMPLETE",
 		"query", query,
 		"symbolsReturned", len(symbols),
@@ -120,8 +184,62 @@ func (s *ServerImpl) Symbols(ctx context.Context, params *protocol.WorkspaceSymb
 	return symbols, nil
 }
 
-// sectionsToSymbols converts a slice of org.Section to DocumentSymbol slice
-func sectionsToSymbols(sections []*org.Section) []protocol.DocumentSymbol {
+// fuzzyScore reports whether every rune of query appears in target in order
+// (case-insensitively), and scores the match. Consecutive runs, matches at
+// the start of words, and a match at the very start of target score higher;
+// gaps and unmatched length lower the score. An empty query matches
+// everything with a score of zero.
+func fuzzyScore(query, target string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	queryRunes := []rune(strings.ToLower(query))
+	targetRunes := []rune(strings.ToLower(target))
+
+	score := 0
+	qi := 0
+	lastMatch := -1
+	for ti, r := range targetRunes {
+		if qi == len(queryRunes) {
+			break
+		}
+		if r != queryRunes[qi] {
+			continue
+		}
+
+		score += 1
+		switch {
+		case ti == 0:
+			score += 8
+		case !unicode.IsLetter(targetRunes[ti-1]) && !unicode.IsDigit(targetRunes[ti-1]):
+			score += 6
+		}
+		if lastMatch >= 0 {
+			if ti == lastMatch+1 {
+				score += 5
+			} else {
+				score -= min(ti-lastMatch-1, 3)
+			}
+		}
+		lastMatch = ti
+		qi++
+	}
+
+	if qi < len(queryRunes) {
+		return 0, false
+	}
+
+	// Prefer shorter titles among otherwise equal matches
+	score -= (len(targetRunes) - len(queryRunes)) / 8
+	return score, true
+}
+
+// sectionsToSymbols converts a slice of org.Section to DocumentSymbol slice.
+// done is the document's set of done keywords, see doneKeywords. When keep
+// is non-nil, only sections it accepts are included, along with their
+// ancestors so the tree structure is preserved.
+func sectionsToSymbols(sections []*org.Section, done map[string]bool, keep func(*org.Section) bool) []protocol.DocumentSymbol {
 	if len(sections) == 0 {
 		return nil
 	}
@@ -132,22 +250,26 @@ func sectionsToSymbols(sections []*org.Section) []protocol.DocumentSymbol {
 			continue
 		}
 
-		symbol := sectionToSymbol(section)
+		symbol := sectionToSymbol(section, done, keep)
+		if keep != nil && !keep(section) && len(symbol.Children) == 0 {
+			continue
+		}
 		symbols = append(symbols, symbol)
 	}
 
 	return symbols
 }
 
-// sectionToSymbol converts a single org.Section to DocumentSymbol
-func sectionToSymbol(section *org.Section) protocol.DocumentSymbol {
+// sectionToSymbol converts a single org.Section to DocumentSymbol, filtering
+// its children with keep as in sectionsToSymbols
+func sectionToSymbol(section *org.Section, done map[string]bool, keep func(*org.Section) bool) protocol.DocumentSymbol {
 	headline := section.Headline
 
 	// Render title nodes to string
 	name := renderNodesToString(headline.Title)
 
-	// Map heading level to SymbolKind
-	kind := levelToSymbolKind(headline.Lvl)
+	// Map heading level and TODO state to SymbolKind
+	kind := levelToSymbolKind(headline.Lvl, headline.Status, done)
 
 	// Create range from headline position
 	selectionRange := protocol.Range{
@@ -177,14 +299,23 @@ func sectionToSymbol(section *org.Section) protocol.DocumentSymbol {
 		Kind:           kind,
 		Range:          fullRange,
 		SelectionRange: selectionRange,
-		Children:       sectionsToSymbols(section.Children),
+		Children:       sectionsToSymbols(section.Children, done, keep),
 	}
 
 	return symbol
 }
 
-// levelToSymbolKind maps org heading levels to LSP SymbolKind
-func levelToSymbolKind(lvl int) protocol.SymbolKind {
+// levelToSymbolKind maps org heading levels to LSP SymbolKind. Tasks are
+// told apart from notes whatever their level: headings with a TODO keyword
+// are events, and those whose keyword is in done are constants.
+func levelToSymbolKind(lvl int, status string, done map[string]bool) protocol.SymbolKind {
+	switch {
+	case status != "" && done[status]:
+		return protocol.SymbolKindConstant
+	case status != "":
+		return protocol.SymbolKindEvent
+	}
+
 	switch lvl {
 	case 1:
 		return protocol.SymbolKindNamespace
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// TangledFile is one file written by the org.tangle command
+type TangledFile struct {
+	Path   string `json:"path"`   // Absolute path of the written file
+	Blocks int    `json:"blocks"` // Number of src blocks tangled into it
+}
+
+// tangleCommand writes every src block of the document at [uri] that has a
+// :tangle header argument to its target file. Blocks sharing a target are
+// concatenated in document order. Relative targets are resolved against the
+// document's directory, and missing directories are created. Unless
+// Config.TangleOutsideWorkspace is set, nothing is written when any target
+// is outside the workspace roots.
+func (s *ServerImpl) tangleCommand(ctx context.Context, args []any) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("expected [uri] argument, got %d", len(args))
+	}
+	uri, ok := args[0].(string)
+	if !ok || uri == "" {
+		return nil, fmt.Errorf("invalid uri argument: %v", args[0])
+	}
+
+	s.state.Mu.RLock()
+	doc, _, err := documentForURI(s.state, protocol.DocumentURI(uri))
+	s.state.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	docDir := filepath.Dir(uriToPath(uri))
+	var order []string
+	contents := make(map[string]*strings.Builder)
+	counts := make(map[string]int)
+	for _, block := range collectSrcBlocks(doc) {
+		target := tangleTarget(block.Parameters)
+		if target == "" {
+			continue
+		}
+		if strings.HasPrefix(target, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				target = filepath.Join(home, target[2:])
+			}
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(docDir, target)
+		}
+		target = filepath.Clean(target)
+
+		builder, seen := contents[target]
+		if !seen {
+			builder = &strings.Builder{}
+			contents[target] = builder
+			order = append(order, target)
+		} else {
+			// org separates consecutive blocks in one file with a blank line
+			builder.WriteString("\n")
+		}
+		code := org.String(block.Children...)
+		builder.WriteString(code)
+		if !strings.HasSuffix(code, "\n") {
+			builder.WriteString("\n")
+		}
+		counts[target]++
+	}
+
+	s.state.Mu.RLock()
+	outside := slices.IndexFunc(order, func(target string) bool { return !inWorkspace(s.state, target) })
+	s.state.Mu.RUnlock()
+	if outside >= 0 && !s.state.Config.TangleOutsideWorkspace {
+		return nil, fmt.Errorf("tangle target %s is outside the workspace; set tangleOutsideWorkspace to allow it", order[outside])
+	}
+
+	files := []TangledFile{}
+	for _, target := range order {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		if err := os.WriteFile(target, []byte(contents[target].String()), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		files = append(files, TangledFile{Path: target, Blocks: counts[target]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	slog.Info("Tangled src blocks", "uri", uri, "files", len(files))
+	return files, nil
+}
+
+// collectSrcBlocks returns every src block in doc in document order
+func collectSrcBlocks(doc *org.Document) []org.Block {
+	var blocks []org.Block
+	var walkNodes func(node org.Node)
+	walkNodes = func(node org.Node) {
+		if block, ok := node.(org.Block); ok && strings.EqualFold(block.Name, "SRC") {
+			blocks = append(blocks, block)
+		}
+		node.Range(func(n org.Node) bool {
+			walkNodes(n)
+			return true
+		})
+	}
+	for _, node := range doc.Nodes {
+		walkNodes(node)
+	}
+	return blocks
+}
+
+// tangleTarget returns the file named by the :tangle header argument in a
+// src block's parameters, or "" when there is none or it is "no". A quoted
+// file name may contain spaces, which go-org splits into separate
+// parameters. :tangle yes is skipped too, since its file name depends on
+// the language's usual extension.
+func tangleTarget(params []string) string {
+	for i := 0; i+1 < len(params); i++ {
+		if params[i] != ":tangle" {
+			continue
+		}
+		target := params[i+1]
+		if strings.HasPrefix(target, `"`) {
+			for j := i + 2; j < len(params) && (len(target) == 1 || !strings.HasSuffix(target, `"`)); j++ {
+				target += " " + params[j]
+			}
+			target = strings.Trim(target, `"`)
+		}
+		if target == "no" || target == "yes" {
+			return ""
+		}
+		return target
+	}
+	return ""
+}
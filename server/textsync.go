@@ -0,0 +1,170 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// applyContentChanges applies didChange events to text in order. An event
+// without a range replaces the whole document; ranged events are spliced in.
+// Inserted text has its line endings normalized like the document's.
+func applyContentChanges(text string, changes []protocol.TextDocumentContentChangeEvent) (string, error) {
+	for _, change := range changes {
+		if change.Range == nil {
+			text = normalizeLineEndings(change.Text)
+			continue
+		}
+
+		start, err := positionToOffset(text, change.Range.Start)
+		if err != nil {
+			return "", fmt.Errorf("invalid change start: %w", err)
+		}
+		end, err := positionToOffset(text, change.Range.End)
+		if err != nil {
+			return "", fmt.Errorf("invalid change end: %w", err)
+		}
+		if end < start {
+			return "", fmt.Errorf("change range ends before it starts: %v", *change.Range)
+		}
+
+		text = text[:start] + normalizeLineEndings(change.Text) + text[end:]
+	}
+	return text, nil
+}
+
+// lineEnding reports whether text a client sent breaks lines with \r\n
+// rather than \n. ok is false when text has no line break to tell by.
+func lineEnding(text string) (crlf, ok bool) {
+	idx := strings.IndexByte(text, '\n')
+	if idx < 0 {
+		return false, false
+	}
+	return idx > 0 && text[idx-1] == '\r', true
+}
+
+// clientText converts text, which like all server-side text uses \n line
+// endings, to the line endings the client holds uri with
+func clientText(state *State, uri protocol.DocumentURI, text string) string {
+	if !state.CRLF[uri] {
+		return text
+	}
+	return strings.ReplaceAll(text, "\n", "\r\n")
+}
+
+// clientEdit converts the new text of every edit in edit to the line
+// endings the client holds its document with; see clientText. Every edit
+// sent to the client goes through here, so CRLF documents never end up
+// with mixed line endings.
+func clientEdit(state *State, edit *protocol.WorkspaceEdit) {
+	if edit == nil {
+		return
+	}
+	for uri, edits := range edit.Changes {
+		for i := range edits {
+			edits[i].NewText = clientText(state, uri, edits[i].NewText)
+		}
+	}
+	for _, docEdit := range edit.DocumentChanges {
+		uri := docEdit.TextDocument.URI
+		for i, e := range docEdit.Edits {
+			switch e := e.(type) {
+			case protocol.TextEdit:
+				e.NewText = clientText(state, uri, e.NewText)
+				docEdit.Edits[i] = e
+			case protocol.AnnotatedTextEdit:
+				e.NewText = clientText(state, uri, e.NewText)
+				docEdit.Edits[i] = e
+			case protocol.SnippetTextEdit:
+				e.Snippet.Value = clientText(state, uri, e.Snippet.Value)
+				docEdit.Edits[i] = e
+			}
+		}
+	}
+}
+
+// normalizeLineEndings converts \r\n and lone \r line endings to \n. LSP
+// counts all three as one line break and none as characters, so positions
+// into the client's text stay valid in the normalized text.
+func normalizeLineEndings(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// positionToOffset converts an LSP position to a byte offset in text. LSP
+// characters count UTF-16 code units, so multi-byte runes are walked rather
+// than assuming one byte per character. Characters past the end of a line
+// clamp to the line end, as the spec requires.
+func positionToOffset(text string, pos protocol.Position) (int, error) {
+	offset := 0
+	for line := uint32(0); line < pos.Line; line++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next == -1 {
+			return 0, fmt.Errorf("line %d out of range", pos.Line)
+		}
+		offset += next + 1
+	}
+
+	lineEnd := len(text)
+	if next := strings.IndexByte(text[offset:], '\n'); next != -1 {
+		lineEnd = offset + next
+	}
+
+	units := uint32(0)
+	for offset < lineEnd && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(text[offset:lineEnd])
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+		offset += size
+	}
+	return offset, nil
+}
+
+// lineStarts returns the byte offset at which each line of text starts
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// documentLine returns line of an open document, without its line break,
+// using the document's line index rather than splitting its whole text
+func documentLine(state *State, uri protocol.DocumentURI, line int) (string, bool) {
+	content, found := state.RawContent[uri]
+	starts, indexed := state.LineStarts[uri]
+	if !found || !indexed || line < 0 || line >= len(starts) {
+		return "", false
+	}
+	end := len(content)
+	if line+1 < len(starts) {
+		end = starts[line+1] - 1
+	}
+	return content[starts[line]:end], true
+}
+
+// documentForURI returns the parsed document and raw content for uri,
+// preferring the open buffer over the file on disk
+func documentForURI(state *State, uri protocol.DocumentURI) (*org.Document, string, error) {
+	if doc, ok := state.OpenDocs[uri]; ok {
+		return doc, state.RawContent[uri], nil
+	}
+	data, err := os.ReadFile(uriToPath(string(uri)))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	content := string(data)
+	return parseOrgDocument(content, uriToPath(string(uri)), state.Config), content, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+const testDocURI = protocol.DocumentURI("file:///notes/test.org")
+
+// openTestDocument returns a server with text open as testDocURI
+func openTestDocument(t *testing.T, text string) *ServerImpl {
+	t.Helper()
+
+	s := New()
+	s.state = &State{
+		OpenDocs:    map[protocol.DocumentURI]*org.Document{},
+		RawContent:  map[protocol.DocumentURI]string{},
+		DocVersions: map[protocol.DocumentURI]int32{},
+		CRLF:        map[protocol.DocumentURI]bool{},
+		LineStarts:  map[protocol.DocumentURI][]int{},
+	}
+	testza.AssertNoError(t, s.DidOpen(context.Background(), &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: testDocURI, LanguageID: "org", Version: 1, Text: text},
+	}))
+	return s
+}
+
+// changeTestDocument sends changes to testDocURI
+func changeTestDocument(t *testing.T, s *ServerImpl, changes ...protocol.TextDocumentContentChangeEvent) {
+	t.Helper()
+
+	testza.AssertNoError(t, s.DidChange(context.Background(), &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: testDocURI},
+			Version:                s.state.DocVersions[testDocURI] + 1,
+		},
+		ContentChanges: changes,
+	}))
+}
+
+func TestIncrementalTextSync(t *testing.T) {
+	s := openTestDocument(t, "* First heading\nSome text here.\n* Second heading\n")
+
+	// Replace "Some" with "Other", then insert a line before the second heading
+	first := protocol.Range{Start: protocol.Position{Line: 1, Character: 0}, End: protocol.Position{Line: 1, Character: 4}}
+	second := protocol.Range{Start: protocol.Position{Line: 2, Character: 0}, End: protocol.Position{Line: 2, Character: 0}}
+	changeTestDocument(t, s,
+		protocol.TextDocumentContentChangeEvent{Range: &first, Text: "Other"},
+		protocol.TextDocumentContentChangeEvent{Range: &second, Text: "More text.\n"},
+	)
+	testza.AssertEqual(t, "* First heading\nOther text here.\nMore text.\n* Second heading\n", s.state.RawContent[testDocURI])
+
+	// A change without a range replaces the whole document
+	changeTestDocument(t, s, protocol.TextDocumentContentChangeEvent{Text: "* Replaced\n"})
+	testza.AssertEqual(t, "* Replaced\n", s.state.RawContent[testDocURI])
+}
+
+func TestIncrementalTextSyncUTF16Columns(t *testing.T) {
+	s := openTestDocument(t, "* Café 🎉 notes\n")
+
+	// "* Café 🎉 " is 10 UTF-16 code units (the emoji is a surrogate pair)
+	r := protocol.Range{Start: protocol.Position{Line: 0, Character: 10}, End: protocol.Position{Line: 0, Character: 15}}
+	changeTestDocument(t, s, protocol.TextDocumentContentChangeEvent{Range: &r, Text: "plans"})
+	testza.AssertEqual(t, "* Café 🎉 plans\n", s.state.RawContent[testDocURI])
+}
+
+func TestCRLFDocumentsAreStoredWithLF(t *testing.T) {
+	s := openTestDocument(t, "* Source   \r\nSee [[id:\r\nMore text.\r\n")
+	testza.AssertEqual(t, "* Source   \nSee [[id:\nMore text.\n", s.state.RawContent[testDocURI])
+	testza.AssertTrue(t, s.state.CRLF[testDocURI])
+
+	r := protocol.Range{Start: protocol.Position{Line: 2}, End: protocol.Position{Line: 2}}
+	changeTestDocument(t, s, protocol.TextDocumentContentChangeEvent{Range: &r, Text: "Inserted.\r\n"})
+	testza.AssertEqual(t, "* Source   \nSee [[id:\nInserted.\nMore text.\n", s.state.RawContent[testDocURI])
+	testza.AssertTrue(t, s.state.CRLF[testDocURI])
+}
+
+func TestLineIndexAfterIncrementalEdits(t *testing.T) {
+	s := openTestDocument(t, "* Notes\nFirst line.\n")
+	// Add lines, join two, then start a block on the last one
+	for _, change := range []struct {
+		r    protocol.Range
+		text string
+	}{
+		{protocol.Range{Start: protocol.Position{Line: 2}, End: protocol.Position{Line: 2}}, "Second line.\nThird line.\n"},
+		{protocol.Range{Start: protocol.Position{Line: 1, Character: 11}, End: protocol.Position{Line: 2}}, " "},
+		{protocol.Range{Start: protocol.Position{Line: 3}, End: protocol.Position{Line: 3}}, "#+begin_s"},
+	} {
+		changeTestDocument(t, s, protocol.TextDocumentContentChangeEvent{Range: &change.r, Text: change.text})
+	}
+
+	content := s.state.RawContent[testDocURI]
+	testza.AssertEqual(t, "* Notes\nFirst line. Second line.\nThird line.\n#+begin_s", content)
+
+	var lines []string
+	for i := range s.state.LineStarts[testDocURI] {
+		line, ok := documentLine(s.state, testDocURI, i)
+		testza.AssertTrue(t, ok)
+		lines = append(lines, line)
+	}
+	testza.AssertEqual(t, strings.Split(content, "\n"), lines)
+}
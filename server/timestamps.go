@@ -0,0 +1,125 @@
+package server
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// relativeDates are the dates offered when completing a timestamp, as days
+// from today
+var relativeDates = []struct {
+	Keyword, Detail string
+	Days            int
+}{
+	{"today", "Today", 0},
+	{"+1d", "Tomorrow", 1},
+	{"+1w", "In a week", 7},
+}
+
+// partialTimestampRegexp matches an active or inactive timestamp opener at
+// the start of a word followed by the start of "today" or of an offset like
+// "+3d". A bare opener doesn't match, so checkboxes ("- [") and comparisons
+// ("a < b") don't offer dates.
+var partialTimestampRegexp = regexp.MustCompile(`(?:^|\s)([<\[])(t|to|tod|toda|today|\+\d*[dwmy]?)$`)
+
+// timestampOffsetRegexp matches a complete relative date offset: a count of
+// days, weeks, months, or years from today
+var timestampOffsetRegexp = regexp.MustCompile(`^\+(\d+)([dwmy])$`)
+
+// detectTimestampContext checks if cursor is right after a "<" or "[" and a
+// relative date being typed, outside of src and example blocks
+func detectTimestampContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	if block, found := findNodeAtPosition[org.Block](doc, pos); found {
+		if name := strings.ToLower(block.Name); name == "src" || name == "example" {
+			return ctx
+		}
+	}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+
+	match := partialTimestampRegexp.FindStringSubmatch(line[:pos.Character])
+	if match == nil {
+		return ctx
+	}
+
+	closer := ">"
+	if match[1] == "[" {
+		closer = "]"
+	}
+	ctx.Type = ContextTypeTimestamp
+	ctx.TypedPrefix = match[1]
+	ctx.FilterPrefix = match[2]
+	ctx.NeedsClosingBracket = !strings.HasPrefix(line[pos.Character:], closer)
+	return ctx
+}
+
+// completeTimestamps offers the relative dates, each expanding to an org
+// timestamp with its weekday, active or inactive to match the opener typed
+func completeTimestamps(ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	now := time.Now()
+	opener, closer := "<", ">"
+	if ctx.TypedPrefix == "[" {
+		opener, closer = "[", "]"
+	}
+
+	startChar := max(int(pos.Character)-len(ctx.FilterPrefix)-len(opener), 0)
+	endChar := int(pos.Character)
+	if !ctx.NeedsClosingBracket {
+		endChar++ // replace the closer already after the cursor
+	}
+
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: uint32(startChar)},
+		End:   protocol.Position{Line: pos.Line, Character: uint32(endChar)},
+	}
+	item := func(keyword, detail, sortText string, date time.Time) protocol.CompletionItem {
+		timestamp := opener + date.Format("2006-01-02 Mon") + closer
+		return protocol.CompletionItem{
+			Label:      opener + keyword + closer,
+			Kind:       protocol.CompletionItemKindValue,
+			Detail:     detail + ": " + timestamp,
+			FilterText: opener + keyword,
+			SortText:   sortText,
+			TextEdit:   &protocol.TextEdit{Range: editRange, NewText: timestamp},
+		}
+	}
+
+	var items []protocol.CompletionItem
+	offered := false
+	for i, date := range relativeDates {
+		if !strings.HasPrefix(date.Keyword, ctx.FilterPrefix) {
+			continue
+		}
+		offered = offered || date.Keyword == ctx.FilterPrefix
+		items = append(items, item(date.Keyword, date.Detail, string(rune('a'+i)), now.AddDate(0, 0, date.Days)))
+	}
+
+	// Any other complete offset, like +3d or +2m, expands as typed
+	if m := timestampOffsetRegexp.FindStringSubmatch(ctx.FilterPrefix); m != nil && !offered {
+		n, _ := strconv.Atoi(m[1])
+		date := now.AddDate(0, 0, n)
+		switch m[2] {
+		case "w":
+			date = now.AddDate(0, 0, 7*n)
+		case "m":
+			date = now.AddDate(0, n, 0)
+		case "y":
+			date = now.AddDate(n, 0, 0)
+		}
+		items = append(items, item(ctx.FilterPrefix, "In "+ctx.FilterPrefix[1:], "a", date))
+	}
+
+	slog.Debug("Timestamp completion generated", "itemCount", len(items), "filter", ctx.FilterPrefix)
+	return items
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/alexispurslane/go-org/org"
+	"github.com/alexispurslane/org-lsp/orgscanner"
+	protocol "go.lsp.dev/protocol"
+)
+
+// parseOrgDocument parses content, recognizing the TODO keywords it defines
+// or, failing that, the workspace's configured keywords
+func parseOrgDocument(content, path string, cfg Config) *org.Document {
+	return orgscanner.ParseDocument(content, path, cfg.TodoKeywords)
+}
+
+// todoKeywords splits doc's TODO sequences into not-done and done keywords.
+// In each sequence the keywords after "|" are done, or the last keyword when
+// there is no "|". Fast-access keys like "DONE(d)" are stripped.
+func todoKeywords(doc *org.Document) (active, done []string) {
+	for _, sequence := range strings.Split(doc.Get("TODO"), "\n") {
+		todo, finished, found := strings.Cut(sequence, "|")
+		if !found {
+			keywords := strings.Fields(sequence)
+			if len(keywords) == 0 {
+				continue
+			}
+			todo = strings.Join(keywords[:len(keywords)-1], " ")
+			finished = keywords[len(keywords)-1]
+		}
+		for _, keyword := range strings.Fields(todo) {
+			name, _, _ := strings.Cut(keyword, "(")
+			active = append(active, name)
+		}
+		for _, keyword := range strings.Fields(finished) {
+			name, _, _ := strings.Cut(keyword, "(")
+			done = append(done, name)
+		}
+	}
+	return active, done
+}
+
+// detectTodoContext checks if cursor is on a partial word right after a
+// headline's stars, where its TODO keyword goes. The word must be a prefix
+// of one of the document's keywords, so typing an ordinary title falls
+// through to tag completion.
+func detectTodoContext(state *State, doc *org.Document, uri protocol.DocumentURI, pos protocol.Position) CompletionContext {
+	ctx := CompletionContext{Type: ContextTypeNone}
+
+	line, found := documentLine(state, uri, int(pos.Line))
+	if !found || int(pos.Character) > len(line) {
+		return ctx
+	}
+	before := line[:pos.Character]
+
+	rest := strings.TrimLeft(before, "*")
+	if rest == before || !strings.HasPrefix(rest, " ") {
+		return ctx
+	}
+	rest = strings.TrimLeft(rest, " ")
+	if strings.ContainsAny(rest, " \t") {
+		return ctx
+	}
+
+	active, done := todoKeywords(doc)
+	for _, keyword := range append(active, done...) {
+		if strings.HasPrefix(keyword, strings.ToUpper(rest)) {
+			ctx.Type = ContextTypeTodo
+			ctx.FilterPrefix = rest
+			return ctx
+		}
+	}
+	return ctx
+}
+
+// completeTodoKeywords returns the document's TODO keywords, not-done ones
+// first, each replacing the partial word typed so far and followed by a space
+func completeTodoKeywords(doc *org.Document, ctx CompletionContext, pos protocol.Position) []protocol.CompletionItem {
+	editRange := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: uint32(max(int(pos.Character)-len(ctx.FilterPrefix), 0))},
+		End:   pos,
+	}
+
+	active, done := todoKeywords(doc)
+	var items []protocol.CompletionItem
+	add := func(keyword, detail string) {
+		if !strings.HasPrefix(keyword, strings.ToUpper(ctx.FilterPrefix)) {
+			return
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:    keyword,
+			Kind:     protocol.CompletionItemKindKeyword,
+			Detail:   detail,
+			SortText: string(rune('a' + min(len(items), 25))),
+			TextEdit: &protocol.TextEdit{Range: editRange, NewText: keyword + " "},
+		})
+	}
+	for _, keyword := range active {
+		add(keyword, "TODO keyword")
+	}
+	for _, keyword := range done {
+		add(keyword, "Done keyword")
+	}
+	return items
+}
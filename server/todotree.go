@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// todoTreeCommand returns the outline of the open document at [uri] reduced
+// to headings with a not-done TODO keyword, plus their ancestors, as a nested
+// DocumentSymbol tree. Clients can render it as a task-only sparse tree.
+func (s *ServerImpl) todoTreeCommand(ctx context.Context, args []any) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("expected [uri] argument, got %d", len(args))
+	}
+	uri, ok := args[0].(string)
+	if !ok || uri == "" {
+		return nil, fmt.Errorf("invalid uri argument: %v", args[0])
+	}
+
+	s.state.Mu.RLock()
+	defer s.state.Mu.RUnlock()
+
+	doc, ok := s.state.OpenDocs[protocol.DocumentURI(uri)]
+	if !ok {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+
+	done := doneKeywords(doc)
+	symbols := sectionsToSymbols(doc.Outline.Children, done, func(section *org.Section) bool {
+		status := section.Headline.Status
+		return status != "" && !done[status]
+	})
+	if symbols == nil {
+		symbols = []protocol.DocumentSymbol{}
+	}
+
+	slog.Debug("TODO tree generated", "uri", uri, "topLevel", len(symbols))
+	return symbols, nil
+}
+
+// doneKeywords returns the set of TODO keywords of doc that mark a heading
+// as done, see todoKeywords
+func doneKeywords(doc *org.Document) map[string]bool {
+	_, finished := todoKeywords(doc)
+	done := make(map[string]bool, len(finished))
+	for _, keyword := range finished {
+		done[keyword] = true
+	}
+	return done
+}
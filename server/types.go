@@ -20,28 +20,55 @@ type LinkNode struct {
 type CompletionContextType string
 
 const (
-	ContextTypeNone   CompletionContextType = ""       // No completion context
-	ContextTypeID     CompletionContextType = "id"     // ID link completion [[id:...]]
-	ContextTypeTag    CompletionContextType = "tag"    // Tag completion in headlines
-	ContextTypeFile   CompletionContextType = "file"   // File link completion [[file:...]]
-	ContextTypeBlock  CompletionContextType = "block"  // Block type completion #+begin_
-	ContextTypeExport CompletionContextType = "export" // Export block completion #+begin_export_
+	ContextTypeNone     CompletionContextType = ""         // No completion context
+	ContextTypeID       CompletionContextType = "id"       // ID link completion [[id:...]]
+	ContextTypeTag      CompletionContextType = "tag"      // Tag completion in headlines
+	ContextTypeFile     CompletionContextType = "file"     // File link completion [[file:...]]
+	ContextTypeBlock    CompletionContextType = "block"    // Block type completion #+begin_
+	ContextTypeExport   CompletionContextType = "export"   // Export block completion #+begin_export_
+	ContextTypeCitation CompletionContextType = "citation" // Citation key completion [cite:@...]
+	ContextTypeEntity   CompletionContextType = "entity"   // Entity completion \alpha
+
+	ContextTypePropertyValue CompletionContextType = "propertyValue" // Property value completion :KEY: ...
+	ContextTypeDenote        CompletionContextType = "denote"        // Denote link completion [[denote:...
+	ContextTypeInclude       CompletionContextType = "include"       // Include path completion #+INCLUDE: "...
+	ContextTypePriority      CompletionContextType = "priority"      // Priority cookie completion * TODO [#...
+	ContextTypeInternalLink  CompletionContextType = "internalLink"  // Heading/target completion [[*...
+	ContextTypeRoam          CompletionContextType = "roam"          // org-roam ref completion [[roam:...
+	ContextTypeTodo          CompletionContextType = "todo"          // TODO keyword completion * NE...
+	ContextTypeDrawer        CompletionContextType = "drawer"        // Property drawer completion :PROP...
+	ContextTypeTimestamp     CompletionContextType = "timestamp"     // Relative date completion <today>
 )
 
 // CompletionContext holds detailed context for code completion
 type CompletionContext struct {
 	Type                CompletionContextType
-	FilterPrefix        string // Text typed after the prefix for filtering
-	NeedsClosingBracket bool   // True if trigger was "[[" and needs "]]" inserted
+	FilterPrefix        string   // Text typed after the prefix for filtering
+	NeedsClosingBracket bool     // True if trigger was "[[" and needs "]]" inserted
+	PropertyKey         string   // Upper-cased key whose value is being completed
+	NeedsOpeningQuote   bool     // True if an #+INCLUDE: path has no opening quote yet
+	NeedsClosingQuote   bool     // True if an #+INCLUDE: path has no closing quote after cursor
+	TypedPrefix         string   // Keyword prefix as typed, e.g. "#+BEGIN_", to match its case
+	ExistingTags        []string // Tags already on the headline being completed
 }
 
 // State holds the global server state
 type State struct {
 	Mu          sync.RWMutex
-	OrgScanRoot string
+	OrgScanRoot string   // Base root; index file paths are relative to it
+	Roots       []string // All workspace roots, OrgScanRoot first
 	Scanner     *orgscanner.OrgScanner
 	OpenDocs    map[protocol.DocumentURI]*org.Document
 	RawContent  map[protocol.DocumentURI]string
 	DocVersions map[protocol.DocumentURI]int32
-	Client      protocol.Client // LSP client for sending notifications
+	CRLF        map[protocol.DocumentURI]bool  // Documents the client holds with \r\n line endings
+	LineStarts  map[protocol.DocumentURI][]int // Byte offset of each line in RawContent
+	Client      protocol.Client                // LSP client for sending notifications
+	Config      Config                         // Settings from initializationOptions
+	WatchFiles  bool                           // Client supports registering file watchers
+	Snippets    bool                           // Client supports snippet completion items
+	PlainHover  bool                           // Client can't render markdown in hovers
+
+	IncludeFiles         includeFileCache     // Files offered by #+INCLUDE: completion
+	WorkspaceDiagnostics workspaceDiagnostics // Closed files with published problems
 }
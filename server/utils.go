@@ -3,6 +3,8 @@ package server
 import (
 	"net/url"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/alexispurslane/go-org/org"
 	"github.com/alexispurslane/org-lsp/orgscanner"
@@ -47,6 +49,66 @@ func pathToURI(path string) string {
 	return "file://" + filepath.ToSlash(absPath)
 }
 
+// ownerRoot returns the workspace root containing path, preferring the
+// deepest one when roots are nested, or OrgScanRoot if no root contains it
+func ownerRoot(state *State, path string) string {
+	owner := state.OrgScanRoot
+	longest := -1
+	for _, root := range state.Roots {
+		if !isWithin(root, path) {
+			continue
+		}
+		if len(root) > longest {
+			owner, longest = root, len(root)
+		}
+	}
+	return owner
+}
+
+// inWorkspace reports whether path is inside one of the workspace roots once
+// symlinks are followed, so a link in the workspace pointing elsewhere
+// doesn't count as inside it
+func inWorkspace(state *State, path string) bool {
+	path = resolveSymlinks(path)
+	for _, root := range state.Roots {
+		if isWithin(resolveSymlinks(root), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSymlinks returns path with its symlinks resolved. Trailing parts
+// that don't exist yet, like a file about to be created, are kept as
+// written below the deepest ancestor that does.
+func resolveSymlinks(path string) string {
+	path = filepath.Clean(path)
+	var missing []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, missing...)...)
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// isWithin reports whether path is root or somewhere below it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// indexPathToAbs resolves a file path from the scanner index, which is
+// relative to OrgScanRoot, to an absolute path
+func indexPathToAbs(state *State, indexPath string) string {
+	return filepath.Clean(filepath.Join(state.OrgScanRoot, indexPath))
+}
+
 func collectChildren(node org.Node) []org.Node {
 	var nodes []org.Node
 	node.Range(func(n org.Node) bool {
@@ -102,7 +164,9 @@ func findNodesInRange(nodes []org.Node, startLine, endLine int) []org.Node {
 	return results
 }
 
-// findNodeAtPosition searches for a node of type T at the given cursor position
+// findNodeAtPosition searches for a node of type T at the given cursor
+// position. Subtrees whose lines don't include the cursor are skipped, which
+// keeps lookups in large documents down to the path to the cursor.
 func findNodeAtPosition[T org.Node](doc *org.Document, pos protocol.Position) (*T, bool) {
 	if doc == nil {
 		var zero T
@@ -145,6 +209,13 @@ func findNodeAtPosition[T org.Node](doc *org.Document, pos protocol.Position) (*
 			}
 		}
 
+		// Children lie within their parent's lines, so a subtree that
+		// doesn't reach the cursor's line can't contain a match. Nodes
+		// without a position are always descended into.
+		if nodePos != (org.Position{}) && (targetLine < nodePos.StartLine || targetLine > nodePos.EndLine) {
+			return
+		}
+
 		node.Range(func(n org.Node) bool {
 			walkNodes(n, currentDepth+1)
 			return true
@@ -209,3 +280,33 @@ func toProtocolRange(pos org.Position) protocol.Range {
 		},
 	}
 }
+
+var (
+	markdownBoldRegexp   = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	markdownItalicRegexp = regexp.MustCompile(`\*([^*\s][^*\n]*)\*`)
+	markdownCodeRegexp   = regexp.MustCompile("`([^`\n]*)`")
+	markdownLinkRegexp   = regexp.MustCompile(`\[([^\]\n]*)\]\(([^)\n]*)\)`)
+)
+
+// markdownToPlaintext strips the markdown this server writes into hovers:
+// code fences are dropped, keeping their contents verbatim, and emphasis,
+// inline code, and links elsewhere are reduced to their text.
+func markdownToPlaintext(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	plain := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			line = markdownLinkRegexp.ReplaceAllString(line, "$1 ($2)")
+			line = markdownCodeRegexp.ReplaceAllString(line, "$1")
+			line = markdownBoldRegexp.ReplaceAllString(line, "$1")
+			line = markdownItalicRegexp.ReplaceAllString(line, "$1")
+		}
+		plain = append(plain, line)
+	}
+	return strings.Join(plain, "\n")
+}
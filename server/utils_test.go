@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MarvinJWendt/testza"
+	"github.com/alexispurslane/go-org/org"
+	protocol "go.lsp.dev/protocol"
+)
+
+// largeDocumentHeadings is how many sections largeDocument has, at ten
+// lines each
+const largeDocumentHeadings = 1000
+
+// largeDocument builds a 10k line document of headings, each with a
+// property drawer, a list, and a paragraph ending in a link to the next
+func largeDocument() *org.Document {
+	var b strings.Builder
+	for i := range largeDocumentHeadings {
+		fmt.Fprintf(&b, "* Heading %d :tag:\n", i)
+		fmt.Fprintf(&b, ":PROPERTIES:\n:ID: id-%d\n:END:\n", i)
+		b.WriteString("- first item\n- second item\n\n")
+		b.WriteString("Some text before the link,\n")
+		fmt.Fprintf(&b, "and then [[id:id-%d][the next one]].\n", i+1)
+		b.WriteString("\n")
+	}
+	return parseOrgDocument(b.String(), "large.org", Config{})
+}
+
+// linkPosition returns the position of the link in the section of heading i
+func linkPosition(i int) protocol.Position {
+	return protocol.Position{Line: uint32(i*10 + 8), Character: 12}
+}
+
+func TestFindNodeAtPositionLargeDocument(t *testing.T) {
+	doc := largeDocument()
+
+	for _, i := range []int{0, largeDocumentHeadings / 2, largeDocumentHeadings - 1} {
+		link, found := findNodeAtPosition[org.RegularLink](doc, linkPosition(i))
+		testza.AssertTrue(t, found, "Expected link in section %d", i)
+		testza.AssertEqual(t, fmt.Sprintf("id:id-%d", i+1), link.URL)
+
+		headline, found := findNodeAtPosition[org.Headline](doc, linkPosition(i))
+		testza.AssertTrue(t, found, "Expected headline around section %d", i)
+		testza.AssertEqual(t, fmt.Sprintf("Heading %d", i), org.String(headline.Title...))
+	}
+
+	_, found := findNodeAtPosition[org.RegularLink](doc, protocol.Position{Line: 4, Character: 0})
+	testza.AssertFalse(t, found, "Expected no link on a list item")
+}
+
+func BenchmarkFindNodeAtPosition(b *testing.B) {
+	doc := largeDocument()
+	pos := linkPosition(largeDocumentHeadings - 1)
+
+	for b.Loop() {
+		findNodeAtPosition[org.RegularLink](doc, pos)
+	}
+}